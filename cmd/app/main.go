@@ -14,17 +14,27 @@ import (
 	"github.com/tsel-ticketmaster/tm-user/internal/module/adminapp/admin"
 	"github.com/tsel-ticketmaster/tm-user/internal/module/customerapp/customer"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/jwt"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/lockout"
 	internalMiddleare "github.com/tsel-ticketmaster/tm-user/internal/pkg/middleware"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/passkey"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/password"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
 	"github.com/tsel-ticketmaster/tm-user/pkg/applogger"
+	"github.com/tsel-ticketmaster/tm-user/pkg/captcha"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
 	"github.com/tsel-ticketmaster/tm-user/pkg/kafka"
 	"github.com/tsel-ticketmaster/tm-user/pkg/middleware"
 	"github.com/tsel-ticketmaster/tm-user/pkg/monitoring"
+	"github.com/tsel-ticketmaster/tm-user/pkg/oidc"
 	"github.com/tsel-ticketmaster/tm-user/pkg/postgresql"
 	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
 	"github.com/tsel-ticketmaster/tm-user/pkg/redis"
 	"github.com/tsel-ticketmaster/tm-user/pkg/server"
+	"github.com/tsel-ticketmaster/tm-user/pkg/sms"
+	"github.com/tsel-ticketmaster/tm-user/pkg/storage"
 	"github.com/tsel-ticketmaster/tm-user/pkg/validator"
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
@@ -54,6 +64,14 @@ func main() {
 
 	mon.Start(ctx)
 
+	clientinfo.SetTrustedProxyHops(c.Application.TrustedProxyHops)
+	middleware.SetEmailKeyOptions(emailaddress.Options{GmailRules: c.Customer.EmailGmailRules})
+
+	validator.SetPasswordPolicy(validator.PasswordPolicy{
+		MinLength:           c.PasswordPolicy.MinLength,
+		MaxLength:           c.PasswordPolicy.MaxLength,
+		MinCharacterClasses: c.PasswordPolicy.MinCharacterClasses,
+	})
 	validate := validator.Get()
 
 	jsonWebToken := jwt.NewJSONWebToken(c.JWT.PrivateKey, c.JWT.PublicKey)
@@ -70,45 +88,152 @@ func main() {
 		logger.WithContext(ctx).WithError(err).Error()
 	}
 
+	refreshToken := session.NewRedisRefreshTokenStore(logger, rc, c.JWT.RefreshTokenTTL)
 	session := session.NewRedisSessionStore(logger, rc)
+	signInLockout := lockout.NewRedisLockout(logger, rc, lockout.Config{
+		MaxAttempts:   c.Lockout.MaxAttempts,
+		IPMaxAttempts: c.Lockout.IPMaxAttempts,
+		DelayAfter:    c.Lockout.DelayAfter,
+		BaseDelay:     c.Lockout.BaseDelay,
+		MaxDelay:      c.Lockout.MaxDelay,
+		Duration:      c.Lockout.Duration,
+		Window:        c.Lockout.Window,
+	})
 
 	adminSessionMiddleware := internalMiddleare.NewAdminSessionMiddleware(jsonWebToken, session)
-	customerSessionMiddleware := internalMiddleare.NewCustomerSessionMiddleware(jsonWebToken, session)
+	customerappCustomerRepository := customer.NewCustomerRepository(logger, psqldb)
+	customerSessionMiddleware := internalMiddleare.NewCustomerSessionMiddleware(jsonWebToken, session, customer.NewMemberStatusChecker(logger, rc, customerappCustomerRepository))
+
+	rateLimiter := middleware.NewRateLimiter(logger, rc)
+	idempotency := middleware.NewIdempotency(logger, rc, c.Crypto.Secret, c.Idempotency.TTL)
+
+	humanVerifier, err := captcha.NewHumanVerifier(c.Captcha.Verifier, nil)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Fatal()
+	}
+	humanChallenge, err := captcha.NewHumanVerifier(c.Captcha.Challenge, nil)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Fatal()
+	}
+	humanVerification := middleware.NewHumanVerification(logger, humanVerifier, humanChallenge, c.Captcha.Policies)
+
+	passwordParams := password.Params{
+		Memory:      c.Password.Memory,
+		Iterations:  c.Password.Iterations,
+		Parallelism: c.Password.Parallelism,
+		SaltLength:  c.Password.SaltLength,
+		KeyLength:   c.Password.KeyLength,
+	}
+	adminPasswordHasher := password.NewArgon2idHasher(passwordParams, c.Crypto.Secret, password.LegacyPBKDF2{KeyLength: 32})
+	customerPasswordHasher := password.NewArgon2idHasher(passwordParams, c.Crypto.Secret, password.LegacyPBKDF2{Pepper: c.Crypto.Secret, KeyLength: 256})
 
 	router := mux.NewRouter()
 	router.Use(
 		otelmux.Middleware(c.Application.Name),
 		middleware.HTTPResponseTraceInjection,
+		middleware.HTTPRequestClientInfoInjection,
 		middleware.NewHTTPRequestLogger(logger, c.Application.Debug, http.StatusInternalServerError).Middleware,
 		middleware.NewRecovery(logger, false).Middleware,
 	)
 
+	passkeyStore := passkey.NewPasskey(logger, rc, psqldb, webauthn.NewRelyingParty(webauthn.Config{
+		RPID:    c.WebAuthn.RPID,
+		RPName:  c.WebAuthn.RPName,
+		Origins: c.WebAuthn.Origins,
+		Timeout: c.WebAuthn.Timeout,
+	}), c.Crypto.Secret)
+
 	// admin's app
 	adminappAdminRepository := admin.NewAdminRepository(logger, psqldb)
 	adminappAdminUseCase := admin.NewAdminUseCase(admin.AdminUseCaseProperty{
-		Logger:          logger,
-		Timeout:         c.Application.Timeout,
-		JSONWebToken:    jsonWebToken,
-		Session:         session,
-		AdminRepository: adminappAdminRepository,
+		Logger:               logger,
+		Timeout:              c.Application.Timeout,
+		JSONWebToken:         jsonWebToken,
+		Session:              session,
+		RefreshToken:         refreshToken,
+		Lockout:              signInLockout,
+		PasswordHasher:       adminPasswordHasher,
+		AdminRepository:      adminappAdminRepository,
+		Passkey:              passkeyStore,
+		CustomerEmailOptions: emailaddress.Options{GmailRules: c.Customer.EmailGmailRules},
 	})
-	admin.InitHTTPHandler(router, adminSessionMiddleware, validate, adminappAdminUseCase)
+	admin.InitHTTPHandler(router, adminSessionMiddleware, rateLimiter, validate, adminappAdminUseCase)
+
+	var objectStorage storage.ObjectStorage
+	switch c.Storage.Driver {
+	case "gcs":
+		gcsStorage, err := storage.NewGCSStorage(ctx, c.Storage.GCSBucket, c.Storage.BaseURL, c.GCP.ServiceAccount)
+		if err != nil {
+			logger.WithContext(ctx).WithError(err).Fatal()
+		}
+		objectStorage = gcsStorage
+	default:
+		// the local files are served by the app itself, it is meant for development only.
+		objectStorage = storage.NewLocalStorage(c.Storage.LocalDir, c.Storage.BaseURL)
+		router.PathPrefix("/tm-user/v1/files/").Handler(http.StripPrefix("/tm-user/v1/files/", http.FileServer(http.Dir(c.Storage.LocalDir)))).Methods(http.MethodGet)
+	}
 
 	// customer's app
-	customerappCustomerRepository := customer.NewCustomerRepository(logger, psqldb)
+	oidcProviders := make(map[string]*oidc.Provider)
+	for _, providerConfig := range c.OIDC.Providers {
+		oidcProviders[providerConfig.Name] = oidc.NewProvider(providerConfig, nil)
+	}
+
+	disposableEmailDomains, err := emailaddress.NewBlocklist(logger, c.Customer.DisposableEmailDomainsFile, c.Customer.DisposableEmailDomains)
+	if err != nil {
+		logger.WithContext(ctx).WithError(err).Fatal()
+	}
+
+	customerappCustomerIdentityRepository := customer.NewCustomerIdentityRepository(logger, psqldb)
+	customerappCustomerSensitiveChangeRepository := customer.NewCustomerSensitiveChangeRepository(logger, psqldb)
+	customerappCustomerLoginEventRepository := customer.NewCustomerLoginEventRepository(logger, psqldb)
+	customerappCustomerConsentRepository := customer.NewCustomerConsentRepository(logger, psqldb)
+	customerappLegalDocumentRepository := customer.NewLegalDocumentRepository(logger, psqldb)
 	customerappCustomerUseCase := customer.NewCustomerUseCase(customer.CustomerUseCaseProperty{
-		AppName:            CustomerApp,
-		Logger:             logger,
-		Timeout:            c.Application.Timeout,
-		TMUserBaseURL:      c.Application.TMUser.BaseURL,
-		CryptoSecret:       c.Crypto.Secret,
-		JSONWebToken:       jsonWebToken,
-		Session:            session,
-		Cache:              rc,
-		Publisher:          publisher,
-		CustomerRepository: customerappCustomerRepository,
+		AppName:                           CustomerApp,
+		Logger:                            logger,
+		Timeout:                           c.Application.Timeout,
+		TMUserBaseURL:                     c.Application.TMUser.BaseURL,
+		CryptoSecret:                      c.Crypto.Secret,
+		JSONWebToken:                      jsonWebToken,
+		Session:                           session,
+		RefreshToken:                      refreshToken,
+		Lockout:                           signInLockout,
+		PasswordHasher:                    customerPasswordHasher,
+		DeletionGracePeriod:               c.Customer.DeletionGracePeriod,
+		OIDCProviders:                     oidcProviders,
+		SMSSender:                         sms.NewPubSubSender(publisher, "customer-sms", CustomerApp),
+		Passkey:                           passkeyStore,
+		EmailOptions:                      emailaddress.Options{GmailRules: c.Customer.EmailGmailRules},
+		DisposableEmailDomains:            disposableEmailDomains,
+		ObjectStorage:                     objectStorage,
+		Cache:                             rc,
+		Publisher:                         publisher,
+		CustomerRepository:                customerappCustomerRepository,
+		CustomerIdentityRepository:        customerappCustomerIdentityRepository,
+		CustomerSensitiveChangeRepository: customerappCustomerSensitiveChangeRepository,
+		CustomerLoginEventRepository:      customerappCustomerLoginEventRepository,
+		CustomerConsentRepository:         customerappCustomerConsentRepository,
+		LegalDocumentRepository:           customerappLegalDocumentRepository,
+	})
+	customer.InitHTTPHandler(router, customerSessionMiddleware, rateLimiter, idempotency, humanVerification, validate, customerappCustomerUseCase)
+	customer.InitAdminHTTPHandler(router, adminSessionMiddleware, validate, customerappCustomerUseCase)
+	customerappErasureJob := customer.NewErasureJob(customer.ErasureJobProperty{
+		AppName:                           CustomerApp,
+		Logger:                            logger,
+		GracePeriod:                       c.Customer.DeletionGracePeriod,
+		Interval:                          c.Customer.ErasureInterval,
+		BatchSize:                         c.Customer.ErasureBatchSize,
+		Cache:                             rc,
+		Publisher:                         publisher,
+		CustomerRepository:                customerappCustomerRepository,
+		CustomerIdentityRepository:        customerappCustomerIdentityRepository,
+		CustomerSensitiveChangeRepository: customerappCustomerSensitiveChangeRepository,
+		CustomerLoginEventRepository:      customerappCustomerLoginEventRepository,
+		CustomerConsentRepository:         customerappCustomerConsentRepository,
+		Passkey:                           passkeyStore,
+		ObjectStorage:                     objectStorage,
 	})
-	customer.InitHTTPHandler(router, customerSessionMiddleware, validate, customerappCustomerUseCase)
 
 	handler := middleware.SetChain(
 		router,
@@ -134,10 +259,15 @@ func main() {
 		srv.ListenAndServe()
 	}()
 
+	jobCtx, cancelJob := context.WithCancel(ctx)
+	go customerappErasureJob.Run(jobCtx)
+	go disposableEmailDomains.Watch(jobCtx, c.Customer.DisposableEmailDomainsReloadInterval)
+
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
 	<-sigterm
 
+	cancelJob()
 	srv.Shutdown(ctx)
 	publisher.Close()
 	psqldb.Close()
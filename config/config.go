@@ -9,6 +9,8 @@ import (
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/tsel-ticketmaster/tm-user/pkg/captcha"
+	"github.com/tsel-ticketmaster/tm-user/pkg/oidc"
 )
 
 var (
@@ -18,12 +20,13 @@ var (
 
 type Config struct {
 	Application struct {
-		Name        string
-		Port        int
-		Environment string
-		Debug       bool
-		Timeout     time.Duration
-		TMUser      struct {
+		Name             string
+		Port             int
+		Environment      string
+		Debug            bool
+		Timeout          time.Duration
+		TrustedProxyHops int
+		TMUser           struct {
 			BaseURL string
 		}
 	}
@@ -44,8 +47,9 @@ type Config struct {
 		AllowCredentials bool
 	}
 	JWT struct {
-		PrivateKey []byte
-		PublicKey  []byte
+		PrivateKey      []byte
+		PublicKey       []byte
+		RefreshTokenTTL time.Duration
 	}
 	Postgresql struct {
 		Host         string
@@ -63,6 +67,14 @@ type Config struct {
 		Password string
 		DB       int
 	}
+	Idempotency struct {
+		TTL time.Duration
+	}
+	Captcha struct {
+		Verifier  captcha.Config
+		Challenge captcha.Config
+		Policies  map[string]captcha.Policy
+	}
 	Kafka struct {
 		Hosts            string
 		SecurityProtocol string
@@ -75,9 +87,54 @@ type Config struct {
 		ProjectID      string
 		ServiceAccount []byte
 	}
+	Storage struct {
+		Driver    string
+		LocalDir  string
+		BaseURL   string
+		GCSBucket string
+	}
+	WebAuthn struct {
+		RPID    string
+		RPName  string
+		Origins []string
+		Timeout time.Duration
+	}
 	Admin struct {
 		DefaultPassword string
 	}
+	Customer struct {
+		DeletionGracePeriod                  time.Duration
+		ErasureInterval                      time.Duration
+		ErasureBatchSize                     int
+		EmailGmailRules                      bool
+		DisposableEmailDomains               []string
+		DisposableEmailDomainsFile           string
+		DisposableEmailDomainsReloadInterval time.Duration
+	}
+	OIDC struct {
+		Providers []oidc.Config
+	}
+	Password struct {
+		Memory      uint32
+		Iterations  uint32
+		Parallelism uint8
+		SaltLength  uint32
+		KeyLength   uint32
+	}
+	PasswordPolicy struct {
+		MinLength           int
+		MaxLength           int
+		MinCharacterClasses int
+	}
+	Lockout struct {
+		MaxAttempts   int
+		IPMaxAttempts int
+		DelayAfter    int
+		BaseDelay     time.Duration
+		MaxDelay      time.Duration
+		Duration      time.Duration
+		Window        time.Duration
+	}
 }
 
 func (cfg *Config) application() {
@@ -90,12 +147,136 @@ func (cfg *Config) application() {
 	cfg.Application.Timeout = time.Duration(timeoutInSec) * time.Second
 
 	cfg.Application.TMUser.BaseURL = os.Getenv("APP_TMUSER_BASE_URL")
+	cfg.Application.TrustedProxyHops, _ = strconv.Atoi(os.Getenv("APP_TRUSTED_PROXY_HOPS"))
 }
 
 func (cfg *Config) admin() {
 	cfg.Admin.DefaultPassword = os.Getenv("ADMIN_DEFAULT_PASSWORD")
 }
 
+func (cfg *Config) customer() {
+	deletionGracePeriodInSec, _ := strconv.Atoi(os.Getenv("CUSTOMER_DELETION_GRACE_PERIOD"))
+	cfg.Customer.DeletionGracePeriod = time.Duration(deletionGracePeriodInSec) * time.Second
+	if cfg.Customer.DeletionGracePeriod == 0 {
+		cfg.Customer.DeletionGracePeriod = time.Hour * 24 * 30
+	}
+
+	erasureIntervalInSec, _ := strconv.Atoi(os.Getenv("CUSTOMER_ERASURE_INTERVAL"))
+	cfg.Customer.ErasureInterval = time.Duration(erasureIntervalInSec) * time.Second
+	if cfg.Customer.ErasureInterval == 0 {
+		cfg.Customer.ErasureInterval = time.Hour
+	}
+
+	cfg.Customer.ErasureBatchSize, _ = strconv.Atoi(os.Getenv("CUSTOMER_ERASURE_BATCH_SIZE"))
+	if cfg.Customer.ErasureBatchSize == 0 {
+		cfg.Customer.ErasureBatchSize = 100
+	}
+
+	cfg.Customer.EmailGmailRules, _ = strconv.ParseBool(os.Getenv("CUSTOMER_EMAIL_GMAIL_RULES"))
+	cfg.Customer.DisposableEmailDomains = strings.Split(os.Getenv("CUSTOMER_DISPOSABLE_EMAIL_DOMAINS"), ",")
+	cfg.Customer.DisposableEmailDomainsFile = os.Getenv("CUSTOMER_DISPOSABLE_EMAIL_DOMAINS_FILE")
+
+	reloadIntervalInSec, _ := strconv.Atoi(os.Getenv("CUSTOMER_DISPOSABLE_EMAIL_DOMAINS_RELOAD_INTERVAL"))
+	cfg.Customer.DisposableEmailDomainsReloadInterval = time.Duration(reloadIntervalInSec) * time.Second
+	if cfg.Customer.DisposableEmailDomainsReloadInterval == 0 {
+		cfg.Customer.DisposableEmailDomainsReloadInterval = time.Minute
+	}
+}
+
+func (cfg *Config) openIDConnect() {
+	json.Unmarshal([]byte(os.Getenv("OIDC_PROVIDERS")), &cfg.OIDC.Providers)
+}
+
+func (cfg *Config) password() {
+	memory, _ := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2ID_MEMORY"), 10, 32)
+	cfg.Password.Memory = uint32(memory)
+	if cfg.Password.Memory == 0 {
+		cfg.Password.Memory = 19456
+	}
+
+	iterations, _ := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2ID_ITERATIONS"), 10, 32)
+	cfg.Password.Iterations = uint32(iterations)
+	if cfg.Password.Iterations == 0 {
+		cfg.Password.Iterations = 2
+	}
+
+	parallelism, _ := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2ID_PARALLELISM"), 10, 8)
+	cfg.Password.Parallelism = uint8(parallelism)
+	if cfg.Password.Parallelism == 0 {
+		cfg.Password.Parallelism = 1
+	}
+
+	saltLength, _ := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2ID_SALT_LENGTH"), 10, 32)
+	cfg.Password.SaltLength = uint32(saltLength)
+	if cfg.Password.SaltLength == 0 {
+		cfg.Password.SaltLength = 16
+	}
+
+	keyLength, _ := strconv.ParseUint(os.Getenv("PASSWORD_ARGON2ID_KEY_LENGTH"), 10, 32)
+	cfg.Password.KeyLength = uint32(keyLength)
+	if cfg.Password.KeyLength == 0 {
+		cfg.Password.KeyLength = 32
+	}
+}
+
+func (cfg *Config) passwordPolicy() {
+	cfg.PasswordPolicy.MinLength, _ = strconv.Atoi(os.Getenv("PASSWORD_POLICY_MIN_LENGTH"))
+	if cfg.PasswordPolicy.MinLength == 0 {
+		cfg.PasswordPolicy.MinLength = 8
+	}
+
+	cfg.PasswordPolicy.MaxLength, _ = strconv.Atoi(os.Getenv("PASSWORD_POLICY_MAX_LENGTH"))
+	if cfg.PasswordPolicy.MaxLength == 0 {
+		cfg.PasswordPolicy.MaxLength = 128
+	}
+
+	cfg.PasswordPolicy.MinCharacterClasses, _ = strconv.Atoi(os.Getenv("PASSWORD_POLICY_MIN_CHARACTER_CLASSES"))
+	if cfg.PasswordPolicy.MinCharacterClasses == 0 {
+		cfg.PasswordPolicy.MinCharacterClasses = 3
+	}
+}
+
+func (cfg *Config) lockout() {
+	cfg.Lockout.MaxAttempts, _ = strconv.Atoi(os.Getenv("LOCKOUT_MAX_ATTEMPTS"))
+	if cfg.Lockout.MaxAttempts == 0 {
+		cfg.Lockout.MaxAttempts = 10
+	}
+
+	cfg.Lockout.IPMaxAttempts, _ = strconv.Atoi(os.Getenv("LOCKOUT_IP_MAX_ATTEMPTS"))
+	if cfg.Lockout.IPMaxAttempts == 0 {
+		cfg.Lockout.IPMaxAttempts = 100
+	}
+
+	cfg.Lockout.DelayAfter, _ = strconv.Atoi(os.Getenv("LOCKOUT_DELAY_AFTER"))
+	if cfg.Lockout.DelayAfter == 0 {
+		cfg.Lockout.DelayAfter = 3
+	}
+
+	baseDelayInSec, _ := strconv.Atoi(os.Getenv("LOCKOUT_BASE_DELAY"))
+	cfg.Lockout.BaseDelay = time.Duration(baseDelayInSec) * time.Second
+	if cfg.Lockout.BaseDelay == 0 {
+		cfg.Lockout.BaseDelay = time.Second
+	}
+
+	maxDelayInSec, _ := strconv.Atoi(os.Getenv("LOCKOUT_MAX_DELAY"))
+	cfg.Lockout.MaxDelay = time.Duration(maxDelayInSec) * time.Second
+	if cfg.Lockout.MaxDelay == 0 {
+		cfg.Lockout.MaxDelay = time.Minute
+	}
+
+	durationInSec, _ := strconv.Atoi(os.Getenv("LOCKOUT_DURATION"))
+	cfg.Lockout.Duration = time.Duration(durationInSec) * time.Second
+	if cfg.Lockout.Duration == 0 {
+		cfg.Lockout.Duration = time.Minute * 15
+	}
+
+	windowInSec, _ := strconv.Atoi(os.Getenv("LOCKOUT_WINDOW"))
+	cfg.Lockout.Window = time.Duration(windowInSec) * time.Second
+	if cfg.Lockout.Window == 0 {
+		cfg.Lockout.Window = time.Minute * 15
+	}
+}
+
 func (cfg *Config) crypto() {
 	cfg.Crypto.Secret = os.Getenv("CRYPTO_SECRET")
 }
@@ -125,6 +306,12 @@ func (c *Config) jwt() {
 
 	c.JWT.PrivateKey = []byte(jwtRsa.PrivateKey)
 	c.JWT.PublicKey = []byte(jwtRsa.PublicKey)
+
+	refreshTokenTTLInSec, _ := strconv.Atoi(os.Getenv("JWT_REFRESH_TOKEN_TTL"))
+	c.JWT.RefreshTokenTTL = time.Duration(refreshTokenTTLInSec) * time.Second
+	if c.JWT.RefreshTokenTTL == 0 {
+		c.JWT.RefreshTokenTTL = time.Hour * 24 * 30
+	}
 }
 
 func (c *Config) postgresql() {
@@ -159,6 +346,50 @@ func (cfg *Config) gcp() {
 	cfg.GCP.ProjectID = os.Getenv("GCP_PROJECT_ID")
 }
 
+func (cfg *Config) storage() {
+	cfg.Storage.Driver = os.Getenv("STORAGE_DRIVER")
+	if cfg.Storage.Driver == "" {
+		cfg.Storage.Driver = "local"
+	}
+
+	cfg.Storage.LocalDir = os.Getenv("STORAGE_LOCAL_DIR")
+	if cfg.Storage.LocalDir == "" {
+		cfg.Storage.LocalDir = "./storage"
+	}
+
+	cfg.Storage.BaseURL = os.Getenv("STORAGE_BASE_URL")
+	cfg.Storage.GCSBucket = os.Getenv("STORAGE_GCS_BUCKET")
+}
+
+func (cfg *Config) idempotency() {
+	ttlInSec, _ := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL"))
+	cfg.Idempotency.TTL = time.Duration(ttlInSec) * time.Second
+	if cfg.Idempotency.TTL == 0 {
+		cfg.Idempotency.TTL = time.Hour * 24
+	}
+}
+
+func (cfg *Config) captcha() {
+	cfg.Captcha.Verifier.Provider = os.Getenv("CAPTCHA_PROVIDER")
+	cfg.Captcha.Verifier.Secret = os.Getenv("CAPTCHA_SECRET")
+	cfg.Captcha.Verifier.VerifyURL = os.Getenv("CAPTCHA_VERIFY_URL")
+
+	cfg.Captcha.Challenge.Provider = os.Getenv("CAPTCHA_CHALLENGE_PROVIDER")
+	cfg.Captcha.Challenge.Secret = os.Getenv("CAPTCHA_CHALLENGE_SECRET")
+	cfg.Captcha.Challenge.VerifyURL = os.Getenv("CAPTCHA_CHALLENGE_VERIFY_URL")
+
+	json.Unmarshal([]byte(os.Getenv("CAPTCHA_POLICIES")), &cfg.Captcha.Policies)
+}
+
+func (cfg *Config) webAuthn() {
+	cfg.WebAuthn.RPID = os.Getenv("WEBAUTHN_RP_ID")
+	cfg.WebAuthn.RPName = os.Getenv("WEBAUTHN_RP_NAME")
+	cfg.WebAuthn.Origins = strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",")
+
+	timeoutInSec, _ := strconv.Atoi(os.Getenv("WEBAUTHN_TIMEOUT"))
+	cfg.WebAuthn.Timeout = time.Duration(timeoutInSec) * time.Second
+}
+
 func load() *Config {
 	cfg := new(Config)
 	cfg.application()
@@ -168,9 +399,18 @@ func load() *Config {
 	cfg.postgresql()
 	cfg.cors()
 	cfg.redis()
+	cfg.idempotency()
+	cfg.captcha()
 	cfg.kafka()
 	cfg.gcp()
+	cfg.storage()
+	cfg.webAuthn()
 	cfg.admin()
+	cfg.customer()
+	cfg.openIDConnect()
+	cfg.password()
+	cfg.passwordPolicy()
+	cfg.lockout()
 	return cfg
 }
 
@@ -4,14 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/middleware"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
 	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
 	publicMiddleware "github.com/tsel-ticketmaster/tm-user/pkg/middleware"
 	"github.com/tsel-ticketmaster/tm-user/pkg/response"
 	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	publicValidator "github.com/tsel-ticketmaster/tm-user/pkg/validator"
 )
 
 type HTTPHandler struct {
@@ -20,37 +24,51 @@ type HTTPHandler struct {
 	AdminUseCase      AdminUseCase
 }
 
-func InitHTTPHandler(router *mux.Router, adminSession *middleware.AdminSession, validate *validator.Validate, adminUseCase AdminUseCase) {
+var (
+	signInIPRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "admin-signin-ip",
+		Limit:     30,
+		Period:    time.Minute,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByIP,
+	}
+	signInEmailRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "admin-signin-email",
+		Limit:     10,
+		Period:    time.Minute,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByJSONField("email"),
+	}
+)
+
+func InitHTTPHandler(router *mux.Router, adminSession *middleware.AdminSession, rateLimiter *publicMiddleware.RateLimiter, validate *validator.Validate, adminUseCase AdminUseCase) {
 	handler := &HTTPHandler{
 		Validate:     validate,
 		AdminUseCase: adminUseCase,
 	}
 
-	router.HandleFunc("/tm-user/v1/adminapp/administrators/signin", publicMiddleware.SetRouteChain(handler.SignIn)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/signin", publicMiddleware.SetRouteChain(handler.SignIn, rateLimiter.Limit(signInIPRateLimit), rateLimiter.Limit(signInEmailRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/signin/refresh", publicMiddleware.SetRouteChain(handler.RefreshToken)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/signin/passkey", publicMiddleware.SetRouteChain(handler.BeginPasskeySignIn, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/signin/passkey/verify", publicMiddleware.SetRouteChain(handler.SignInPasskey, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
 	router.HandleFunc("/tm-user/v1/adminapp/administrators", publicMiddleware.SetRouteChain(handler.Create, adminSession.Verify)).Methods(http.MethodPost)
 	router.HandleFunc("/tm-user/v1/adminapp/administrators/signout", publicMiddleware.SetRouteChain(handler.SignOut, adminSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/change-password", publicMiddleware.SetRouteChain(handler.ChangePassword, adminSession.Verify)).Methods(http.MethodPatch)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/passkeys", publicMiddleware.SetRouteChain(handler.GetPasskeys, adminSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/passkeys/register", publicMiddleware.SetRouteChain(handler.BeginPasskeyRegistration, adminSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/passkeys/register/confirm", publicMiddleware.SetRouteChain(handler.FinishPasskeyRegistration, adminSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/passkeys/{id}", publicMiddleware.SetRouteChain(handler.RenamePasskey, adminSession.Verify)).Methods(http.MethodPatch)
+	router.HandleFunc("/tm-user/v1/adminapp/administrators/passkeys/{id}", publicMiddleware.SetRouteChain(handler.DeletePasskey, adminSession.Verify)).Methods(http.MethodDelete)
+	router.HandleFunc("/tm-user/v1/adminapp/accounts/unlock", publicMiddleware.SetRouteChain(handler.UnlockAccount, adminSession.Verify)).Methods(http.MethodPost)
 }
 
-func (handler HTTPHandler) validate(ctx context.Context, payload interface{}) error {
+func (handler HTTPHandler) validate(ctx context.Context, payload interface{}) *publicValidator.ValidationError {
 	err := handler.Validate.StructCtx(ctx, payload)
 	if err == nil {
 		return nil
 	}
 
-	panic(err)
-
-	// errorFields := err.(validator.ValidationErrors)
-
-	// errMessages := make([]string, len(errorFields))
-
-	// for k, errorField := range errorFields {
-	// 	errMessages[k] = fmt.Sprintf("invalid '%s' with value '%v'", errorField.Field(), errorField.Value())
-	// }
-
-	// errorMessage := strings.Join(errMessages, ", ")
-
-	// return fmt.Errorf(errorMessage)
-
+	return publicValidator.NewValidationError(ctx, payload, err)
 }
 
 func (handler HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -70,6 +88,7 @@ func (handler HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
 			Status:  status.BAD_REQUEST,
 			Message: err.Error(),
+			Data:    err.Violations,
 		})
 
 		return
@@ -110,6 +129,7 @@ func (handler HTTPHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
 			Status:  status.BAD_REQUEST,
 			Message: err.Error(),
+			Data:    err.Violations,
 		})
 
 		return
@@ -133,6 +153,131 @@ func (handler HTTPHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (handler HTTPHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := RefreshTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.AdminUseCase.RefreshToken(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "admin's token has been successfully refreshed",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ChangePasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if acc, err := session.GetAccountFromCtx(ctx); err == nil {
+		ctx = publicValidator.WithPasswordIdentities(ctx, acc.Email, acc.Name)
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.AdminUseCase.ChangePassword(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "admin's password has been successfully changed",
+	})
+}
+
+func (handler HTTPHandler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := UnlockAccountRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.AdminUseCase.UnlockAccount(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "account has been successfully unlocked",
+	})
+}
+
 func (handler HTTPHandler) SignOut(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -152,3 +297,234 @@ func (handler HTTPHandler) SignOut(w http.ResponseWriter, r *http.Request) {
 		Message: "admin has been successfully signed out",
 	})
 }
+
+func (handler HTTPHandler) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.AdminUseCase.BeginPasskeyRegistration(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey's registration has been successfully started",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := FinishPasskeyRegistrationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.AdminUseCase.FinishPasskeyRegistration(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, response.RESTEnvelope{
+		Status:  status.CREATED,
+		Message: "passkey has been successfully registered",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) GetPasskeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.AdminUseCase.GetPasskeys(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "admin's passkeys",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) RenamePasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := RenamePasskeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid passkey's id",
+		})
+
+		return
+	}
+
+	req.ID = ID
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err = handler.AdminUseCase.RenamePasskey(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey has been successfully renamed",
+	})
+}
+
+func (handler HTTPHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := DeletePasskeyRequest{}
+
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid passkey's id",
+		})
+
+		return
+	}
+
+	req.ID = ID
+
+	err = handler.AdminUseCase.DeletePasskey(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey has been successfully deleted",
+	})
+}
+
+func (handler HTTPHandler) BeginPasskeySignIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.AdminUseCase.BeginPasskeySignIn(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey's challenge has been successfully created",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) SignInPasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := PasskeySignInRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.AdminUseCase.SignInPasskey(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "admin has been successfully signed in",
+		Data:    resp,
+	})
+}
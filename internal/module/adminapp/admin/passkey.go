@@ -0,0 +1,167 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/passkey"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
+)
+
+// BeginPasskeyRegistration will start the registration of the administrator's passkey.
+func (a adminUseCase) BeginPasskeyRegistration(ctx context.Context) (webauthn.CreationOptions, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	admin, err := a.adminRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	user := webauthn.User{
+		Name:        admin.Email,
+		DisplayName: admin.Name,
+	}
+
+	return a.passkey.BeginRegistration(ctx, fmt.Sprintf("admin:%d", admin.ID), user)
+}
+
+// FinishPasskeyRegistration will verify and save the administrator's passkey.
+func (a adminUseCase) FinishPasskeyRegistration(ctx context.Context, req FinishPasskeyRegistrationRequest) (PasskeyResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return PasskeyResponse{}, err
+	}
+
+	cred, err := a.passkey.FinishRegistration(ctx, fmt.Sprintf("admin:%d", acc.ID), req.Name, req.Credential)
+	if err != nil {
+		return PasskeyResponse{}, err
+	}
+
+	return newPasskeyResponse(cred), nil
+}
+
+// GetPasskeys will list the administrator's passkeys.
+func (a adminUseCase) GetPasskeys(ctx context.Context) ([]PasskeyResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := a.passkey.List(ctx, fmt.Sprintf("admin:%d", acc.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]PasskeyResponse, len(creds))
+	for k, cred := range creds {
+		resp[k] = newPasskeyResponse(cred)
+	}
+
+	return resp, nil
+}
+
+// RenamePasskey will rename the administrator's passkey.
+func (a adminUseCase) RenamePasskey(ctx context.Context, req RenamePasskeyRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.passkey.Rename(ctx, fmt.Sprintf("admin:%d", acc.ID), req.ID, req.Name)
+}
+
+// DeletePasskey will delete the administrator's passkey.
+func (a adminUseCase) DeletePasskey(ctx context.Context, req DeletePasskeyRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.passkey.Delete(ctx, fmt.Sprintf("admin:%d", acc.ID), req.ID)
+}
+
+// BeginPasskeySignIn will create the challenge to sign in the administrator with the passkey.
+func (a adminUseCase) BeginPasskeySignIn(ctx context.Context) (PasskeySignInChallengeResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	sc, err := a.passkey.BeginSignIn(ctx, "admin")
+	if err != nil {
+		return PasskeySignInChallengeResponse{}, err
+	}
+
+	resp := PasskeySignInChallengeResponse{
+		ChallengeToken: sc.Token,
+		Options:        sc.Options,
+		ExpiresAt:      sc.ExpiresAt,
+	}
+
+	return resp, nil
+}
+
+// SignInPasskey will sign in the administrator with the passkey's assertion and got token for the session.
+func (a adminUseCase) SignInPasskey(ctx context.Context, req PasskeySignInRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	cred, err := a.passkey.FinishSignIn(ctx, "admin", req.ChallengeToken, req.Credential)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	var ID int64
+	if _, err := fmt.Sscanf(cred.Subject, "admin:%d", &ID); err != nil {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	admin, err := a.adminRepository.FindByID(ctx, ID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+		}
+		return SignInResponse{}, err
+	}
+
+	if admin.Status != StatusActive {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	rt, err := a.refreshToken.Issue(ctx, fmt.Sprintf("admin:%d", admin.ID), util.GenerateRandomHEX(16))
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	return a.createSession(ctx, admin, rt)
+}
+
+func newPasskeyResponse(cred passkey.Credential) PasskeyResponse {
+	return PasskeyResponse{
+		ID:         cred.ID,
+		Name:       cred.Name,
+		CreatedAt:  cred.CreatedAt,
+		LastUsedAt: cred.LastUsedAt,
+	}
+}
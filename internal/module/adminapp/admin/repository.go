@@ -324,7 +324,7 @@ func (r *adminRepository) Update(ctx context.Context, ID int64, data Administrat
 
 	defer stmt.Close()
 
-	if _, err := stmt.ExecContext(ctx, query, data.Name, data.Email, data.Password, data.PasswordSalt, data.Status, data.UpdatedAt, ID); err != nil {
+	if _, err := stmt.ExecContext(ctx, data.Name, data.Email, data.Password, data.PasswordSalt, data.Status, data.UpdatedAt, ID); err != nil {
 		r.logger.WithContext(ctx).WithError(err).Error()
 		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating admin's prorperties")
 	}
@@ -1,5 +1,7 @@
 package admin
 
+import "github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
+
 type SignInRequest struct {
 	Email    string `json:"email" validate:"email"`
 	Password string `json:"password" validate:"required"`
@@ -9,3 +11,36 @@ type CreateRequest struct {
 	Name  string `json:"name" validate:"required"`
 	Email string `json:"email" validate:"email"`
 }
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type UnlockAccountRequest struct {
+	Type  string `json:"type" validate:"oneof=CUSTOMER ADMIN"`
+	Email string `json:"email" validate:"email"`
+}
+
+type ChangePasswordRequest struct {
+	ExistingPassword string `json:"existing_password" validate:"required"`
+	NewPassword      string `json:"new_password" validate:"required,password"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	Name       string                       `json:"name" validate:"required,max=100"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+
+type RenamePasskeyRequest struct {
+	ID   int64
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type DeletePasskeyRequest struct {
+	ID int64
+}
+
+type PasskeySignInRequest struct {
+	ChallengeToken string                     `json:"challenge_token" validate:"required"`
+	Credential     webauthn.AssertionResponse `json:"credential"`
+}
@@ -2,13 +2,30 @@ package admin
 
 import (
 	"time"
+
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
 )
 
 type SignInResponse struct {
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
+	Token                 string    `json:"token"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
 type CreateResponse struct {
 	ID int64 `json:"id"`
 }
+
+type PasskeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type PasskeySignInChallengeResponse struct {
+	ChallengeToken string                  `json:"challenge_token"`
+	Options        webauthn.RequestOptions `json:"options"`
+	ExpiresAt      time.Time               `json:"expires_at"`
+}
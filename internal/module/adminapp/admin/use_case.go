@@ -4,20 +4,37 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/jwt"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/lockout"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/passkey"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/password"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
 	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
 	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
 )
 
 type AdminUseCase interface {
 	SignIn(context.Context, SignInRequest) (SignInResponse, error)
 	Create(context.Context, CreateRequest) (CreateResponse, error)
 	SignOut(context.Context) error
+	RefreshToken(context.Context, RefreshTokenRequest) (SignInResponse, error)
+	UnlockAccount(context.Context, UnlockAccountRequest) error
+	ChangePassword(context.Context, ChangePasswordRequest) error
+	BeginPasskeyRegistration(context.Context) (webauthn.CreationOptions, error)
+	FinishPasskeyRegistration(context.Context, FinishPasskeyRegistrationRequest) (PasskeyResponse, error)
+	GetPasskeys(context.Context) ([]PasskeyResponse, error)
+	RenamePasskey(context.Context, RenamePasskeyRequest) error
+	DeletePasskey(context.Context, DeletePasskeyRequest) error
+	BeginPasskeySignIn(context.Context) (PasskeySignInChallengeResponse, error)
+	SignInPasskey(context.Context, PasskeySignInRequest) (SignInResponse, error)
 	// GetByID(context.Context, GetByIDRequest) (GetByIDResponse, error)
 	// GetMany(context.Context, GetManyRequest) (GetManyResponse, error)
 	// ChangeEmail(context.Context, ChangeEmailRequest) (ChangeEmailResponse, error)
@@ -31,26 +48,42 @@ type adminUseCase struct {
 	timeout         time.Duration
 	jsonWebToken    *jwt.JSONWebToken
 	session         session.Session
+	refreshToken    session.RefreshTokenStore
+	lockout         lockout.Lockout
+	passwordHasher  password.Hasher
 	adminRepository AdminRepository
+	passkey         passkey.Passkey
+	// customerEmailOptions canonicalise the customer's email the same as the customer app, so the lock of its account is found.
+	customerEmailOptions emailaddress.Options
 }
 
 type AdminUseCaseProperty struct {
-	Logger          *logrus.Logger
-	DefaultPassword string
-	Timeout         time.Duration
-	JSONWebToken    *jwt.JSONWebToken
-	Session         session.Session
-	AdminRepository AdminRepository
+	Logger               *logrus.Logger
+	DefaultPassword      string
+	Timeout              time.Duration
+	JSONWebToken         *jwt.JSONWebToken
+	Session              session.Session
+	RefreshToken         session.RefreshTokenStore
+	Lockout              lockout.Lockout
+	PasswordHasher       password.Hasher
+	AdminRepository      AdminRepository
+	Passkey              passkey.Passkey
+	CustomerEmailOptions emailaddress.Options
 }
 
 func NewAdminUseCase(props AdminUseCaseProperty) AdminUseCase {
 	return adminUseCase{
-		logger:          props.Logger,
-		defaultPassword: props.DefaultPassword,
-		timeout:         props.Timeout,
-		jsonWebToken:    props.JSONWebToken,
-		session:         props.Session,
-		adminRepository: props.AdminRepository,
+		logger:               props.Logger,
+		defaultPassword:      props.DefaultPassword,
+		timeout:              props.Timeout,
+		jsonWebToken:         props.JSONWebToken,
+		session:              props.Session,
+		refreshToken:         props.RefreshToken,
+		lockout:              props.Lockout,
+		passwordHasher:       props.PasswordHasher,
+		adminRepository:      props.AdminRepository,
+		passkey:              props.Passkey,
+		customerEmailOptions: props.CustomerEmailOptions,
 	}
 }
 
@@ -69,15 +102,14 @@ func (a adminUseCase) Create(ctx context.Context, req CreateRequest) (CreateResp
 	}
 
 	now := time.Now()
-	passwordSalt := util.GenerateRandomHEX(16)
 	defaultPassword := a.defaultPassword
-	hashedPassword := util.GenerateSecret(defaultPassword, passwordSalt, 32)
+	hashedPassword := a.passwordHasher.Hash(defaultPassword)
 
 	newAdmin := Administrator{
 		Name:         req.Name,
 		Email:        req.Email,
 		Password:     hashedPassword,
-		PasswordSalt: passwordSalt,
+		PasswordSalt: "",
 		Status:       StatusActive,
 		CreatedAt:    now,
 		UpdatedAt:    now,
@@ -100,20 +132,87 @@ func (a adminUseCase) SignIn(ctx context.Context, req SignInRequest) (SignInResp
 	ctx, cancel := context.WithTimeout(ctx, a.timeout)
 	defer cancel()
 
+	account := fmt.Sprintf("admin:%s", strings.ToLower(req.Email))
+	ip := clientinfo.FromContext(ctx).IPAddress
+
+	if err := a.lockout.Check(ctx, account, ip); err != nil {
+		return SignInResponse{}, err
+	}
+
 	admin, err := a.adminRepository.FindByEmail(ctx, req.Email, nil)
 	if err != nil {
 		if errors.MatchStatus(err, status.NOT_FOUND) {
+			a.lockout.Fail(ctx, account, ip)
 			return SignInResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid admin email or password")
 		}
 		return SignInResponse{}, err
 	}
 
-	hashPassword := util.GenerateSecret(req.Password, admin.PasswordSalt, 32)
+	match, rehash := a.passwordHasher.Verify(req.Password, admin.Password, admin.PasswordSalt)
+	if !match {
+		lockedUntil, err := a.lockout.Fail(ctx, account, ip)
+		if err != nil {
+			return SignInResponse{}, err
+		}
+
+		if !lockedUntil.IsZero() {
+			a.logger.WithContext(ctx).WithField("admin_id", admin.ID).Warn("admin account is locked due to too many failed sign in attempts")
+			return SignInResponse{}, errors.New(http.StatusLocked, status.ACCOUNT_LOCKED, "account is temporarily locked due to too many failed sign in attempts")
+		}
 
-	if hashPassword != admin.Password {
 		return SignInResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid admin email or password")
 	}
 
+	if err := a.lockout.Succeed(ctx, account); err != nil {
+		return SignInResponse{}, err
+	}
+
+	if rehash {
+		admin.Password = a.passwordHasher.Hash(req.Password)
+		admin.PasswordSalt = ""
+		admin.UpdatedAt = time.Now()
+		// the administrator is still able to sign in with the old hash, so the failure is not returned.
+		a.adminRepository.Update(ctx, admin.ID, admin, nil)
+	}
+
+	rt, err := a.refreshToken.Issue(ctx, fmt.Sprintf("admin:%d", admin.ID), util.GenerateRandomHEX(16))
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	return a.createSession(ctx, admin, rt)
+}
+
+// RefreshToken will exchange the refresh token with the new token for the session. The used refresh token is no longer valid.
+func (a adminUseCase) RefreshToken(ctx context.Context, req RefreshTokenRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	rt, err := a.refreshToken.Rotate(ctx, req.RefreshToken)
+	if err != nil {
+		if rt.FamilyID != "" {
+			a.session.Delete(ctx, rt.Subject, rt.FamilyID)
+		}
+		return SignInResponse{}, err
+	}
+
+	var ID int64
+	if _, err := fmt.Sscanf(rt.Subject, "admin:%d", &ID); err != nil {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid refresh token")
+	}
+
+	admin, err := a.adminRepository.FindByID(ctx, ID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid refresh token")
+		}
+		return SignInResponse{}, err
+	}
+
+	return a.createSession(ctx, admin, rt)
+}
+
+func (a adminUseCase) createSession(ctx context.Context, admin Administrator, rt session.RefreshToken) (SignInResponse, error) {
 	now := time.Now()
 	expiresIn := time.Hour * 1
 	expiresAt := now.Add(expiresIn)
@@ -121,6 +220,7 @@ func (a adminUseCase) SignIn(ctx context.Context, req SignInRequest) (SignInResp
 	userType := "ADMIN"
 
 	claim := jwt.Claim{}
+	claim.Id = rt.FamilyID
 	claim.Subject = subject
 	claim.IssuedAt = now.Unix()
 	claim.ExpiresAt = expiresAt.Unix()
@@ -135,22 +235,100 @@ func (a adminUseCase) SignIn(ctx context.Context, req SignInRequest) (SignInResp
 		return SignInResponse{}, err
 	}
 
-	if err := a.session.Set(ctx, fmt.Sprintf("%s:%d", "admin", admin.ID), session.Account{
-		ID:   admin.ID,
-		Name: admin.Name,
-		Type: userType,
-	}, expiresIn); err != nil {
+	ci := clientinfo.FromContext(ctx)
+	acc := session.Account{
+		ID:         admin.ID,
+		Name:       admin.Name,
+		Type:       userType,
+		SessionID:  rt.FamilyID,
+		Device:     ci.Device,
+		IPAddress:  ci.IPAddress,
+		UserAgent:  ci.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	if existing, err := a.session.Get(ctx, subject, rt.FamilyID); err == nil {
+		acc.CreatedAt = existing.CreatedAt
+	}
+
+	if err := a.session.Set(ctx, subject, acc, time.Until(rt.ExpiresAt)); err != nil {
 		return SignInResponse{}, err
 	}
 
 	resp := SignInResponse{
-		Token:     idToken,
-		ExpiresAt: expiresAt,
+		Token:                 idToken,
+		ExpiresAt:             expiresAt,
+		RefreshToken:          rt.Token,
+		RefreshTokenExpiresAt: rt.ExpiresAt,
 	}
 
 	return resp, nil
 }
 
+// ChangePassword will change the password of the signed in administrator and sign out the other sessions.
+func (a adminUseCase) ChangePassword(ctx context.Context, req ChangePasswordRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	admin, err := a.adminRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	if match, _ := a.passwordHasher.Verify(req.ExistingPassword, admin.Password, admin.PasswordSalt); !match {
+		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid admin existing password")
+	}
+
+	admin.Password = a.passwordHasher.Hash(req.NewPassword)
+	admin.PasswordSalt = ""
+	admin.UpdatedAt = time.Now()
+
+	if err := a.adminRepository.Update(ctx, admin.ID, admin, nil); err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("admin:%d", admin.ID)
+	if err := a.session.DeleteAll(ctx, subject, acc.SessionID); err != nil {
+		return err
+	}
+
+	if err := a.refreshToken.RevokeAll(ctx, subject, acc.SessionID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnlockAccount will release the lock of the customer's or administrator's account before the lock expires.
+func (a adminUseCase) UnlockAccount(ctx context.Context, req UnlockAccountRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	account := fmt.Sprintf("admin:%s", strings.ToLower(req.Email))
+	if req.Type == "CUSTOMER" {
+		account = fmt.Sprintf("customer:%s", emailaddress.Canonicalize(req.Email, a.customerEmailOptions))
+	}
+	if err := a.lockout.Unlock(ctx, account); err != nil {
+		return err
+	}
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err == nil {
+		a.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"admin_id": acc.ID,
+			"account":  account,
+		}).Info("account has been unlocked")
+	}
+
+	return nil
+}
+
 // SignOut will sign out the administrator and kill the existing session.
 func (a adminUseCase) SignOut(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, a.timeout)
@@ -167,7 +345,11 @@ func (a adminUseCase) SignOut(ctx context.Context) error {
 	}
 
 	key := fmt.Sprintf("admin:%d", acc.ID)
-	if err := a.session.Delete(ctx, key); err != nil {
+	if err := a.session.Delete(ctx, key, acc.SessionID); err != nil {
+		return err
+	}
+
+	if err := a.refreshToken.Revoke(ctx, acc.SessionID); err != nil {
 		return err
 	}
 
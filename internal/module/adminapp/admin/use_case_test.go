@@ -0,0 +1,46 @@
+package admin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/module/adminapp/admin"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/lockout"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
+)
+
+func TestUnlockCustomerAccountWithGmailRules(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	logger := logrus.New()
+
+	lo := lockout.NewRedisLockout(logger, rc, lockout.Config{
+		MaxAttempts:   1,
+		IPMaxAttempts: 100,
+		DelayAfter:    100,
+		Duration:      time.Minute * 15,
+		Window:        time.Minute * 15,
+	})
+
+	uc := admin.NewAdminUseCase(admin.AdminUseCaseProperty{
+		Logger:               logger,
+		Timeout:              time.Second * 5,
+		Lockout:              lo,
+		CustomerEmailOptions: emailaddress.Options{GmailRules: true},
+	})
+
+	ctx := context.Background()
+	account := "customer:johnsmith@gmail.com"
+
+	_, err := lo.Fail(ctx, account, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Error(t, lo.Check(ctx, account, "10.0.0.1"))
+
+	assert.NoError(t, uc.UnlockAccount(ctx, admin.UnlockAccountRequest{Type: "CUSTOMER", Email: "John.Smith+tickets@gmail.com"}))
+	assert.NoError(t, lo.Check(ctx, account, "10.0.0.1"))
+}
@@ -0,0 +1,113 @@
+package customer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	"github.com/tsel-ticketmaster/tm-user/pkg/storage"
+	"github.com/tsel-ticketmaster/tm-user/pkg/thumbnail"
+)
+
+// UploadAvatar implements CustomerUseCase. The avatar is stored as thumbnails under a new key, so the old avatar is deleted only after the customer points to the new one.
+func (u *customerUseCase) UploadAvatar(ctx context.Context, req UploadAvatarRequest) (UploadAvatarResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return UploadAvatarResponse{}, err
+	}
+
+	if len(req.Content) > AvatarMaxSize {
+		return UploadAvatarResponse{}, errors.New(http.StatusRequestEntityTooLarge, status.PAYLOAD_TOO_LARGE, fmt.Sprintf("avatar must not be larger than %d bytes", AvatarMaxSize))
+	}
+
+	thumbnails, err := thumbnail.Generate(req.Content, avatarSizes)
+	if err != nil {
+		switch err {
+		case thumbnail.ErrUnsupportedType:
+			return UploadAvatarResponse{}, errors.New(http.StatusUnsupportedMediaType, status.UNSUPPORTED_MEDIA_TYPE, "avatar must be a jpeg, png or webp image")
+		case thumbnail.ErrInvalidImage, thumbnail.ErrTooManyPixels:
+			return UploadAvatarResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, err.Error())
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return UploadAvatarResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while uploading customer's avatar")
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return UploadAvatarResponse{}, err
+	}
+
+	avatarKey := fmt.Sprintf(avatarKeyPrefix, c.ID, util.GenerateRandomHEX(8))
+
+	for _, size := range avatarSizes {
+		if err := u.objectStorage.Put(ctx, avatarObjectKey(avatarKey, size), bytes.NewReader(thumbnails[size]), "image/jpeg"); err != nil {
+			u.logger.WithContext(ctx).WithError(err).Error()
+			deleteAvatar(ctx, u.logger, u.objectStorage, avatarKey)
+			return UploadAvatarResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while uploading customer's avatar")
+		}
+	}
+
+	oldAvatarKey := c.AvatarKey
+	now := time.Now()
+	c.AvatarKey = &avatarKey
+	c.UpdatedAt = now
+
+	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+		deleteAvatar(ctx, u.logger, u.objectStorage, avatarKey)
+		return UploadAvatarResponse{}, err
+	}
+
+	if oldAvatarKey != nil {
+		deleteAvatar(ctx, u.logger, u.objectStorage, *oldAvatarKey)
+	}
+
+	profile := newProfileResponse(c, u.objectStorage)
+	resp := UploadAvatarResponse{
+		AvatarURL:        *profile.AvatarURL,
+		AvatarThumbnails: profile.AvatarThumbnails,
+	}
+
+	var oldAvatarURL *string
+	if oldAvatarKey != nil {
+		url := u.objectStorage.URL(avatarObjectKey(*oldAvatarKey, avatarSizes[len(avatarSizes)-1]))
+		oldAvatarURL = &url
+	}
+
+	profileUpdatedEvent := ProfileUpdatedEvent{
+		ID: c.ID,
+		Changes: map[string]ProfileChange{
+			"avatar_url": {Old: oldAvatarURL, New: profile.AvatarURL},
+		},
+		UpdatedAt: now,
+	}
+
+	profileUpdatedEventBuff, _ := json.Marshal(profileUpdatedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-profile-updated", fmt.Sprintf("customer:%d", c.ID), messageHeader, profileUpdatedEventBuff)
+
+	return resp, nil
+}
+
+// deleteAvatar removes all of the avatar's thumbnails. It is best effort, the failure is only logged since the avatar is no longer referenced.
+func deleteAvatar(ctx context.Context, logger *logrus.Logger, objectStorage storage.ObjectStorage, avatarKey string) {
+	for _, size := range avatarSizes {
+		if err := objectStorage.Delete(ctx, avatarObjectKey(avatarKey, size)); err != nil {
+			logger.WithContext(ctx).WithError(err).Warn()
+		}
+	}
+}
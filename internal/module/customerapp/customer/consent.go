@@ -0,0 +1,495 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// GetLegalDocuments implements CustomerUseCase. It returns the current version of every legal document.
+func (u *customerUseCase) GetLegalDocuments(ctx context.Context) ([]LegalDocumentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	documents, err := u.legalDocumentRepository.FindLatestPublished(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLegalDocumentResponses(documents), nil
+}
+
+// GetAllLegalDocuments implements CustomerUseCase. It is requested by the administrator, so the drafts are included.
+func (u *customerUseCase) GetAllLegalDocuments(ctx context.Context) ([]LegalDocumentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	documents, err := u.legalDocumentRepository.FindAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLegalDocumentResponses(documents), nil
+}
+
+// CreateLegalDocument implements CustomerUseCase. The document is a draft until it is published.
+func (u *customerUseCase) CreateLegalDocument(ctx context.Context, req CreateLegalDocumentRequest) (LegalDocumentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return LegalDocumentResponse{}, err
+	}
+
+	// the promoter marketing is an opt in, so the customer is never forced to accept it.
+	if req.Type == LegalDocumentPromoterMarketing && req.Mandatory {
+		return LegalDocumentResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "promoter marketing can not be mandatory")
+	}
+
+	now := time.Now()
+	ld := LegalDocument{
+		Type:      req.Type,
+		Version:   req.Version,
+		Title:     req.Title,
+		URL:       req.URL,
+		Mandatory: req.Mandatory,
+		CreatedBy: acc.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	ID, err := u.legalDocumentRepository.Save(ctx, ld, nil)
+	if err != nil {
+		return LegalDocumentResponse{}, err
+	}
+
+	ld.ID = ID
+
+	return newLegalDocumentResponse(ld), nil
+}
+
+// PublishLegalDocument implements CustomerUseCase. The customers must accept the published mandatory document on the next sign in.
+func (u *customerUseCase) PublishLegalDocument(ctx context.Context, req PublishLegalDocumentRequest) (LegalDocumentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	ld, err := u.legalDocumentRepository.FindByID(ctx, req.ID, nil)
+	if err != nil {
+		return LegalDocumentResponse{}, err
+	}
+
+	now := time.Now()
+	if err := u.legalDocumentRepository.Publish(ctx, ld.ID, now, nil); err != nil {
+		return LegalDocumentResponse{}, err
+	}
+
+	ld.PublishedAt = &now
+	ld.UpdatedAt = now
+
+	if acc, err := session.GetAccountFromCtx(ctx); err == nil {
+		u.logger.WithContext(ctx).WithField("admin_id", acc.ID).WithField("legal_document_id", ld.ID).Info("legal document is published by administrator")
+	}
+
+	legalDocumentPublishedEvent := LegalDocumentPublishedEvent{
+		ID:          ld.ID,
+		Type:        ld.Type,
+		Version:     ld.Version,
+		Title:       ld.Title,
+		URL:         ld.URL,
+		Mandatory:   ld.Mandatory,
+		PublishedAt: now,
+	}
+
+	legalDocumentPublishedEventBuff, _ := json.Marshal(legalDocumentPublishedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "legal-document-published", fmt.Sprintf("legal-document:%d", ld.ID), messageHeader, legalDocumentPublishedEventBuff)
+
+	return newLegalDocumentResponse(ld), nil
+}
+
+// GetConsents implements CustomerUseCase.
+func (u *customerUseCase) GetConsents(ctx context.Context) ([]ConsentResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	consents, err := u.customerConsentRepository.FindLatestByCustomerID(ctx, acc.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := u.legalDocumentRepository.FindLatestPublished(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := u.legalDocumentRepository.FindPendingForCustomer(ctx, acc.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	currentConsents := make(map[string]CustomerConsent, len(consents))
+	for _, cc := range consents {
+		currentConsents[cc.Type] = cc
+	}
+
+	latestDocuments := make(map[string]LegalDocument, len(documents))
+	for _, ld := range documents {
+		latestDocuments[ld.Type] = ld
+	}
+
+	pendingTypes := make(map[string]bool, len(pending))
+	for _, ld := range pending {
+		pendingTypes[ld.Type] = true
+	}
+
+	resp := make([]ConsentResponse, len(consentTypes))
+	for k, consentType := range consentTypes {
+		resp[k] = ConsentResponse{
+			Type:             consentType,
+			ReacceptRequired: pendingTypes[consentType],
+		}
+
+		if cc, ok := currentConsents[consentType]; ok {
+			resp[k].Granted = cc.Granted
+			resp[k].Version = cc.DocumentVersion
+			resp[k].UpdatedAt = &cc.CreatedAt
+		}
+
+		if ld, ok := latestDocuments[consentType]; ok {
+			resp[k].LatestVersion = &ld.Version
+		}
+	}
+
+	return resp, nil
+}
+
+// UpdateConsents implements CustomerUseCase. The grant accepts the current version of the legal document, the unchanged consent is not logged again.
+func (u *customerUseCase) UpdateConsents(ctx context.Context, req UpdateConsentsRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	consents, err := u.customerConsentRepository.FindLatestByCustomerID(ctx, acc.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	documents, err := u.legalDocumentRepository.FindLatestPublished(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	currentConsents := make(map[string]CustomerConsent, len(consents))
+	for _, cc := range consents {
+		currentConsents[cc.Type] = cc
+	}
+
+	latestDocuments := make(map[string]LegalDocument, len(documents))
+	for _, ld := range documents {
+		latestDocuments[ld.Type] = ld
+	}
+
+	tx, err := u.customerRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updated := []CustomerConsent{}
+	for _, consent := range req.Consents {
+		if isTermsDocumentType(consent.Type) && !consent.Granted {
+			return errors.New(http.StatusBadRequest, status.BAD_REQUEST, fmt.Sprintf("consent '%s' can not be withdrawn, delete the account instead", consent.Type))
+		}
+
+		var document *LegalDocument
+		if ld, ok := latestDocuments[consent.Type]; ok && consent.Granted {
+			document = &ld
+		}
+
+		if document == nil && isTermsDocumentType(consent.Type) {
+			return errors.New(http.StatusBadRequest, status.BAD_REQUEST, fmt.Sprintf("legal document '%s' has not been published", consent.Type))
+		}
+
+		if cc, ok := currentConsents[consent.Type]; ok && cc.Granted == consent.Granted && equalDocumentID(cc.DocumentID, document) {
+			continue
+		}
+
+		cc, err := u.saveConsent(ctx, acc.ID, consent.Type, document, consent.Granted, tx)
+		if err != nil {
+			return err
+		}
+
+		// the next consent of the same type in the request is compared against this one.
+		currentConsents[cc.Type] = cc
+		updated = append(updated, cc)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while updating customer's consents")
+	}
+
+	for _, cc := range updated {
+		u.publishConsentUpdated(ctx, cc)
+	}
+
+	return nil
+}
+
+// SignInConsent implements CustomerUseCase. The customer accepts the pending legal documents to continue the sign in.
+func (u *customerUseCase) SignInConsent(ctx context.Context, req SignInConsentRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	key := fmt.Sprintf(consentChallengeKeyPrefix, req.ChallengeToken)
+
+	// the challenge is consumed by the first attempt, so the concurrent attempts cannot sign in twice with it.
+	challengeBuff, err := u.cache.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired consent challenge token")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	var challenge ConsentChallenge
+	json.Unmarshal(challengeBuff, &challenge)
+
+	c, err := u.customerRepository.FindByID(ctx, challenge.CustomerID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired consent challenge token")
+		}
+		return SignInResponse{}, err
+	}
+
+	if c.DeletedAt != nil {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired consent challenge token")
+	}
+
+	pending, err := u.legalDocumentRepository.FindPendingForCustomer(ctx, c.ID, nil)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	accepted := make(map[int64]bool, len(req.DocumentIDs))
+	for _, ID := range req.DocumentIDs {
+		accepted[ID] = true
+	}
+
+	// another version may have been published since the challenge was created, so the customer has to read it first.
+	for _, ld := range pending {
+		if !accepted[ld.ID] {
+			return SignInResponse{}, errors.New(http.StatusBadRequest, status.CONSENT_REQUIRED, fmt.Sprintf("legal document '%s' with version '%s' must be accepted", ld.Type, ld.Version))
+		}
+	}
+
+	tx, err := u.customerRepository.BeginTx(ctx)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+	defer tx.Rollback()
+
+	granted := make([]CustomerConsent, len(pending))
+	for k, ld := range pending {
+		ld := ld
+		granted[k], err = u.saveConsent(ctx, c.ID, ld.Type, &ld, true, tx)
+		if err != nil {
+			return SignInResponse{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	for _, cc := range granted {
+		u.publishConsentUpdated(ctx, cc)
+	}
+
+	return u.completeSignIn(ctx, c, challenge.Method)
+}
+
+// challengeConsent holds the sign in until the customer accepts the pending legal documents.
+func (u *customerUseCase) challengeConsent(ctx context.Context, c Customer, method string, pending []LegalDocument) (SignInResponse, error) {
+	challengeToken := util.GenerateRandomHEX(32)
+	challengeExpiresAt := time.Now().Add(consentChallengeExpiresIn)
+
+	challengeBuff, _ := json.Marshal(ConsentChallenge{
+		CustomerID: c.ID,
+		Method:     method,
+	})
+
+	if err := u.cache.Set(ctx, fmt.Sprintf(consentChallengeKeyPrefix, challengeToken), challengeBuff, consentChallengeExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	u.recordLogin(ctx, c, method, LoginOutcomeConsentRequired, nil)
+
+	resp := SignInResponse{
+		ConsentRequired:    true,
+		RequiredDocuments:  newLegalDocumentResponses(pending),
+		ChallengeToken:     challengeToken,
+		ChallengeExpiresAt: &challengeExpiresAt,
+	}
+
+	return resp, nil
+}
+
+// grantSignUpConsents logs the consents that are given on the sign up. The terms are accepted in their current version.
+func (u *customerUseCase) grantSignUpConsents(ctx context.Context, customerID int64, documents []LegalDocument, promoterMarketing bool, tx *sql.Tx) ([]CustomerConsent, error) {
+	granted := []CustomerConsent{}
+
+	var marketingDocument *LegalDocument
+	for _, ld := range documents {
+		ld := ld
+		if ld.Type == LegalDocumentPromoterMarketing {
+			marketingDocument = &ld
+			continue
+		}
+
+		cc, err := u.saveConsent(ctx, customerID, ld.Type, &ld, true, tx)
+		if err != nil {
+			return nil, err
+		}
+		granted = append(granted, cc)
+	}
+
+	if promoterMarketing {
+		cc, err := u.saveConsent(ctx, customerID, LegalDocumentPromoterMarketing, marketingDocument, true, tx)
+		if err != nil {
+			return nil, err
+		}
+		granted = append(granted, cc)
+	}
+
+	return granted, nil
+}
+
+// saveConsent appends the consent to the customer's log along with the client that has given it.
+func (u *customerUseCase) saveConsent(ctx context.Context, customerID int64, consentType string, document *LegalDocument, granted bool, tx *sql.Tx) (CustomerConsent, error) {
+	ci := clientinfo.FromContext(ctx)
+	cc := CustomerConsent{
+		CustomerID: customerID,
+		Type:       consentType,
+		Granted:    granted,
+		IPAddress:  ci.IPAddress,
+		UserAgent:  clientinfo.Truncate(ci.UserAgent, clientinfo.MaxUserAgentLength),
+		CreatedAt:  time.Now(),
+	}
+
+	if document != nil {
+		cc.DocumentID = &document.ID
+		cc.DocumentVersion = &document.Version
+	}
+
+	ID, err := u.customerConsentRepository.Save(ctx, cc, tx)
+	if err != nil {
+		return CustomerConsent{}, err
+	}
+
+	cc.ID = ID
+
+	return cc, nil
+}
+
+// publishConsentUpdated tells the promoters' systems about the consent, e.g. to stop sending the marketing once it is withdrawn.
+func (u *customerUseCase) publishConsentUpdated(ctx context.Context, cc CustomerConsent) {
+	consentUpdatedEvent := ConsentUpdatedEvent{
+		ID:        cc.CustomerID,
+		Type:      cc.Type,
+		Granted:   cc.Granted,
+		Version:   cc.DocumentVersion,
+		UpdatedAt: cc.CreatedAt,
+	}
+
+	consentUpdatedEventBuff, _ := json.Marshal(consentUpdatedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-consent-updated", fmt.Sprintf("customer:%d", cc.CustomerID), messageHeader, consentUpdatedEventBuff)
+}
+
+func isTermsDocumentType(documentType string) bool {
+	for _, t := range termsDocumentTypes {
+		if t == documentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func equalDocumentID(documentID *int64, document *LegalDocument) bool {
+	if documentID == nil || document == nil {
+		return documentID == nil && document == nil
+	}
+
+	return *documentID == document.ID
+}
+
+func newLegalDocumentResponse(ld LegalDocument) LegalDocumentResponse {
+	return LegalDocumentResponse{
+		ID:          ld.ID,
+		Type:        ld.Type,
+		Version:     ld.Version,
+		Title:       ld.Title,
+		URL:         ld.URL,
+		Mandatory:   ld.Mandatory,
+		PublishedAt: ld.PublishedAt,
+		CreatedAt:   ld.CreatedAt,
+	}
+}
+
+func newLegalDocumentResponses(documents []LegalDocument) []LegalDocumentResponse {
+	resp := make([]LegalDocumentResponse, len(documents))
+	for k, ld := range documents {
+		resp[k] = newLegalDocumentResponse(ld)
+	}
+
+	return resp
+}
+
+func newConsentLogResponses(consents []CustomerConsent) []ConsentLogResponse {
+	resp := make([]ConsentLogResponse, len(consents))
+	for k, cc := range consents {
+		resp[k] = ConsentLogResponse{
+			Type:      cc.Type,
+			Granted:   cc.Granted,
+			Version:   cc.DocumentVersion,
+			IPAddress: cc.IPAddress,
+			UserAgent: cc.UserAgent,
+			CreatedAt: cc.CreatedAt,
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,178 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type CustomerConsentRepository interface {
+	Save(ctx context.Context, cc CustomerConsent, tx *sql.Tx) (int64, error)
+	FindByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerConsent, error)
+	FindLatestByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerConsent, error)
+	DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error
+}
+
+// customerConsentColumns reads the version of the document along with the consent, the consents are joined with legal_document as ld.
+const customerConsentColumns = `
+	cc.id, cc.customer_id, cc.type, cc.document_id, ld.version, cc.granted, cc.ip_address, cc.user_agent, cc.created_at
+`
+
+func scanCustomerConsent(row rowScanner) (CustomerConsent, error) {
+	var data CustomerConsent
+
+	err := row.Scan(&data.ID, &data.CustomerID, &data.Type, &data.DocumentID, &data.DocumentVersion, &data.Granted, &data.IPAddress, &data.UserAgent, &data.CreatedAt)
+
+	return data, err
+}
+
+type customerConsentRepository struct {
+	logger *logrus.Logger
+	db     *sql.DB
+}
+
+// Save implements CustomerConsentRepository.
+func (r *customerConsentRepository) Save(ctx context.Context, cc CustomerConsent, tx *sql.Tx) (int64, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		INSERT INTO customer_consent
+		(
+			customer_id, type, document_id, granted, ip_address, user_agent, created_at
+		)
+		VALUES
+		(
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		RETURNING id
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's consent")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, cc.CustomerID, cc.Type, cc.DocumentID, cc.Granted, cc.IPAddress, cc.UserAgent, cc.CreatedAt)
+
+	var ID int64
+
+	if err := row.Scan(&ID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's consent")
+	}
+
+	return ID, nil
+}
+
+// FindByCustomerID implements CustomerConsentRepository. The latest consent comes first.
+func (r *customerConsentRepository) FindByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerConsent, error) {
+	query := `
+		SELECT ` + customerConsentColumns + `
+		FROM customer_consent cc
+		LEFT JOIN legal_document ld ON ld.id = cc.document_id
+		WHERE
+			cc.customer_id = $1
+		ORDER BY cc.created_at DESC, cc.id DESC
+	`
+
+	return r.query(ctx, tx, query, customerID)
+}
+
+// FindLatestByCustomerID implements CustomerConsentRepository. It returns the current consent of every type.
+func (r *customerConsentRepository) FindLatestByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerConsent, error) {
+	query := `
+		SELECT DISTINCT ON (cc.type) ` + customerConsentColumns + `
+		FROM customer_consent cc
+		LEFT JOIN legal_document ld ON ld.id = cc.document_id
+		WHERE
+			cc.customer_id = $1
+		ORDER BY cc.type, cc.created_at DESC, cc.id DESC
+	`
+
+	return r.query(ctx, tx, query, customerID)
+}
+
+func (r *customerConsentRepository) query(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]CustomerConsent, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's consents")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's consents")
+	}
+	defer rows.Close()
+
+	consents := []CustomerConsent{}
+	for rows.Next() {
+		data, err := scanCustomerConsent(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error()
+			return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's consents")
+		}
+		consents = append(consents, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's consents")
+	}
+
+	return consents, nil
+}
+
+// DeleteByCustomerID implements CustomerConsentRepository.
+func (r *customerConsentRepository) DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		DELETE FROM customer_consent
+		WHERE
+			customer_id = $1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's consents")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, customerID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's consents")
+	}
+
+	return nil
+}
+
+func NewCustomerConsentRepository(logger *logrus.Logger, db *sql.DB) CustomerConsentRepository {
+	return &customerConsentRepository{
+		logger: logger,
+		db:     db,
+	}
+}
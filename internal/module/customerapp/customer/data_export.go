@@ -0,0 +1,304 @@
+package customer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// DataExport is everything that is held about the customer.
+type DataExport struct {
+	GeneratedAt  time.Time              `json:"generated_at"`
+	Profile      GetProfileResponse     `json:"profile"`
+	Verification DataExportVerification `json:"verification"`
+	TwoFactor    DataExportTwoFactor    `json:"two_factor"`
+	Sessions     []SessionResponse      `json:"sessions"`
+	Identities   []IdentityResponse     `json:"identities"`
+	Passkeys     []PasskeyResponse      `json:"passkeys"`
+	LoginHistory []LoginEventResponse   `json:"login_history"`
+	Consents     []ConsentLogResponse   `json:"consents"`
+}
+
+type DataExportVerification struct {
+	Status string `json:"status"`
+}
+
+type DataExportTwoFactor struct {
+	Enabled                bool `json:"enabled"`
+	RemainingRecoveryCodes int  `json:"remaining_recovery_codes"`
+}
+
+// DataExportArchive is the built export that is kept until the download link expires.
+type DataExportArchive struct {
+	CustomerID  int64  `json:"customer_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// ExportData implements CustomerUseCase.
+func (u *customerUseCase) ExportData(ctx context.Context, req DataExportRequest) (DataExportResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return DataExportResponse{}, err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return DataExportResponse{}, err
+	}
+
+	return u.requestDataExport(ctx, c, req.Format)
+}
+
+// ExportDataForCustomer implements CustomerUseCase. It is requested by the administrator, the link is still delivered to the customer.
+func (u *customerUseCase) ExportDataForCustomer(ctx context.Context, req AdminDataExportRequest) (DataExportResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	c, err := u.customerRepository.FindByID(ctx, req.CustomerID, nil)
+	if err != nil {
+		return DataExportResponse{}, err
+	}
+
+	if c.DeletedAt != nil {
+		return DataExportResponse{}, errors.New(http.StatusNotFound, status.NOT_FOUND, "customer's account has been deleted")
+	}
+
+	if acc, err := session.GetAccountFromCtx(ctx); err == nil {
+		u.logger.WithContext(ctx).WithField("admin_id", acc.ID).WithField("customer_id", c.ID).Info("customer's data export is requested by administrator")
+	}
+
+	return u.requestDataExport(ctx, c, req.Format)
+}
+
+// DownloadDataExport implements CustomerUseCase.
+func (u *customerUseCase) DownloadDataExport(ctx context.Context, req DownloadDataExportRequest) (DataExportArchive, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	if time.Now().Unix() > req.Expires {
+		return DataExportArchive{}, errors.New(http.StatusForbidden, status.FORBIDDEN, "download link has expired")
+	}
+
+	signature := u.signDataExport(req.ID, req.Expires)
+	if !hmac.Equal([]byte(signature), []byte(req.Signature)) {
+		return DataExportArchive{}, errors.New(http.StatusForbidden, status.FORBIDDEN, "invalid download link")
+	}
+
+	archiveBuff, err := u.cache.Get(ctx, fmt.Sprintf(dataExportKeyPrefix, req.ID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return DataExportArchive{}, errors.New(http.StatusNotFound, status.NOT_FOUND, "data export is not found")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return DataExportArchive{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while downloading customer's data export")
+	}
+
+	var archive DataExportArchive
+	if err := json.Unmarshal(archiveBuff, &archive); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return DataExportArchive{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while downloading customer's data export")
+	}
+
+	return archive, nil
+}
+
+func (u *customerUseCase) requestDataExport(ctx context.Context, c Customer, format string) (DataExportResponse, error) {
+	if format == "" {
+		format = DataExportFormatJSON
+	}
+
+	pendingKey := fmt.Sprintf(dataExportPendingKeyPrefix, c.ID)
+	exportID := util.GenerateRandomHEX(16)
+
+	ok, err := u.cache.SetNX(ctx, pendingKey, exportID, dataExportBuildTimeout).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return DataExportResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while exporting customer's data")
+	}
+	if !ok {
+		return DataExportResponse{}, errors.New(http.StatusConflict, status.ALREADY_EXIST, "customer's data export is still in progress")
+	}
+
+	// the export outlives the request, so it must not be cancelled along with the request.
+	go u.buildDataExport(context.WithoutCancel(ctx), c.ID, exportID, format)
+
+	resp := DataExportResponse{
+		ID:     exportID,
+		Format: format,
+	}
+
+	return resp, nil
+}
+
+func (u *customerUseCase) buildDataExport(ctx context.Context, ID int64, exportID, format string) {
+	ctx, cancel := context.WithTimeout(ctx, dataExportBuildTimeout)
+	defer cancel()
+
+	defer u.cache.Del(ctx, fmt.Sprintf(dataExportPendingKeyPrefix, ID))
+
+	c, err := u.customerRepository.FindByID(ctx, ID, nil)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+
+	accs, err := u.session.List(ctx, fmt.Sprintf("customer:%d", c.ID))
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+
+	identities, err := u.customerIdentityRepository.FindByCustomerID(ctx, c.ID, nil)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+
+	creds, err := u.passkey.List(ctx, fmt.Sprintf("customer:%d", c.ID))
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+
+	loginEvents, err := u.customerLoginEventRepository.FindByCustomerID(ctx, c.ID, 0, 0, nil)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+
+	consents, err := u.customerConsentRepository.FindByCustomerID(ctx, c.ID, nil)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+
+	now := time.Now()
+	export := DataExport{
+		GeneratedAt: now,
+		Profile:     newProfileResponse(c, u.objectStorage),
+		Verification: DataExportVerification{
+			Status: c.VerificationStatus,
+		},
+		TwoFactor: DataExportTwoFactor{
+			Enabled:                c.TwoFactorEnabled,
+			RemainingRecoveryCodes: len(c.TwoFactorRecoveryCodes),
+		},
+		Sessions:     make([]SessionResponse, len(accs)),
+		Identities:   make([]IdentityResponse, len(identities)),
+		Passkeys:     newPasskeyResponses(creds),
+		LoginHistory: newLoginEventResponses(loginEvents),
+		Consents:     newConsentLogResponses(consents),
+	}
+
+	for k, a := range accs {
+		export.Sessions[k] = SessionResponse{
+			ID:         a.SessionID,
+			Device:     a.Device,
+			IPAddress:  a.IPAddress,
+			UserAgent:  a.UserAgent,
+			CreatedAt:  a.CreatedAt,
+			LastSeenAt: a.LastSeenAt,
+		}
+	}
+
+	for k, identity := range identities {
+		export.Identities[k] = IdentityResponse{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		}
+	}
+
+	exportBuff, _ := json.MarshalIndent(export, "", "  ")
+
+	archive := DataExportArchive{
+		CustomerID:  c.ID,
+		FileName:    fmt.Sprintf("customer-%d-%s.json", c.ID, now.Format("20060102150405")),
+		ContentType: "application/json",
+		Content:     exportBuff,
+	}
+
+	if format == DataExportFormatZIP {
+		zipBuff, err := zipFile(archive.FileName, exportBuff)
+		if err != nil {
+			u.logger.WithContext(ctx).WithError(err).Error()
+			return
+		}
+
+		archive.FileName = fmt.Sprintf("customer-%d-%s.zip", c.ID, now.Format("20060102150405"))
+		archive.ContentType = "application/zip"
+		archive.Content = zipBuff
+	}
+
+	archiveBuff, _ := json.Marshal(archive)
+
+	if err := u.cache.Set(ctx, fmt.Sprintf(dataExportKeyPrefix, exportID), archiveBuff, dataExportExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+
+	expiresAt := now.Add(dataExportExpiresIn)
+	dataExportReadyEvent := DataExportReadyEvent{
+		ID:           c.ID,
+		Name:         c.Name,
+		Email:        c.Email,
+		DownloadLink: fmt.Sprintf("%s%s?id=%s&expires=%d&signature=%s", u.tmuserBaseURL, DataExportDownloadURLPath, exportID, expiresAt.Unix(), u.signDataExport(exportID, expiresAt.Unix())),
+		ExpiresAt:    expiresAt,
+	}
+
+	dataExportReadyEventBuff, _ := json.Marshal(dataExportReadyEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	if err := u.publisher.Publish(ctx, "customer-data-export-ready", fmt.Sprintf("customer:%d", c.ID), messageHeader, dataExportReadyEventBuff); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+}
+
+// signDataExport returns the signature of the download link in hex format. The used algorithm is hmac-sha256.
+func (u *customerUseCase) signDataExport(exportID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(u.cryptoSecret))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", exportID, expires)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func zipFile(name string, content []byte) ([]byte, error) {
+	buff := new(bytes.Buffer)
+	zw := zip.NewWriter(buff)
+
+	f, err := zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// reserveEmailScript sets the reservation when it is free or already owned by the same customer, so the owner is able to request the change again.
+var reserveEmailScript = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if owner == false or owner == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseEmailScript deletes the reservation only when it is owned by the customer.
+var releaseEmailScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func emailReservationKey(email string) string {
+	return fmt.Sprintf(emailReservationKeyPrefix, strings.ToLower(email))
+}
+
+// reserveEmail holds the email for the customer while the change is pending, so another customer can not claim it in the meantime.
+func (u *customerUseCase) reserveEmail(ctx context.Context, email string, customerID int64, expiresIn time.Duration) error {
+	reserved, err := reserveEmailScript.Run(ctx, u.cache, []string{emailReservationKey(email)}, customerID, expiresIn.Milliseconds()).Int()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while reserving customer's email")
+	}
+
+	if reserved == 0 {
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("customer with email '%s' is already registered", email))
+	}
+
+	return nil
+}
+
+// checkEmailReservation fails when the email is held by another customer. The zero id is used when the caller does not own any reservation, e.g. sign up.
+func (u *customerUseCase) checkEmailReservation(ctx context.Context, email string, customerID int64) error {
+	owner, err := u.cache.Get(ctx, emailReservationKey(email)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while checking customer's email")
+	}
+
+	if owner != strconv.FormatInt(customerID, 10) {
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("customer with email '%s' is already registered", email))
+	}
+
+	return nil
+}
+
+// releaseEmail gives up the customer's reservation. The reservation expires on its own, so the failure is only logged.
+func (u *customerUseCase) releaseEmail(ctx context.Context, email string, customerID int64) {
+	if err := releaseEmailScript.Run(ctx, u.cache, []string{emailReservationKey(email)}, customerID).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+}
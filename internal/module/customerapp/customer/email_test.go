@@ -0,0 +1,103 @@
+package customer_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/module/customerapp/customer"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// legacyCustomerRepository matches the emails the same way as the database, the stored email is not canonicalised by the gmail rules.
+type legacyCustomerRepository struct {
+	customer.CustomerRepository
+	c customer.Customer
+}
+
+func (r *legacyCustomerRepository) FindByEmail(ctx context.Context, email string, tx *sql.Tx) (customer.Customer, error) {
+	if emailaddress.Canonicalize(email, emailaddress.Options{}) == emailaddress.Canonicalize(r.c.Email, emailaddress.Options{}) {
+		return r.c, nil
+	}
+
+	return customer.Customer{}, errors.New(http.StatusNotFound, status.NOT_FOUND, "customer is not found")
+}
+
+func (r *legacyCustomerRepository) FindByGmailAddress(ctx context.Context, email string, tx *sql.Tx) (customer.Customer, error) {
+	opts := emailaddress.Options{GmailRules: true}
+	if emailaddress.Canonicalize(email, opts) == emailaddress.Canonicalize(r.c.Email, opts) {
+		return r.c, nil
+	}
+
+	return customer.Customer{}, errors.New(http.StatusNotFound, status.NOT_FOUND, "customer is not found")
+}
+
+func TestSignInLegacyGmailAddress(t *testing.T) {
+	uc, _ := newTestUseCase(t, func(props *customer.CustomerUseCaseProperty) {
+		props.EmailOptions = emailaddress.Options{GmailRules: true}
+		props.CustomerRepository = &legacyCustomerRepository{c: customer.Customer{
+			ID:                 1,
+			Email:              "John.Smith+tickets@gmail.com",
+			Password:           "secret",
+			VerificationStatus: customer.VerficationStatusVerified,
+			MemberStatus:       customer.MemberStatusActive,
+			TwoFactorEnabled:   true,
+		}}
+	})
+
+	ctx := context.Background()
+
+	for _, email := range []string{"john.smith+tickets@gmail.com", "johnsmith@gmail.com", "john.smith@googlemail.com"} {
+		resp, err := uc.SignIn(ctx, customer.SignInRequest{Email: email, Password: "secret"})
+		assert.NoError(t, err, email)
+		assert.True(t, resp.TwoFactorRequired, email)
+	}
+
+	_, err := uc.SignIn(ctx, customer.SignInRequest{Email: "jane@gmail.com", Password: "secret"})
+	assert.True(t, errors.MatchStatus(err, status.NOT_FOUND))
+}
+
+// takenEmailCustomerRepository finds the existing email of the revert on another customer.
+type takenEmailCustomerRepository struct {
+	customer.CustomerRepository
+	c customer.Customer
+}
+
+func (r *takenEmailCustomerRepository) FindByID(ctx context.Context, ID int64, tx *sql.Tx) (customer.Customer, error) {
+	return r.c, nil
+}
+
+func (r *takenEmailCustomerRepository) FindByEmail(ctx context.Context, email string, tx *sql.Tx) (customer.Customer, error) {
+	return customer.Customer{ID: r.c.ID + 1, Email: email}, nil
+}
+
+func TestRevertEmailChangeKeepsTokenWhenEmailIsTaken(t *testing.T) {
+	uc, props := newTestUseCase(t, func(props *customer.CustomerUseCaseProperty) {
+		props.CustomerRepository = &takenEmailCustomerRepository{c: customer.Customer{ID: 1, Email: "new@example.com"}}
+	})
+
+	ctx := context.Background()
+	key := "user:email_change_revert:customer:token:revert-token"
+	revertBuff, _ := json.Marshal(customer.EmailChangeRevert{
+		CustomerID:        1,
+		ExistingEmail:     "john@example.com",
+		NewEmail:          "new@example.com",
+		VerificationToken: "verification-token",
+		RequestedAt:       time.Now(),
+	})
+	props.Cache.Set(ctx, key, revertBuff, time.Hour)
+
+	err := uc.RevertEmailChange(ctx, customer.ChangeEmailRevertRequest{Token: "revert-token"})
+	assert.True(t, errors.MatchStatus(err, status.ALREADY_EXIST))
+
+	// the owner is still able to use the link once the email is available again.
+	stored, err := props.Cache.Get(ctx, key).Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, revertBuff, stored)
+}
@@ -1,29 +1,320 @@
 package customer
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 const (
 	verificationKeyPrefix            = "user:verification:customer:token:%s"
+	verificationCustomerKeyPrefix    = "user:verification:customer:%d"
+	verificationResendCooldownPrefix = "user:verification_resend_cooldown:customer:email:%s"
+	verificationResendDailyPrefix    = "user:verification_resend_daily:customer:email:%s:%s"
 	changeEmailVerificationKeyPrefix = "user:change_email_verification:customer:token:%s"
+	resetPasswordKeyPrefix           = "user:reset_password:customer:token:%s"
+	resetPasswordCustomerKeyPrefix   = "user:reset_password:customer:%d"
+	resetPasswordCooldownPrefix      = "user:reset_password_cooldown:customer:email:%s"
+	resetPasswordDailyPrefix         = "user:reset_password_daily:customer:email:%s:%s"
+	twoFactorEnrolmentKeyPrefix      = "user:two_factor_enrolment:customer:%d"
+	twoFactorChallengeKeyPrefix      = "user:two_factor_challenge:customer:token:%s"
+	twoFactorChallengeAttemptsPrefix = "user:two_factor_challenge_attempts:customer:token:%s"
+	twoFactorUsedCodeKeyPrefix       = "user:two_factor_used_code:customer:%d:%d"
+	dataExportKeyPrefix              = "user:data_export:customer:id:%s"
+	dataExportPendingKeyPrefix       = "user:data_export_pending:customer:%d"
+	memberStatusKeyPrefix            = "user:member_status:customer:%d"
+	reactivationKeyPrefix            = "user:reactivation:customer:token:%s"
+	reactivationCooldownPrefix       = "user:reactivation_cooldown:customer:email:%s"
+	oidcStateKeyPrefix               = "user:oidc_state:customer:state:%s"
+	magicLinkKeyPrefix               = "user:magic_link:customer:token:%s"
+	magicLinkCooldownPrefix          = "user:magic_link_cooldown:customer:email:%s"
+	phoneVerificationKeyPrefix       = "user:phone_verification:customer:%d"
+	phoneVerificationAttemptsPrefix  = "user:phone_verification_attempts:customer:%d"
+	phoneSignInKeyPrefix             = "user:phone_signin:customer:phone:%s"
+	phoneSignInAttemptsPrefix        = "user:phone_signin_attempts:customer:phone:%s"
+	phoneOTPCooldownPrefix           = "user:phone_otp_cooldown:customer:phone:%s"
+	phoneOTPDailyPrefix              = "user:phone_otp_daily:customer:phone:%s:%s"
+	avatarKeyPrefix                  = "avatars/customer/%d/%s"
+	emailChangeRevertKeyPrefix       = "user:email_change_revert:customer:token:%s"
+	emailReservationKeyPrefix        = "user:email_reservation:customer:email:%s"
+	consentChallengeKeyPrefix        = "user:consent_challenge:customer:token:%s"
 
 	VerificationURLPath            = "/v1/customerapp/customers/verify"
 	ChangeEmailVerificationURLPath = "/v1/customerapp/customers/verify-change-email"
+	ChangeEmailRevertURLPath       = "/v1/customerapp/customers/revert-change-email"
+	ResetPasswordURLPath           = "/v1/customerapp/customers/reset-password"
+	DataExportDownloadURLPath      = "/v1/customerapp/customers/data-export/download"
+	ReactivationURLPath            = "/v1/customerapp/customers/verify-reactivation"
+	MagicLinkURLPath               = "/v1/customerapp/customers/signin/magic-link/verify"
 
 	VerficationStatusVerified    = "VERIFIED"
 	VerificationStatusUnverified = "UNVERIFIED"
 
 	MemberStatusActive   = "ACTIVE"
 	MemberStatusInactive = "INACTIVE"
+	MemberStatusDeleted  = "DELETED"
+
+	GenderMale           = "MALE"
+	GenderFemale         = "FEMALE"
+	GenderOther          = "OTHER"
+	GenderPreferNotToSay = "PREFER_NOT_TO_SAY"
+
+	SensitiveChangeEmailRequested    = "EMAIL_CHANGE_REQUESTED"
+	SensitiveChangeEmail             = "EMAIL_CHANGED"
+	SensitiveChangeEmailReverted     = "EMAIL_CHANGE_REVERTED"
+	SensitiveChangePassword          = "PASSWORD_CHANGED"
+	SensitiveChangePasswordReset     = "PASSWORD_RESET"
+	SensitiveChangePhone             = "PHONE_CHANGED"
+	SensitiveChangeTwoFactorEnabled  = "TWO_FACTOR_ENABLED"
+	SensitiveChangeTwoFactorDisabled = "TWO_FACTOR_DISABLED"
+	SensitiveChangePasskeyAdded      = "PASSKEY_ADDED"
+	SensitiveChangePasskeyRemoved    = "PASSKEY_REMOVED"
+
+	LoginMethodPassword  = "PASSWORD"
+	LoginMethodTwoFactor = "TWO_FACTOR"
+	LoginMethodMagicLink = "MAGIC_LINK"
+	LoginMethodPhone     = "PHONE"
+	LoginMethodOIDC      = "OIDC"
+	LoginMethodPasskey   = "PASSKEY"
+
+	LoginOutcomeSuccess           = "SUCCESS"
+	LoginOutcomeFailure           = "FAILURE"
+	LoginOutcomeTwoFactorRequired = "TWO_FACTOR_REQUIRED"
+	LoginOutcomeConsentRequired   = "CONSENT_REQUIRED"
+
+	LoginFailureInvalidCredentials    = "INVALID_CREDENTIALS"
+	LoginFailureInvalidCode           = "INVALID_CODE"
+	LoginFailureAccountLocked         = "ACCOUNT_LOCKED"
+	LoginFailureAccountInactive       = "ACCOUNT_INACTIVE"
+	LoginFailureUnverified            = "UNVERIFIED"
+	LoginFailurePasswordResetRequired = "PASSWORD_RESET_REQUIRED"
+
+	loginHistoryDefaultLimit = 20
+
+	LegalDocumentTermsOfService    = "TERMS_OF_SERVICE"
+	LegalDocumentPrivacyPolicy     = "PRIVACY_POLICY"
+	LegalDocumentPromoterMarketing = "PROMOTER_MARKETING"
+
+	consentChallengeExpiresIn = time.Minute * 10
+
+	// the existing email is able to revert the change for days, since the owner may not read the email right away.
+	emailChangeRevertExpiresIn = time.Hour * 24 * 7
+
+	verificationResendCooldown = time.Minute
+	verificationResendDailyCap = 5
+
+	resetPasswordCooldown = time.Minute
+	resetPasswordDailyCap = 5
+
+	twoFactorIssuer               = "ticket-master"
+	twoFactorRecoveryCodeCount    = 10
+	twoFactorChallengeMaxAttempts = 5
+	twoFactorChallengeExpiresIn   = time.Minute * 5
+
+	erasureJobLockKey = "user:erasure_job:customer:lock"
+
+	DataExportFormatJSON = "JSON"
+	DataExportFormatZIP  = "ZIP"
+
+	dataExportExpiresIn    = time.Hour * 24
+	dataExportBuildTimeout = time.Minute
+
+	memberStatusCacheExpiresIn = time.Minute
+	reactivationExpiresIn      = time.Minute * 30
+	reactivationCooldown       = time.Minute
+
+	OIDCIntentSignIn = "SIGNIN"
+	OIDCIntentLink   = "LINK"
+
+	oidcStateExpiresIn = time.Minute * 10
+
+	magicLinkExpiresIn = time.Minute * 10
+	magicLinkCooldown  = time.Minute
+
+	phoneOTPLength      = 6
+	phoneOTPExpiresIn   = time.Minute * 5
+	phoneOTPMaxAttempts = 5
+	phoneOTPCooldown    = time.Minute
+	phoneOTPDailyCap    = 10
+
+	// AvatarMaxSize is the largest avatar that can be uploaded in bytes.
+	AvatarMaxSize = 5 << 20
+	// AvatarFormField is the field of the multipart form that holds the avatar.
+	AvatarFormField = "avatar"
+
+	// the nonce of the magic link is sent back by the browser as cookie, or by the native app as header.
+	MagicLinkNonceCookie     = "tm_magic_link_nonce"
+	MagicLinkNonceCookiePath = "/tm-user/v1/customerapp/customers/signin/magic-link"
+	MagicLinkNonceHeader     = "X-Magic-Link-Nonce"
 )
 
+// termsDocumentTypes are the legal documents that are accepted along with the terms of service, they can not be withdrawn.
+var termsDocumentTypes = []string{LegalDocumentTermsOfService, LegalDocumentPrivacyPolicy}
+
+// consentTypes are every consent of the customer.
+var consentTypes = []string{LegalDocumentTermsOfService, LegalDocumentPrivacyPolicy, LegalDocumentPromoterMarketing}
+
+// avatarSizes are the sizes of the avatar's thumbnails in pixels, from the smallest to the largest.
+var avatarSizes = []int{64, 128, 256, 512}
+
 type Customer struct {
-	ID                 int64
-	Name               string
-	Email              string
-	Password           string
-	PasswordSalt       string
-	VerificationStatus string
-	MemberStatus       string
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	ID                     int64
+	Name                   string
+	Email                  string
+	Password               string
+	PasswordSalt           string
+	VerificationStatus     string
+	MemberStatus           string
+	TwoFactorEnabled       bool
+	TwoFactorSecret        string
+	TwoFactorRecoveryCodes RecoveryCodes
+	Phone                  *string
+	PhoneVerifiedAt        *time.Time
+	BirthDate              *time.Time
+	Gender                 *string
+	City                   *string
+	Province               *string
+	PreferredLanguage      *string
+	Timezone               *string
+	AvatarKey              *string
+	PasswordResetRequired  bool
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	DeletedAt              *time.Time
+}
+
+// CustomerSensitiveChange is the history of the changes that can be used to take over the customer's account, it is kept for the support.
+type CustomerSensitiveChange struct {
+	ID         int64
+	CustomerID int64
+	Type       string
+	OldValue   *string
+	NewValue   *string
+	IPAddress  string
+	Device     string
+	CreatedAt  time.Time
+}
+
+// CustomerLoginEvent is the history of the sign in attempts of the customer, it is kept for the customer and the support.
+type CustomerLoginEvent struct {
+	ID                int64
+	CustomerID        int64
+	Method            string
+	Outcome           string
+	FailureReason     *string
+	IPAddress         string
+	UserAgent         string
+	Device            string
+	DeviceFingerprint string
+	Country           string
+	CreatedAt         time.Time
+}
+
+// LegalDocument is a version of the terms, the policy or the consent's text. The published version is not changed anymore.
+// The customer must accept the mandatory version before signing in again.
+type LegalDocument struct {
+	ID          int64
+	Type        string
+	Version     string
+	Title       string
+	URL         string
+	Mandatory   bool
+	PublishedAt *time.Time
+	CreatedBy   int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CustomerConsent is an entry of the customer's consent log, the grant and the withdrawal are appended.
+type CustomerConsent struct {
+	ID              int64
+	CustomerID      int64
+	Type            string
+	DocumentID      *int64
+	DocumentVersion *string
+	Granted         bool
+	IPAddress       string
+	UserAgent       string
+	CreatedAt       time.Time
+}
+
+// ConsentChallenge holds the sign in until the customer accepts the latest mandatory legal documents.
+type ConsentChallenge struct {
+	CustomerID int64  `json:"customer_id"`
+	Method     string `json:"method"`
+}
+
+// KnownLoginClient tells whether the device and the country of the client have signed in to the customer's account before.
+type KnownLoginClient struct {
+	SuccessCount int
+	Device       bool
+	Country      bool
+}
+
+// EmailChangeRevert is kept until the existing email reverts the change or it expires.
+type EmailChangeRevert struct {
+	CustomerID        int64     `json:"customer_id"`
+	ExistingEmail     string    `json:"existing_email"`
+	NewEmail          string    `json:"new_email"`
+	VerificationToken string    `json:"verification_token"`
+	RequestedAt       time.Time `json:"requested_at"`
+}
+
+// CustomerIdentity is the account of an openid connect provider that is linked to the customer.
+type CustomerIdentity struct {
+	ID         int64
+	CustomerID int64
+	Provider   string
+	Subject    string
+	Email      string
+	CreatedAt  time.Time
+}
+
+// OIDCState is kept between the authorization request and the callback of the provider.
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	Intent       string `json:"intent"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	CustomerID   int64  `json:"customer_id,omitempty"`
+}
+
+// PhoneOTP is the one-time password that is sent to the phone. Only the hash of the code is kept.
+type PhoneOTP struct {
+	CustomerID int64  `json:"customer_id"`
+	Phone      string `json:"phone"`
+	CodeHash   string `json:"code_hash"`
+}
+
+// RecoveryCodes is a list of hashed one-time codes to pass the two factor authentication when the authenticator is not available. It is stored as json array.
+type RecoveryCodes []string
+
+// Scan implements sql.Scanner.
+func (rc *RecoveryCodes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*rc = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), rc)
+	case []byte:
+		return json.Unmarshal(v, rc)
+	default:
+		return fmt.Errorf("unsupported type of recovery codes: %T", src)
+	}
+}
+
+// Value implements driver.Valuer.
+func (rc RecoveryCodes) Value() (driver.Value, error) {
+	if rc == nil {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
 }
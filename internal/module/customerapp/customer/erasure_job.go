@@ -0,0 +1,156 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/passkey"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/storage"
+)
+
+type ErasureJobProperty struct {
+	AppName                           string
+	Logger                            *logrus.Logger
+	GracePeriod                       time.Duration
+	Interval                          time.Duration
+	BatchSize                         int
+	Cache                             redis.UniversalClient
+	Publisher                         pubsub.Publisher
+	CustomerRepository                CustomerRepository
+	CustomerIdentityRepository        CustomerIdentityRepository
+	CustomerSensitiveChangeRepository CustomerSensitiveChangeRepository
+	CustomerLoginEventRepository      CustomerLoginEventRepository
+	CustomerConsentRepository         CustomerConsentRepository
+	Passkey                           passkey.Passkey
+	ObjectStorage                     storage.ObjectStorage
+}
+
+// ErasureJob anonymises the personal data of the customers that have been deleted for longer than the grace period.
+type ErasureJob struct {
+	appName                           string
+	logger                            *logrus.Logger
+	gracePeriod                       time.Duration
+	interval                          time.Duration
+	batchSize                         int
+	cache                             redis.UniversalClient
+	publisher                         pubsub.Publisher
+	customerRepository                CustomerRepository
+	customerIdentityRepository        CustomerIdentityRepository
+	customerSensitiveChangeRepository CustomerSensitiveChangeRepository
+	customerLoginEventRepository      CustomerLoginEventRepository
+	customerConsentRepository         CustomerConsentRepository
+	passkey                           passkey.Passkey
+	objectStorage                     storage.ObjectStorage
+}
+
+func NewErasureJob(props ErasureJobProperty) *ErasureJob {
+	return &ErasureJob{
+		appName:                           props.AppName,
+		logger:                            props.Logger,
+		gracePeriod:                       props.GracePeriod,
+		interval:                          props.Interval,
+		batchSize:                         props.BatchSize,
+		cache:                             props.Cache,
+		publisher:                         props.Publisher,
+		customerRepository:                props.CustomerRepository,
+		customerIdentityRepository:        props.CustomerIdentityRepository,
+		customerSensitiveChangeRepository: props.CustomerSensitiveChangeRepository,
+		customerLoginEventRepository:      props.CustomerLoginEventRepository,
+		customerConsentRepository:         props.CustomerConsentRepository,
+		passkey:                           props.Passkey,
+		objectStorage:                     props.ObjectStorage,
+	}
+}
+
+// Run erases the deleted customers on every interval until the context is done.
+func (j *ErasureJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.erase(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *ErasureJob) erase(ctx context.Context) {
+	// only one instance runs the job on every interval.
+	acquired, err := j.cache.SetNX(ctx, erasureJobLockKey, j.appName, j.interval).Result()
+	if err != nil {
+		j.logger.WithContext(ctx).WithError(err).Error()
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	deletedBefore := time.Now().Add(-j.gracePeriod)
+	messageHeader := pubsub.MessageHeaders{
+		"origin": j.appName,
+	}
+
+	for {
+		customers, err := j.customerRepository.FindErasable(ctx, deletedBefore, j.batchSize, nil)
+		if err != nil {
+			return
+		}
+
+		for _, c := range customers {
+			if err := j.customerIdentityRepository.DeleteByCustomerID(ctx, c.ID, nil); err != nil {
+				return
+			}
+
+			// the history holds the erased emails and phones.
+			if err := j.customerSensitiveChangeRepository.DeleteByCustomerID(ctx, c.ID, nil); err != nil {
+				return
+			}
+
+			if err := j.customerLoginEventRepository.DeleteByCustomerID(ctx, c.ID, nil); err != nil {
+				return
+			}
+
+			if err := j.customerConsentRepository.DeleteByCustomerID(ctx, c.ID, nil); err != nil {
+				return
+			}
+
+			if err := j.passkey.DeleteAll(ctx, fmt.Sprintf("customer:%d", c.ID)); err != nil {
+				return
+			}
+
+			if err := j.customerRepository.Erase(ctx, c.ID, nil); err != nil {
+				return
+			}
+
+			if c.AvatarKey != nil {
+				deleteAvatar(ctx, j.logger, j.objectStorage, *c.AvatarKey)
+			}
+
+			customerErasedEvent := CustomerErasedEvent{
+				ID:       c.ID,
+				ErasedAt: time.Now(),
+			}
+
+			customerErasedEventBuff, _ := json.Marshal(customerErasedEvent)
+
+			j.publisher.Publish(ctx, "customer-erased", fmt.Sprintf("customer:%d", c.ID), messageHeader, customerErasedEventBuff)
+		}
+
+		if len(customers) > 0 {
+			j.logger.WithContext(ctx).WithField("count", len(customers)).Info("deleted customers have been erased")
+		}
+
+		if len(customers) < j.batchSize {
+			return
+		}
+	}
+}
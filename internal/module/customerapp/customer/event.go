@@ -10,6 +10,25 @@ type ChangeEmailEvent struct {
 	VerficationLink string `json:"verification_link"`
 }
 
+// ChangeEmailNoticeEvent is sent to the existing email, so the owner is able to revert the change that is not made by them.
+type ChangeEmailNoticeEvent struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	ExistingEmail string    `json:"existing_email"`
+	NewEmail      string    `json:"new_email"`
+	RevertLink    string    `json:"revert_link"`
+	IPAddress     string    `json:"ip_address"`
+	Device        string    `json:"device"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type ChangeEmailRevertedEvent struct {
+	ID            int64     `json:"id"`
+	RevertedEmail string    `json:"reverted_email"`
+	Email         string    `json:"email"`
+	RevertedAt    time.Time `json:"reverted_at"`
+}
+
 type SignUpEvent struct {
 	ID                 int64     `json:"id"`
 	Name               string    `json:"name"`
@@ -19,3 +38,125 @@ type SignUpEvent struct {
 	VerificationLink   string    `json:"verification_link"`
 	CreatedAt          time.Time `json:"created_at"`
 }
+
+type ResetPasswordEvent struct {
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	Email             string    `json:"email"`
+	ResetPasswordLink string    `json:"reset_password_link"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type AccountLockedEvent struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	IPAddress   string    `json:"ip_address"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+type CustomerDeletedEvent struct {
+	ID                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	Email              string    `json:"email"`
+	DeletedAt          time.Time `json:"deleted_at"`
+	ErasureScheduledAt time.Time `json:"erasure_scheduled_at"`
+}
+
+type CustomerErasedEvent struct {
+	ID       int64     `json:"id"`
+	ErasedAt time.Time `json:"erased_at"`
+}
+
+type DataExportReadyEvent struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	DownloadLink string    `json:"download_link"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type CustomerDeactivatedEvent struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	DeactivatedAt time.Time `json:"deactivated_at"`
+}
+
+type ReactivationEvent struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	ReactivationLink string    `json:"reactivation_link"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type IdentityLinkedEvent struct {
+	ID       int64     `json:"id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Provider string    `json:"provider"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+type MagicLinkEvent struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	MagicLink string    `json:"magic_link"`
+	IPAddress string    `json:"ip_address"`
+	Device    string    `json:"device"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type PhoneChangedEvent struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// ProfileChange is the value of a profile's field before and after it is updated. The cleared value is null.
+type ProfileChange struct {
+	Old *string `json:"old"`
+	New *string `json:"new"`
+}
+
+type ProfileUpdatedEvent struct {
+	ID        int64                    `json:"id"`
+	Changes   map[string]ProfileChange `json:"changes"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+type NewDeviceSignInEvent struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	Method     string    `json:"method"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	Device     string    `json:"device"`
+	Country    string    `json:"country"`
+	NewDevice  bool      `json:"new_device"`
+	NewCountry bool      `json:"new_country"`
+	SignedInAt time.Time `json:"signed_in_at"`
+}
+
+type ConsentUpdatedEvent struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Granted   bool      `json:"granted"`
+	Version   *string   `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type LegalDocumentPublishedEvent struct {
+	ID          int64     `json:"id"`
+	Type        string    `json:"type"`
+	Version     string    `json:"version"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Mandatory   bool      `json:"mandatory"`
+	PublishedAt time.Time `json:"published_at"`
+}
@@ -0,0 +1,93 @@
+package customer_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/internal/module/customerapp/customer"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/lockout"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+)
+
+const cryptoSecret = "0123456789abcdef0123456789abcdef"
+
+type customerRepository struct {
+	customer.CustomerRepository
+	c customer.Customer
+}
+
+func (r *customerRepository) FindByEmail(ctx context.Context, email string, tx *sql.Tx) (customer.Customer, error) {
+	return r.c, nil
+}
+
+func (r *customerRepository) FindByID(ctx context.Context, ID int64, tx *sql.Tx) (customer.Customer, error) {
+	return r.c, nil
+}
+
+func (r *customerRepository) ConsumeRecoveryCode(ctx context.Context, ID int64, hashedCode string, tx *sql.Tx) (bool, error) {
+	return false, nil
+}
+
+type loginEventRepository struct {
+	customer.CustomerLoginEventRepository
+}
+
+func (r *loginEventRepository) Save(ctx context.Context, cle customer.CustomerLoginEvent, tx *sql.Tx) (int64, error) {
+	return 1, nil
+}
+
+type publisher struct{}
+
+func (p *publisher) Publish(ctx context.Context, topic string, key string, headers pubsub.MessageHeaders, message []byte) error {
+	return nil
+}
+
+func (p *publisher) Close() error {
+	return nil
+}
+
+type passwordHasher struct{}
+
+func (h passwordHasher) Hash(plain string) string {
+	return plain
+}
+
+func (h passwordHasher) Verify(plain, hashed, salt string) (bool, bool) {
+	return plain == hashed, false
+}
+
+// newTestUseCase creates the use case on a new redis with the lockout and the fakes that the tests share. The override changes the property before the use case is created.
+func newTestUseCase(t *testing.T, override func(props *customer.CustomerUseCaseProperty)) (customer.CustomerUseCase, customer.CustomerUseCaseProperty) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	logger := logrus.New()
+
+	props := customer.CustomerUseCaseProperty{
+		Logger:       logger,
+		Timeout:      time.Second * 5,
+		CryptoSecret: cryptoSecret,
+		Lockout: lockout.NewRedisLockout(logger, rc, lockout.Config{
+			MaxAttempts:   5,
+			IPMaxAttempts: 100,
+			DelayAfter:    100,
+			Duration:      time.Minute * 15,
+			Window:        time.Minute * 15,
+		}),
+		PasswordHasher:               passwordHasher{},
+		Cache:                        rc,
+		Publisher:                    &publisher{},
+		CustomerRepository:           &customerRepository{},
+		CustomerLoginEventRepository: &loginEventRepository{},
+	}
+
+	if override != nil {
+		override(&props)
+	}
+
+	return customer.NewCustomerUseCase(props), props
+}
@@ -3,17 +3,22 @@ package customer
 import (
 	"context"
 	"encoding/json"
+	stdErrors "errors"
 	"fmt"
+	"io"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/middleware"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
 	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
 	publicMiddleware "github.com/tsel-ticketmaster/tm-user/pkg/middleware"
 	"github.com/tsel-ticketmaster/tm-user/pkg/response"
 	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	publicValidator "github.com/tsel-ticketmaster/tm-user/pkg/validator"
 )
 
 type HTTPHandler struct {
@@ -22,51 +27,244 @@ type HTTPHandler struct {
 	CustomerUseCase   CustomerUseCase
 }
 
-func InitHTTPHandler(router *mux.Router, customerSession *middleware.CustomerSession, validate *validator.Validate, customerUseCase CustomerUseCase) {
+var (
+	signUpRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-signup",
+		Limit:     20,
+		Period:    10 * time.Minute,
+		Algorithm: publicMiddleware.TokenBucket,
+		Key:       publicMiddleware.KeyByIP,
+	}
+	signInIPRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-signin-ip",
+		Limit:     60,
+		Period:    time.Minute,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByIP,
+	}
+	signInEmailRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-signin-email",
+		Limit:     10,
+		Period:    time.Minute,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByEmailField("email"),
+	}
+	magicLinkEmailRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-magic-link-email",
+		Limit:     5,
+		Period:    time.Hour,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByEmailField("email"),
+	}
+	phoneOTPRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-phone-otp",
+		Limit:     5,
+		Period:    time.Hour,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByJSONField("phone"),
+	}
+	phoneSignInRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-signin-phone",
+		Limit:     10,
+		Period:    time.Minute,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByJSONField("phone"),
+	}
+	recoveryIPRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-recovery-ip",
+		Limit:     20,
+		Period:    time.Hour,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByIP,
+	}
+	verifyRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-verify",
+		Limit:     30,
+		Period:    time.Minute,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       publicMiddleware.KeyByIP,
+	}
+	changeEmailRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-change-email",
+		Limit:     5,
+		Period:    time.Hour,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       middleware.KeyByAccount,
+	}
+	avatarUploadRateLimit = publicMiddleware.RateLimitRule{
+		Name:      "customer-avatar-upload",
+		Limit:     10,
+		Period:    time.Hour,
+		Algorithm: publicMiddleware.SlidingWindow,
+		Key:       middleware.KeyByAccount,
+	}
+)
+
+func InitHTTPHandler(router *mux.Router, customerSession *middleware.CustomerSession, rateLimiter *publicMiddleware.RateLimiter, idempotency *publicMiddleware.Idempotency, humanVerification *publicMiddleware.HumanVerification, validate *validator.Validate, customerUseCase CustomerUseCase) {
 	handler := &HTTPHandler{
 		Validate:        validate,
 		CustomerUseCase: customerUseCase,
 	}
 
-	router.HandleFunc("/tm-user/v1/customerapp/customers/signin", publicMiddleware.SetRouteChain(handler.SignIn)).Methods(http.MethodPost)
-	router.HandleFunc("/tm-user/v1/customerapp/customers/signup", publicMiddleware.SetRouteChain(handler.SignUp)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin", publicMiddleware.SetRouteChain(handler.SignIn, rateLimiter.Limit(signInIPRateLimit), rateLimiter.Limit(signInEmailRateLimit), humanVerification.Verify("signin"))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/refresh", publicMiddleware.SetRouteChain(handler.RefreshToken, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/2fa", publicMiddleware.SetRouteChain(handler.SignInTwoFactor, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/magic-link", publicMiddleware.SetRouteChain(handler.RequestMagicLink, rateLimiter.Limit(signInIPRateLimit), rateLimiter.Limit(magicLinkEmailRateLimit), humanVerification.Verify("signin"))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/magic-link/verify", publicMiddleware.SetRouteChain(handler.VerifyMagicLink, rateLimiter.Limit(verifyRateLimit))).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/phone", publicMiddleware.SetRouteChain(handler.RequestPhoneSignIn, rateLimiter.Limit(signInIPRateLimit), rateLimiter.Limit(phoneOTPRateLimit), humanVerification.Verify("signin"))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/phone/verify", publicMiddleware.SetRouteChain(handler.SignInPhone, rateLimiter.Limit(signInIPRateLimit), rateLimiter.Limit(phoneSignInRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/passkey", publicMiddleware.SetRouteChain(handler.BeginPasskeySignIn, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/passkey/verify", publicMiddleware.SetRouteChain(handler.SignInPasskey, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signin/consents", publicMiddleware.SetRouteChain(handler.SignInConsent, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/signup", publicMiddleware.SetRouteChain(handler.SignUp, rateLimiter.Limit(signUpRateLimit), humanVerification.Verify("signup"), idempotency.Handle(publicMiddleware.KeyByDevice))).Methods(http.MethodPost)
 	router.HandleFunc("/tm-user/v1/customerapp/customers/signout", publicMiddleware.SetRouteChain(handler.SignOut, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/consents", publicMiddleware.SetRouteChain(handler.GetConsents, customerSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/consents", publicMiddleware.SetRouteChain(handler.UpdateConsents, customerSession.Verify)).Methods(http.MethodPut)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/login-history", publicMiddleware.SetRouteChain(handler.GetLoginHistory, customerSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/sessions", publicMiddleware.SetRouteChain(handler.GetSessions, customerSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/sessions/signout-others", publicMiddleware.SetRouteChain(handler.RevokeOtherSessions, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/sessions/{id}", publicMiddleware.SetRouteChain(handler.RevokeSession, customerSession.Verify)).Methods(http.MethodDelete)
 	router.HandleFunc("/tm-user/v1/customerapp/customers/profile", publicMiddleware.SetRouteChain(handler.GetProfile, customerSession.Verify)).Methods(http.MethodGet)
-	router.HandleFunc("/tm-user/v1/customerapp/customers/profile", publicMiddleware.SetRouteChain(handler.UpdateProfile, customerSession.Verify)).Methods(http.MethodPatch)
-	router.HandleFunc("/tm-user/v1/customerapp/customers/change-email", publicMiddleware.SetRouteChain(handler.ChangeEmail, customerSession.Verify)).Methods(http.MethodPatch)
-	router.HandleFunc("/tm-user/v1/customerapp/customers/change-password", publicMiddleware.SetRouteChain(handler.ChangePassword, customerSession.Verify)).Methods(http.MethodPatch)
-	router.HandleFunc("/tm-user/v1/customerapp/customers/verify", publicMiddleware.SetRouteChain(handler.Verify)).Methods(http.MethodGet)
-	router.HandleFunc("/tm-user/v1/customerapp/customers/verify-change-email", publicMiddleware.SetRouteChain(handler.VerifyChangeEmail)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/profile", publicMiddleware.SetRouteChain(handler.UpdateProfile, customerSession.Verify, idempotency.Handle(middleware.KeyByAccount))).Methods(http.MethodPatch)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/profile/avatar", publicMiddleware.SetRouteChain(handler.UploadAvatar, customerSession.Verify, rateLimiter.Limit(avatarUploadRateLimit))).Methods(http.MethodPut)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/profile", publicMiddleware.SetRouteChain(handler.DeleteAccount, customerSession.Verify)).Methods(http.MethodDelete)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/change-email", publicMiddleware.SetRouteChain(handler.ChangeEmail, customerSession.Verify, idempotency.Handle(middleware.KeyByAccount), rateLimiter.Limit(changeEmailRateLimit))).Methods(http.MethodPatch)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/phone", publicMiddleware.SetRouteChain(handler.ChangePhone, customerSession.Verify, rateLimiter.Limit(phoneOTPRateLimit))).Methods(http.MethodPut)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/phone/verify", publicMiddleware.SetRouteChain(handler.VerifyPhone, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/change-password", publicMiddleware.SetRouteChain(handler.ChangePassword, customerSession.Verify, idempotency.Handle(middleware.KeyByAccount))).Methods(http.MethodPatch)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/2fa/enrol", publicMiddleware.SetRouteChain(handler.EnrolTwoFactor, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/2fa/confirm", publicMiddleware.SetRouteChain(handler.ConfirmTwoFactor, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/2fa/disable", publicMiddleware.SetRouteChain(handler.DisableTwoFactor, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/passkeys", publicMiddleware.SetRouteChain(handler.GetPasskeys, customerSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/passkeys/register", publicMiddleware.SetRouteChain(handler.BeginPasskeyRegistration, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/passkeys/register/confirm", publicMiddleware.SetRouteChain(handler.FinishPasskeyRegistration, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/passkeys/{id}", publicMiddleware.SetRouteChain(handler.RenamePasskey, customerSession.Verify)).Methods(http.MethodPatch)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/passkeys/{id}", publicMiddleware.SetRouteChain(handler.DeletePasskey, customerSession.Verify)).Methods(http.MethodDelete)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/forgot-password", publicMiddleware.SetRouteChain(handler.ForgotPassword, rateLimiter.Limit(recoveryIPRateLimit), humanVerification.Verify("forgot_password"), idempotency.Handle(publicMiddleware.KeyByDevice))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/reset-password", publicMiddleware.SetRouteChain(handler.ResetPassword, rateLimiter.Limit(recoveryIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/resend-verification", publicMiddleware.SetRouteChain(handler.ResendVerification, rateLimiter.Limit(recoveryIPRateLimit), humanVerification.Verify("resend_verification"), idempotency.Handle(publicMiddleware.KeyByDevice))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/data-export", publicMiddleware.SetRouteChain(handler.ExportData, customerSession.Verify, idempotency.Handle(middleware.KeyByAccount))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/data-export/download", publicMiddleware.SetRouteChain(handler.DownloadDataExport)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/deactivate", publicMiddleware.SetRouteChain(handler.Deactivate, customerSession.Verify, idempotency.Handle(middleware.KeyByAccount))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/reactivate", publicMiddleware.SetRouteChain(handler.RequestReactivation, rateLimiter.Limit(recoveryIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/verify-reactivation", publicMiddleware.SetRouteChain(handler.VerifyReactivation, rateLimiter.Limit(verifyRateLimit))).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/oidc/{provider}/authorize", publicMiddleware.SetRouteChain(handler.AuthorizeOIDC, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/oidc/{provider}/signin", publicMiddleware.SetRouteChain(handler.SignInOIDC, rateLimiter.Limit(signInIPRateLimit))).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/oidc/{provider}/link", publicMiddleware.SetRouteChain(handler.AuthorizeOIDCLink, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/oidc/{provider}/link/confirm", publicMiddleware.SetRouteChain(handler.LinkOIDC, customerSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/identities", publicMiddleware.SetRouteChain(handler.GetIdentities, customerSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/identities/{provider}", publicMiddleware.SetRouteChain(handler.UnlinkIdentity, customerSession.Verify)).Methods(http.MethodDelete)
+	router.HandleFunc("/tm-user/v1/customerapp/legal-documents", publicMiddleware.SetRouteChain(handler.GetLegalDocuments)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/verify", publicMiddleware.SetRouteChain(handler.Verify, rateLimiter.Limit(verifyRateLimit))).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/verify-change-email", publicMiddleware.SetRouteChain(handler.VerifyChangeEmail, rateLimiter.Limit(verifyRateLimit))).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/customerapp/customers/revert-change-email", publicMiddleware.SetRouteChain(handler.RevertEmailChange, rateLimiter.Limit(verifyRateLimit))).Methods(http.MethodGet)
 
 	// SignUp(ctx context.Context, req SignUpRequest) (SignUpResponse, error)
 	// SignIn(ctx context.Context, req SignInRequest) (SignInResponse, error)
 	// SignOut(ctx context.Context) error
 	// GetProfile(ctx context.Context) (GetProfileResponse, error)
 	// UpdateProfile(ctx context.Context, req UpdateProfileRequest) error
+	// UploadAvatar(ctx context.Context, req UploadAvatarRequest) (UploadAvatarResponse, error)
 	// ChangeEmail(ctx context.Context, req ChangeEmailRequest) (ChangeEmailResponse, error)
 	// ChangePassword(ctx context.Context, req ChangePasswordRequest) error
 	// Verify(ctx context.Context, req VerifyRequest) error
 	// VerifyChangeEmail(ctx context.Context, req ChangeEmailVerificationRequest) error
+	// RevertEmailChange(ctx context.Context, req ChangeEmailRevertRequest) error
+	// RefreshToken(ctx context.Context, req RefreshTokenRequest) (SignInResponse, error)
+	// GetSessions(ctx context.Context) ([]SessionResponse, error)
+	// RevokeSession(ctx context.Context, req RevokeSessionRequest) error
+	// RevokeOtherSessions(ctx context.Context) error
+	// EnrolTwoFactor(ctx context.Context) (EnrolTwoFactorResponse, error)
+	// ConfirmTwoFactor(ctx context.Context, req ConfirmTwoFactorRequest) (ConfirmTwoFactorResponse, error)
+	// DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) error
+	// SignInTwoFactor(ctx context.Context, req SignInTwoFactorRequest) (SignInResponse, error)
+	// ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error
+	// ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+	// ResendVerification(ctx context.Context, req ResendVerificationRequest) error
+	// DeleteAccount(ctx context.Context, req DeleteAccountRequest) (DeleteAccountResponse, error)
+	// ExportData(ctx context.Context, req DataExportRequest) (DataExportResponse, error)
+	// ExportDataForCustomer(ctx context.Context, req AdminDataExportRequest) (DataExportResponse, error)
+	// GetSensitiveChangesForCustomer(ctx context.Context, req AdminSensitiveChangesRequest) ([]SensitiveChangeResponse, error)
+	// DownloadDataExport(ctx context.Context, req DownloadDataExportRequest) (DataExportArchive, error)
+	// Deactivate(ctx context.Context, req DeactivateRequest) error
+	// RequestReactivation(ctx context.Context, req RequestReactivationRequest) error
+	// VerifyReactivation(ctx context.Context, req ReactivationVerificationRequest) error
+	// AuthorizeOIDC(ctx context.Context, req OIDCAuthorizationRequest) (OIDCAuthorizationResponse, error)
+	// SignInOIDC(ctx context.Context, req OIDCCallbackRequest) (SignInResponse, error)
+	// AuthorizeOIDCLink(ctx context.Context, req OIDCAuthorizationRequest) (OIDCAuthorizationResponse, error)
+	// LinkOIDC(ctx context.Context, req OIDCCallbackRequest) error
+	// GetIdentities(ctx context.Context) ([]IdentityResponse, error)
+	// UnlinkIdentity(ctx context.Context, req UnlinkIdentityRequest) error
+	// RequestMagicLink(ctx context.Context, req MagicLinkRequest) (MagicLinkResponse, error)
+	// VerifyMagicLink(ctx context.Context, req MagicLinkVerificationRequest) (SignInResponse, error)
+	// ChangePhone(ctx context.Context, req ChangePhoneRequest) (PhoneOTPResponse, error)
+	// VerifyPhone(ctx context.Context, req VerifyPhoneRequest) error
+	// RequestPhoneSignIn(ctx context.Context, req PhoneSignInRequest) (PhoneOTPResponse, error)
+	// SignInPhone(ctx context.Context, req VerifyPhoneSignInRequest) (SignInResponse, error)
+	// BeginPasskeyRegistration(ctx context.Context) (webauthn.CreationOptions, error)
+	// FinishPasskeyRegistration(ctx context.Context, req FinishPasskeyRegistrationRequest) (PasskeyResponse, error)
+	// GetPasskeys(ctx context.Context) ([]PasskeyResponse, error)
+	// RenamePasskey(ctx context.Context, req RenamePasskeyRequest) error
+	// DeletePasskey(ctx context.Context, req DeletePasskeyRequest) error
+	// BeginPasskeySignIn(ctx context.Context) (PasskeySignInChallengeResponse, error)
+	// SignInPasskey(ctx context.Context, req PasskeySignInRequest) (SignInResponse, error)
+	// GetLoginHistory(ctx context.Context, req LoginHistoryRequest) ([]LoginEventResponse, error)
+	// GetLoginHistoryForCustomer(ctx context.Context, req AdminLoginHistoryRequest) ([]LoginEventResponse, error)
+	// GetLegalDocuments(ctx context.Context) ([]LegalDocumentResponse, error)
+	// GetAllLegalDocuments(ctx context.Context) ([]LegalDocumentResponse, error)
+	// CreateLegalDocument(ctx context.Context, req CreateLegalDocumentRequest) (LegalDocumentResponse, error)
+	// PublishLegalDocument(ctx context.Context, req PublishLegalDocumentRequest) (LegalDocumentResponse, error)
+	// GetConsents(ctx context.Context) ([]ConsentResponse, error)
+	// UpdateConsents(ctx context.Context, req UpdateConsentsRequest) error
+	// SignInConsent(ctx context.Context, req SignInConsentRequest) (SignInResponse, error)
 }
 
-func (handler HTTPHandler) validate(ctx context.Context, payload interface{}) error {
-	err := handler.Validate.StructCtx(ctx, payload)
-	if err == nil {
-		return nil
+// InitAdminHTTPHandler registers the routes of the customer's resources that are managed by the administrator.
+func InitAdminHTTPHandler(router *mux.Router, adminSession *middleware.AdminSession, validate *validator.Validate, customerUseCase CustomerUseCase) {
+	handler := &HTTPHandler{
+		Validate:        validate,
+		CustomerUseCase: customerUseCase,
 	}
 
-	errorFields := err.(validator.ValidationErrors)
+	router.HandleFunc("/tm-user/v1/adminapp/customers/{id}/data-export", publicMiddleware.SetRouteChain(handler.ExportDataForCustomer, adminSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/customers/{id}/sensitive-changes", publicMiddleware.SetRouteChain(handler.GetSensitiveChangesForCustomer, adminSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/adminapp/customers/{id}/login-history", publicMiddleware.SetRouteChain(handler.GetLoginHistoryForCustomer, adminSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/adminapp/legal-documents", publicMiddleware.SetRouteChain(handler.GetAllLegalDocuments, adminSession.Verify)).Methods(http.MethodGet)
+	router.HandleFunc("/tm-user/v1/adminapp/legal-documents", publicMiddleware.SetRouteChain(handler.CreateLegalDocument, adminSession.Verify)).Methods(http.MethodPost)
+	router.HandleFunc("/tm-user/v1/adminapp/legal-documents/{id}/publish", publicMiddleware.SetRouteChain(handler.PublishLegalDocument, adminSession.Verify)).Methods(http.MethodPost)
+}
 
-	errMessages := make([]string, len(errorFields))
+// pagination reads the limit and the offset from the query, the default limit is used when it is not given.
+func pagination(r *http.Request, defaultLimit int) (int, int, error) {
+	values := r.URL.Query()
+	limit, offset := defaultLimit, 0
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, stdErrors.New("invalid limit")
+		}
+		limit = n
+	}
 
-	for k, errorField := range errorFields {
-		errMessages[k] = fmt.Sprintf("invalid '%s' with value '%v'", errorField.Field(), errorField.Value())
+	if v := values.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, stdErrors.New("invalid offset")
+		}
+		offset = n
 	}
 
-	errorMessage := strings.Join(errMessages, ", ")
+	return limit, offset, nil
+}
 
-	return fmt.Errorf(errorMessage)
+func (handler HTTPHandler) validate(ctx context.Context, payload interface{}) *publicValidator.ValidationError {
+	err := handler.Validate.StructCtx(ctx, payload)
+	if err == nil {
+		return nil
+	}
 
+	return publicValidator.NewValidationError(ctx, payload, err)
 }
 
 func (handler HTTPHandler) SignUp(w http.ResponseWriter, r *http.Request) {
@@ -86,6 +284,7 @@ func (handler HTTPHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
 			Status:  status.BAD_REQUEST,
 			Message: err.Error(),
+			Data:    err.Violations,
 		})
 
 		return
@@ -126,6 +325,7 @@ func (handler HTTPHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
 			Status:  status.BAD_REQUEST,
 			Message: err.Error(),
+			Data:    err.Violations,
 		})
 
 		return
@@ -142,6 +342,26 @@ func (handler HTTPHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if resp.ConsentRequired {
+		response.JSON(w, http.StatusOK, response.RESTEnvelope{
+			Status:  status.CONSENT_REQUIRED,
+			Message: "customer is required to accept the latest legal documents",
+			Data:    resp,
+		})
+
+		return
+	}
+
+	if resp.TwoFactorRequired {
+		response.JSON(w, http.StatusOK, response.RESTEnvelope{
+			Status:  status.OK,
+			Message: "customer is required to pass the two factor authentication",
+			Data:    resp,
+		})
+
+		return
+	}
+
 	response.JSON(w, http.StatusOK, response.RESTEnvelope{
 		Status:  status.OK,
 		Message: "customer has been successfully signed in",
@@ -149,6 +369,47 @@ func (handler HTTPHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (handler HTTPHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := RefreshTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.RefreshToken(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's token has been successfully refreshed",
+		Data:    resp,
+	})
+}
+
 func (handler HTTPHandler) SignOut(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -169,6 +430,71 @@ func (handler HTTPHandler) SignOut(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (handler HTTPHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.GetSessions(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer sessions",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := RevokeSessionRequest{
+		ID: mux.Vars(r)["id"],
+	}
+
+	err := handler.CustomerUseCase.RevokeSession(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's session has been successfully revoked",
+	})
+}
+
+func (handler HTTPHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := handler.CustomerUseCase.RevokeOtherSessions(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer has been successfully signed out from other sessions",
+	})
+}
+
 func (handler HTTPHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -207,6 +533,7 @@ func (handler HTTPHandler) UpdateProfile(w http.ResponseWriter, r *http.Request)
 		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
 			Status:  status.BAD_REQUEST,
 			Message: err.Error(),
+			Data:    err.Violations,
 		})
 
 		return
@@ -229,6 +556,63 @@ func (handler HTTPHandler) UpdateProfile(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+func (handler HTTPHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// the form may carry a little more than the avatar itself, so the body is limited with some room for the multipart's overhead.
+	r.Body = http.MaxBytesReader(w, r.Body, AvatarMaxSize+(1<<20))
+
+	file, _, err := r.FormFile(AvatarFormField)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stdErrors.As(err, &maxBytesErr) {
+			response.JSON(w, http.StatusRequestEntityTooLarge, response.RESTEnvelope{
+				Status:  status.PAYLOAD_TOO_LARGE,
+				Message: fmt.Sprintf("avatar must not be larger than %d bytes", AvatarMaxSize),
+			})
+
+			return
+		}
+
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: fmt.Sprintf("multipart form with '%s' file is required", AvatarFormField),
+		})
+
+		return
+	}
+	defer file.Close()
+	defer r.MultipartForm.RemoveAll()
+
+	// one more byte is read, so the use case can tell that the avatar is too large.
+	content, err := io.ReadAll(io.LimitReader(file, AvatarMaxSize+1))
+	if err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.UploadAvatar(ctx, UploadAvatarRequest{Content: content})
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer has been successfully uploaded avatar",
+		Data:    resp,
+	})
+}
+
 func (handler HTTPHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -246,6 +630,7 @@ func (handler HTTPHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
 			Status:  status.BAD_REQUEST,
 			Message: err.Error(),
+			Data:    err.Violations,
 		})
 
 		return
@@ -282,10 +667,15 @@ func (handler HTTPHandler) ChangePassword(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if acc, err := session.GetAccountFromCtx(ctx); err == nil {
+		ctx = publicValidator.WithPasswordIdentities(ctx, acc.Email, acc.Name)
+	}
+
 	if err := handler.validate(ctx, req); err != nil {
 		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
 			Status:  status.BAD_REQUEST,
 			Message: err.Error(),
+			Data:    err.Violations,
 		})
 
 		return
@@ -308,17 +698,61 @@ func (handler HTTPHandler) ChangePassword(w http.ResponseWriter, r *http.Request
 	})
 }
 
-func (handler HTTPHandler) Verify(w http.ResponseWriter, r *http.Request) {
+func (handler HTTPHandler) SignInTwoFactor(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	req := VerifyRequest{}
+	req := SignInTwoFactorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
 
-	values := r.URL.Query()
-	token := values.Get("token")
+		return
+	}
 
-	req.Token = token
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
 
-	err := handler.CustomerUseCase.Verify(ctx, req)
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.SignInTwoFactor(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	if resp.ConsentRequired {
+		response.JSON(w, http.StatusOK, response.RESTEnvelope{
+			Status:  status.CONSENT_REQUIRED,
+			Message: "customer is required to accept the latest legal documents",
+			Data:    resp,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer has been successfully signed in",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) EnrolTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.EnrolTwoFactor(ctx)
 	if err != nil {
 		ae := errors.Destruct(err)
 		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
@@ -331,21 +765,35 @@ func (handler HTTPHandler) Verify(w http.ResponseWriter, r *http.Request) {
 
 	response.JSON(w, http.StatusOK, response.RESTEnvelope{
 		Status:  status.OK,
-		Message: "customer verification succeded",
+		Message: "customer's two factor enrolment has been started",
+		Data:    resp,
 	})
 }
 
-func (handler HTTPHandler) VerifyChangeEmail(w http.ResponseWriter, r *http.Request) {
+func (handler HTTPHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	req := ChangeEmailVerificationRequest{}
+	req := ConfirmTwoFactorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
 
-	values := r.URL.Query()
-	token := values.Get("token")
+		return
+	}
 
-	req.Token = token
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
 
-	err := handler.CustomerUseCase.VerifyChangeEmail(ctx, req)
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.ConfirmTwoFactor(ctx, req)
 	if err != nil {
 		ae := errors.Destruct(err)
 		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
@@ -358,6 +806,1577 @@ func (handler HTTPHandler) VerifyChangeEmail(w http.ResponseWriter, r *http.Requ
 
 	response.JSON(w, http.StatusOK, response.RESTEnvelope{
 		Status:  status.OK,
-		Message: "customer change email verification succeded",
+		Message: "customer's two factor authentication has been successfully enabled",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := DisableTwoFactorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.DisableTwoFactor(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's two factor authentication has been successfully disabled",
+	})
+}
+
+func (handler HTTPHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ForgotPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.ForgotPassword(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "if the email is registered, a reset password link will be sent to it",
+	})
+}
+
+func (handler HTTPHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ResetPasswordRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.ResetPassword(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's password has been successfully reset",
+	})
+}
+
+func (handler HTTPHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ResendVerificationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.ResendVerification(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "if the email is registered and not verified yet, a verification link will be sent to it",
+	})
+}
+
+func (handler HTTPHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := DeleteAccountRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.DeleteAccount(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's account has been successfully deleted",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// the body is optional, the default format is used when it is empty.
+	req := DataExportRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.ExportData(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, response.RESTEnvelope{
+		Status:  status.ACCEPTED,
+		Message: "customer's data export is being prepared, a download link will be sent to the customer's email",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) ExportDataForCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// the body is optional, the default format is used when it is empty.
+	req := AdminDataExportRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	customerID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid customer's id",
+		})
+
+		return
+	}
+
+	req.CustomerID = customerID
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.ExportDataForCustomer(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, response.RESTEnvelope{
+		Status:  status.ACCEPTED,
+		Message: "customer's data export is being prepared, a download link will be sent to the customer's email",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	values := r.URL.Query()
+	expires, _ := strconv.ParseInt(values.Get("expires"), 10, 64)
+
+	req := DownloadDataExportRequest{
+		ID:        values.Get("id"),
+		Expires:   expires,
+		Signature: values.Get("signature"),
+	}
+
+	archive, err := handler.CustomerUseCase.DownloadDataExport(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	w.Header().Set("Content-Type", archive.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.FileName))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive.Content)
+}
+
+func (handler HTTPHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := DeactivateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.Deactivate(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's account has been successfully deactivated",
+	})
+}
+
+func (handler HTTPHandler) RequestReactivation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := RequestReactivationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.RequestReactivation(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "if the email belongs to a deactivated account, a reactivation link will be sent to it",
+	})
+}
+
+func (handler HTTPHandler) VerifyReactivation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ReactivationVerificationRequest{}
+
+	values := r.URL.Query()
+	token := values.Get("token")
+
+	req.Token = token
+
+	err := handler.CustomerUseCase.VerifyReactivation(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's account has been successfully reactivated",
+	})
+}
+
+func (handler HTTPHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := VerifyRequest{}
+
+	values := r.URL.Query()
+	token := values.Get("token")
+
+	req.Token = token
+
+	err := handler.CustomerUseCase.Verify(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer verification succeded",
+	})
+}
+
+func (handler HTTPHandler) VerifyChangeEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ChangeEmailVerificationRequest{}
+
+	values := r.URL.Query()
+	token := values.Get("token")
+
+	req.Token = token
+
+	err := handler.CustomerUseCase.VerifyChangeEmail(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer change email verification succeded",
+	})
+}
+
+func (handler HTTPHandler) RevertEmailChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ChangeEmailRevertRequest{
+		Token: r.URL.Query().Get("token"),
+	}
+
+	err := handler.CustomerUseCase.RevertEmailChange(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's email change has been reverted, follow the reset password link that has been sent to the email",
+	})
+}
+
+func (handler HTTPHandler) GetSensitiveChangesForCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	customerID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid customer's id",
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.GetSensitiveChangesForCustomer(ctx, AdminSensitiveChangesRequest{CustomerID: customerID})
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's sensitive changes",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) AuthorizeOIDC(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := OIDCAuthorizationRequest{
+		Provider: mux.Vars(r)["provider"],
+	}
+
+	resp, err := handler.CustomerUseCase.AuthorizeOIDC(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's authorization url",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) SignInOIDC(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := OIDCCallbackRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	req.Provider = mux.Vars(r)["provider"]
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.SignInOIDC(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	if resp.ConsentRequired {
+		response.JSON(w, http.StatusOK, response.RESTEnvelope{
+			Status:  status.CONSENT_REQUIRED,
+			Message: "customer is required to accept the latest legal documents",
+			Data:    resp,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer signed in",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) AuthorizeOIDCLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := OIDCAuthorizationRequest{
+		Provider: mux.Vars(r)["provider"],
+	}
+
+	resp, err := handler.CustomerUseCase.AuthorizeOIDCLink(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's authorization url",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) LinkOIDC(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := OIDCCallbackRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	req.Provider = mux.Vars(r)["provider"]
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.LinkOIDC(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "provider has been successfully linked to customer's account",
+	})
+}
+
+func (handler HTTPHandler) GetIdentities(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.GetIdentities(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's linked identities",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := UnlinkIdentityRequest{
+		Provider: mux.Vars(r)["provider"],
+	}
+
+	err := handler.CustomerUseCase.UnlinkIdentity(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "provider has been successfully unlinked from customer's account",
+	})
+}
+
+func (handler HTTPHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := MagicLinkRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.RequestMagicLink(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     MagicLinkNonceCookie,
+		Value:    resp.Nonce,
+		Path:     MagicLinkNonceCookiePath,
+		Expires:  resp.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "if the email belongs to a customer, a magic link will be sent to it",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) VerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := MagicLinkVerificationRequest{
+		Token: r.URL.Query().Get("token"),
+		Nonce: r.Header.Get(MagicLinkNonceHeader),
+	}
+
+	if cookie, err := r.Cookie(MagicLinkNonceCookie); err == nil && req.Nonce == "" {
+		req.Nonce = cookie.Value
+	}
+
+	resp, err := handler.CustomerUseCase.VerifyMagicLink(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	if resp.ConsentRequired {
+		response.JSON(w, http.StatusOK, response.RESTEnvelope{
+			Status:  status.CONSENT_REQUIRED,
+			Message: "customer is required to accept the latest legal documents",
+			Data:    resp,
+		})
+
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     MagicLinkNonceCookie,
+		Path:     MagicLinkNonceCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer signed in",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) ChangePhone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := ChangePhoneRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.ChangePhone(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "a one-time password has been sent to the new phone",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := VerifyPhoneRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.VerifyPhone(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's phone has been successfully verified",
+	})
+}
+
+func (handler HTTPHandler) RequestPhoneSignIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := PhoneSignInRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.RequestPhoneSignIn(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "if the phone belongs to a customer, a one-time password will be sent to it",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) SignInPhone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := VerifyPhoneSignInRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.SignInPhone(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	if resp.ConsentRequired {
+		response.JSON(w, http.StatusOK, response.RESTEnvelope{
+			Status:  status.CONSENT_REQUIRED,
+			Message: "customer is required to accept the latest legal documents",
+			Data:    resp,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer signed in",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.BeginPasskeyRegistration(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey's registration has been successfully started",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := FinishPasskeyRegistrationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.FinishPasskeyRegistration(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, response.RESTEnvelope{
+		Status:  status.CREATED,
+		Message: "passkey has been successfully registered",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) GetPasskeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.GetPasskeys(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's passkeys",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) RenamePasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := RenamePasskeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid passkey's id",
+		})
+
+		return
+	}
+
+	req.ID = ID
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err = handler.CustomerUseCase.RenamePasskey(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey has been successfully renamed",
+	})
+}
+
+func (handler HTTPHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := DeletePasskeyRequest{}
+
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid passkey's id",
+		})
+
+		return
+	}
+
+	req.ID = ID
+
+	err = handler.CustomerUseCase.DeletePasskey(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey has been successfully deleted",
+	})
+}
+
+func (handler HTTPHandler) BeginPasskeySignIn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.BeginPasskeySignIn(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "passkey's challenge has been successfully created",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) SignInPasskey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := PasskeySignInRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.SignInPasskey(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	if resp.ConsentRequired {
+		response.JSON(w, http.StatusOK, response.RESTEnvelope{
+			Status:  status.CONSENT_REQUIRED,
+			Message: "customer is required to accept the latest legal documents",
+			Data:    resp,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer has been successfully signed in",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, offset, err := pagination(r, loginHistoryDefaultLimit)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	req := LoginHistoryRequest{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.GetLoginHistory(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's login history",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) GetLoginHistoryForCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	customerID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid customer's id",
+		})
+
+		return
+	}
+
+	limit, offset, err := pagination(r, loginHistoryDefaultLimit)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	req := AdminLoginHistoryRequest{
+		CustomerID: customerID,
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.GetLoginHistoryForCustomer(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's login history",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) GetLegalDocuments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.GetLegalDocuments(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "legal documents",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) GetConsents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.GetConsents(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's consents",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) UpdateConsents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := UpdateConsentsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	err := handler.CustomerUseCase.UpdateConsents(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer's consents have been successfully updated",
+	})
+}
+
+func (handler HTTPHandler) SignInConsent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := SignInConsentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.SignInConsent(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "customer has been successfully signed in",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) GetAllLegalDocuments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	resp, err := handler.CustomerUseCase.GetAllLegalDocuments(ctx)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "legal documents",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) CreateLegalDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := CreateLegalDocumentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := handler.validate(ctx, req); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: err.Error(),
+			Data:    err.Violations,
+		})
+
+		return
+	}
+
+	resp, err := handler.CustomerUseCase.CreateLegalDocument(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, response.RESTEnvelope{
+		Status:  status.CREATED,
+		Message: "legal document has been successfully created",
+		Data:    resp,
+	})
+}
+
+func (handler HTTPHandler) PublishLegalDocument(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+			Status:  status.BAD_REQUEST,
+			Message: "invalid legal document's id",
+		})
+
+		return
+	}
+
+	req := PublishLegalDocumentRequest{
+		ID: ID,
+	}
+
+	resp, err := handler.CustomerUseCase.PublishLegalDocument(ctx, req)
+	if err != nil {
+		ae := errors.Destruct(err)
+		response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+			Status:  ae.Status,
+			Message: ae.Message,
+		})
+
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.RESTEnvelope{
+		Status:  status.OK,
+		Message: "legal document has been successfully published",
+		Data:    resp,
 	})
 }
@@ -0,0 +1,348 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/oidc"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// AuthorizeOIDC implements CustomerUseCase.
+func (u *customerUseCase) AuthorizeOIDC(ctx context.Context, req OIDCAuthorizationRequest) (OIDCAuthorizationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	return u.authorizeOIDC(ctx, req.Provider, OIDCState{Intent: OIDCIntentSignIn})
+}
+
+// AuthorizeOIDCLink implements CustomerUseCase.
+func (u *customerUseCase) AuthorizeOIDCLink(ctx context.Context, req OIDCAuthorizationRequest) (OIDCAuthorizationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return OIDCAuthorizationResponse{}, err
+	}
+
+	return u.authorizeOIDC(ctx, req.Provider, OIDCState{Intent: OIDCIntentLink, CustomerID: acc.ID})
+}
+
+// authorizeOIDC keeps the state, nonce and code verifier of the authorization request until the provider calls back.
+func (u *customerUseCase) authorizeOIDC(ctx context.Context, providerName string, state OIDCState) (OIDCAuthorizationResponse, error) {
+	provider, err := u.oidcProvider(providerName)
+	if err != nil {
+		return OIDCAuthorizationResponse{}, err
+	}
+
+	stateToken := util.GenerateRandomHEX(32)
+	expiresAt := time.Now().Add(oidcStateExpiresIn)
+
+	state.Provider = provider.Name()
+	state.Nonce = util.GenerateRandomHEX(16)
+	state.CodeVerifier = oidc.GenerateCodeVerifier()
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, stateToken, state.Nonce, oidc.CodeChallengeS256(state.CodeVerifier))
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).WithField("provider", provider.Name()).Error()
+		return OIDCAuthorizationResponse{}, errors.New(http.StatusBadGateway, status.BAD_GATEWAY, fmt.Sprintf("provider '%s' is not available", provider.Name()))
+	}
+
+	stateBuff, _ := json.Marshal(state)
+
+	if err := u.cache.Set(ctx, fmt.Sprintf(oidcStateKeyPrefix, stateToken), stateBuff, oidcStateExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return OIDCAuthorizationResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while authorizing customer")
+	}
+
+	resp := OIDCAuthorizationResponse{
+		AuthorizationURL: authorizationURL,
+		State:            stateToken,
+		ExpiresAt:        expiresAt,
+	}
+
+	return resp, nil
+}
+
+// SignInOIDC implements CustomerUseCase. The customer is registered on the first sign in when the provider asserts a verified email that is not registered yet.
+func (u *customerUseCase) SignInOIDC(ctx context.Context, req OIDCCallbackRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	state, claims, err := u.exchangeOIDC(ctx, req, OIDCIntentSignIn)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	var c Customer
+
+	identity, err := u.customerIdentityRepository.FindByProviderAndSubject(ctx, state.Provider, claims.Subject, nil)
+	if err == nil {
+		c, err = u.customerRepository.FindByID(ctx, identity.CustomerID, nil)
+		if err != nil {
+			return SignInResponse{}, err
+		}
+
+		if c.DeletedAt != nil {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "customer's account has been deleted")
+		}
+	} else {
+		if !errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, err
+		}
+
+		c, err = u.registerOIDC(ctx, state.Provider, claims)
+		if err != nil {
+			return SignInResponse{}, err
+		}
+	}
+
+	if c.MemberStatus != MemberStatusActive {
+		return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodOIDC, LoginFailureAccountInactive, errors.New(http.StatusForbidden, status.ACCOUNT_INACTIVE, "customer's account is deactivated, request a reactivation link to activate it again"))
+	}
+
+	if c.TwoFactorEnabled {
+		return u.challengeTwoFactor(ctx, c, LoginMethodOIDC)
+	}
+
+	return u.completeSignIn(ctx, c, LoginMethodOIDC)
+}
+
+// registerOIDC creates the customer of the identity. The existing account is never linked implicitly, because the provider may assert an email that is owned by someone else.
+func (u *customerUseCase) registerOIDC(ctx context.Context, provider string, claims oidc.Claims) (Customer, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return Customer{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, fmt.Sprintf("provider '%s' does not assert a verified email", provider))
+	}
+
+	claims.Email = u.canonicalEmail(claims.Email)
+	if err := u.checkEmailDomain(claims.Email); err != nil {
+		return Customer{}, err
+	}
+
+	_, err := u.findCustomerByEmail(ctx, claims.Email, nil)
+	if err == nil {
+		return Customer{}, errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("customer with email '%s' is already registered, sign in with the password and link the provider from the profile", claims.Email))
+	}
+
+	if !errors.MatchStatus(err, status.NOT_FOUND) {
+		return Customer{}, err
+	}
+
+	if err := u.checkEmailReservation(ctx, claims.Email, 0); err != nil {
+		return Customer{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = strings.Split(claims.Email, "@")[0]
+	}
+
+	now := time.Now()
+	// the customer signs in through the provider only, the password can be set by resetting it.
+	c := Customer{
+		Name:               name,
+		Email:              claims.Email,
+		VerificationStatus: VerficationStatusVerified,
+		MemberStatus:       MemberStatusActive,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	ID, err := u.customerRepository.Save(ctx, c, nil)
+	if err != nil {
+		return Customer{}, err
+	}
+
+	c.ID = ID
+
+	if err := u.linkIdentity(ctx, c, provider, claims); err != nil {
+		return Customer{}, err
+	}
+
+	return c, nil
+}
+
+// LinkOIDC implements CustomerUseCase.
+func (u *customerUseCase) LinkOIDC(ctx context.Context, req OIDCCallbackRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	state, claims, err := u.exchangeOIDC(ctx, req, OIDCIntentLink)
+	if err != nil {
+		return err
+	}
+
+	if state.CustomerID != acc.ID {
+		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid or expired state")
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	identity, err := u.customerIdentityRepository.FindByProviderAndSubject(ctx, state.Provider, claims.Subject, nil)
+	if err == nil {
+		if identity.CustomerID == c.ID {
+			return nil
+		}
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("the account of '%s' has been linked to another customer", state.Provider))
+	}
+
+	if !errors.MatchStatus(err, status.NOT_FOUND) {
+		return err
+	}
+
+	return u.linkIdentity(ctx, c, state.Provider, claims)
+}
+
+func (u *customerUseCase) linkIdentity(ctx context.Context, c Customer, provider string, claims oidc.Claims) error {
+	now := time.Now()
+	identity := CustomerIdentity{
+		CustomerID: c.ID,
+		Provider:   provider,
+		Subject:    claims.Subject,
+		Email:      claims.Email,
+		CreatedAt:  now,
+	}
+
+	if _, err := u.customerIdentityRepository.Save(ctx, identity, nil); err != nil {
+		return err
+	}
+
+	identityLinkedEvent := IdentityLinkedEvent{
+		ID:       c.ID,
+		Name:     c.Name,
+		Email:    c.Email,
+		Provider: provider,
+		LinkedAt: now,
+	}
+
+	identityLinkedEventBuff, _ := json.Marshal(identityLinkedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-identity-linked", fmt.Sprintf("customer:%d", c.ID), messageHeader, identityLinkedEventBuff)
+
+	return nil
+}
+
+// exchangeOIDC consumes the state and trades the authorization code for the verified identity of the provider.
+func (u *customerUseCase) exchangeOIDC(ctx context.Context, req OIDCCallbackRequest, intent string) (OIDCState, oidc.Claims, error) {
+	stateBuff, err := u.cache.GetDel(ctx, fmt.Sprintf(oidcStateKeyPrefix, req.State)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return OIDCState{}, oidc.Claims{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid or expired state")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return OIDCState{}, oidc.Claims{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	var state OIDCState
+	json.Unmarshal(stateBuff, &state)
+
+	if state.Provider != req.Provider || state.Intent != intent {
+		return OIDCState{}, oidc.Claims{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid or expired state")
+	}
+
+	provider, err := u.oidcProvider(state.Provider)
+	if err != nil {
+		return OIDCState{}, oidc.Claims{}, err
+	}
+
+	token, err := provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).WithField("provider", provider.Name()).Warn()
+		return OIDCState{}, oidc.Claims{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid authorization code")
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).WithField("provider", provider.Name()).Warn()
+		return OIDCState{}, oidc.Claims{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid id token")
+	}
+
+	return state, claims, nil
+}
+
+func (u *customerUseCase) oidcProvider(name string) (*oidc.Provider, error) {
+	provider, ok := u.oidcProviders[name]
+	if !ok {
+		return nil, errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("provider '%s' is not found", name))
+	}
+
+	return provider, nil
+}
+
+// GetIdentities implements CustomerUseCase.
+func (u *customerUseCase) GetIdentities(ctx context.Context) ([]IdentityResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := u.customerIdentityRepository.FindByCustomerID(ctx, acc.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]IdentityResponse, len(identities))
+	for k, identity := range identities {
+		resp[k] = IdentityResponse{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		}
+	}
+
+	return resp, nil
+}
+
+// UnlinkIdentity implements CustomerUseCase.
+func (u *customerUseCase) UnlinkIdentity(ctx context.Context, req UnlinkIdentityRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	if c.Password == "" {
+		identities, err := u.customerIdentityRepository.FindByCustomerID(ctx, c.ID, nil)
+		if err != nil {
+			return err
+		}
+
+		// the customer must not lose every way to sign in.
+		if len(identities) <= 1 {
+			return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "set a password before unlinking the only sign in method")
+		}
+	}
+
+	return u.customerIdentityRepository.Delete(ctx, c.ID, req.Provider, nil)
+}
@@ -0,0 +1,240 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type CustomerIdentityRepository interface {
+	Save(ctx context.Context, ci CustomerIdentity, tx *sql.Tx) (int64, error)
+	FindByProviderAndSubject(ctx context.Context, provider, subject string, tx *sql.Tx) (CustomerIdentity, error)
+	FindByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerIdentity, error)
+	Delete(ctx context.Context, customerID int64, provider string, tx *sql.Tx) error
+	DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error
+}
+
+const customerIdentityColumns = `
+	id, customer_id, provider, subject, email, created_at
+`
+
+func scanCustomerIdentity(row rowScanner) (CustomerIdentity, error) {
+	var data CustomerIdentity
+
+	err := row.Scan(&data.ID, &data.CustomerID, &data.Provider, &data.Subject, &data.Email, &data.CreatedAt)
+
+	return data, err
+}
+
+type customerIdentityRepository struct {
+	logger *logrus.Logger
+	db     *sql.DB
+}
+
+// Save implements CustomerIdentityRepository. The identity is rejected when it has been linked to any customer, or the customer has linked another account of the same provider.
+func (r *customerIdentityRepository) Save(ctx context.Context, ci CustomerIdentity, tx *sql.Tx) (int64, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		INSERT INTO customer_identity
+		(
+			customer_id, provider, subject, email, created_at
+		)
+		VALUES
+		(
+			$1, $2, $3, $4, $5
+		)
+		RETURNING id
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's identity")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, ci.CustomerID, ci.Provider, ci.Subject, ci.Email, ci.CreatedAt)
+
+	var ID int64
+
+	err = row.Scan(&ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stdErrors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("the account of '%s' has already been linked", ci.Provider))
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's identity")
+	}
+
+	return ID, nil
+}
+
+// FindByProviderAndSubject implements CustomerIdentityRepository.
+func (r *customerIdentityRepository) FindByProviderAndSubject(ctx context.Context, provider, subject string, tx *sql.Tx) (CustomerIdentity, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + customerIdentityColumns + `
+		FROM customer_identity
+		WHERE
+			provider = $1
+			AND subject = $2
+		LIMIT 1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return CustomerIdentity{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's identity")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, provider, subject)
+
+	data, err := scanCustomerIdentity(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return CustomerIdentity{}, errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("customer's identity of '%s' is not found", provider))
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return CustomerIdentity{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's identity")
+	}
+
+	return data, nil
+}
+
+// FindByCustomerID implements CustomerIdentityRepository.
+func (r *customerIdentityRepository) FindByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerIdentity, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + customerIdentityColumns + `
+		FROM customer_identity
+		WHERE
+			customer_id = $1
+		ORDER BY created_at ASC
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's identities")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, customerID)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's identities")
+	}
+	defer rows.Close()
+
+	identities := []CustomerIdentity{}
+	for rows.Next() {
+		data, err := scanCustomerIdentity(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error()
+			return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's identities")
+		}
+		identities = append(identities, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's identities")
+	}
+
+	return identities, nil
+}
+
+// Delete implements CustomerIdentityRepository.
+func (r *customerIdentityRepository) Delete(ctx context.Context, customerID int64, provider string, tx *sql.Tx) error {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		DELETE FROM customer_identity
+		WHERE
+			customer_id = $1
+			AND provider = $2
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's identity")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, customerID, provider)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's identity")
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("customer's identity of '%s' is not found", provider))
+	}
+
+	return nil
+}
+
+// DeleteByCustomerID implements CustomerIdentityRepository.
+func (r *customerIdentityRepository) DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		DELETE FROM customer_identity
+		WHERE
+			customer_id = $1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's identities")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, customerID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's identities")
+	}
+
+	return nil
+}
+
+func NewCustomerIdentityRepository(logger *logrus.Logger, db *sql.DB) CustomerIdentityRepository {
+	return &customerIdentityRepository{
+		logger: logger,
+		db:     db,
+	}
+}
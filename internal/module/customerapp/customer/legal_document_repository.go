@@ -0,0 +1,269 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type LegalDocumentRepository interface {
+	Save(ctx context.Context, ld LegalDocument, tx *sql.Tx) (int64, error)
+	FindByID(ctx context.Context, ID int64, tx *sql.Tx) (LegalDocument, error)
+	FindAll(ctx context.Context, tx *sql.Tx) ([]LegalDocument, error)
+	FindLatestPublished(ctx context.Context, tx *sql.Tx) ([]LegalDocument, error)
+	FindPendingForCustomer(ctx context.Context, customerID int64, tx *sql.Tx) ([]LegalDocument, error)
+	Publish(ctx context.Context, ID int64, publishedAt time.Time, tx *sql.Tx) error
+}
+
+const legalDocumentColumns = `
+	id, type, version, title, url, mandatory, published_at, created_by, created_at, updated_at
+`
+
+func scanLegalDocument(row rowScanner) (LegalDocument, error) {
+	var data LegalDocument
+
+	err := row.Scan(&data.ID, &data.Type, &data.Version, &data.Title, &data.URL, &data.Mandatory, &data.PublishedAt, &data.CreatedBy, &data.CreatedAt, &data.UpdatedAt)
+
+	return data, err
+}
+
+type legalDocumentRepository struct {
+	logger *logrus.Logger
+	db     *sql.DB
+}
+
+// Save implements LegalDocumentRepository.
+func (r *legalDocumentRepository) Save(ctx context.Context, ld LegalDocument, tx *sql.Tx) (int64, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		INSERT INTO legal_document
+		(
+			type, version, title, url, mandatory, published_at, created_by, created_at, updated_at
+		)
+		VALUES
+		(
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		RETURNING id
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving legal document")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, ld.Type, ld.Version, ld.Title, ld.URL, ld.Mandatory, ld.PublishedAt, ld.CreatedBy, ld.CreatedAt, ld.UpdatedAt)
+
+	var ID int64
+
+	if err := row.Scan(&ID); err != nil {
+		var pgErr *pgconn.PgError
+		if stdErrors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("legal document '%s' with version '%s' is already exist", ld.Type, ld.Version))
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving legal document")
+	}
+
+	return ID, nil
+}
+
+// FindByID implements LegalDocumentRepository.
+func (r *legalDocumentRepository) FindByID(ctx context.Context, ID int64, tx *sql.Tx) (LegalDocument, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + legalDocumentColumns + `
+		FROM legal_document
+		WHERE
+			id = $1
+		LIMIT 1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return LegalDocument{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting legal document")
+	}
+	defer stmt.Close()
+
+	data, err := scanLegalDocument(stmt.QueryRowContext(ctx, ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return LegalDocument{}, errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("legal document with id '%d' is not found", ID))
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return LegalDocument{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting legal document")
+	}
+
+	return data, nil
+}
+
+// FindAll implements LegalDocumentRepository. The latest version of the type comes first.
+func (r *legalDocumentRepository) FindAll(ctx context.Context, tx *sql.Tx) ([]LegalDocument, error) {
+	query := `
+		SELECT ` + legalDocumentColumns + `
+		FROM legal_document
+		ORDER BY type, created_at DESC
+	`
+
+	return r.query(ctx, tx, query)
+}
+
+// FindLatestPublished implements LegalDocumentRepository. It returns the current version of every type.
+func (r *legalDocumentRepository) FindLatestPublished(ctx context.Context, tx *sql.Tx) ([]LegalDocument, error) {
+	query := `
+		SELECT DISTINCT ON (type) ` + legalDocumentColumns + `
+		FROM legal_document
+		WHERE
+			published_at IS NOT NULL
+		ORDER BY type, published_at DESC
+	`
+
+	return r.query(ctx, tx, query)
+}
+
+// FindPendingForCustomer implements LegalDocumentRepository. It returns the current version of every type that has a mandatory version
+// which is published after the latest version accepted by the customer.
+func (r *legalDocumentRepository) FindPendingForCustomer(ctx context.Context, customerID int64, tx *sql.Tx) ([]LegalDocument, error) {
+	query := `
+		SELECT ` + legalDocumentColumns + `
+		FROM (
+			SELECT DISTINCT ON (type) ` + legalDocumentColumns + `
+			FROM legal_document
+			WHERE
+				published_at IS NOT NULL
+			ORDER BY type, published_at DESC
+		) latest
+		WHERE EXISTS (
+			SELECT 1
+			FROM legal_document mandatory
+			WHERE
+				mandatory.type = latest.type
+			AND
+				mandatory.mandatory
+			AND
+				mandatory.published_at IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1
+				FROM customer_consent cc
+				JOIN legal_document accepted ON accepted.id = cc.document_id
+				WHERE
+					cc.customer_id = $1
+				AND
+					cc.type = mandatory.type
+				AND
+					cc.granted
+				AND
+					accepted.published_at >= mandatory.published_at
+			)
+		)
+		ORDER BY type
+	`
+
+	return r.query(ctx, tx, query, customerID)
+}
+
+func (r *legalDocumentRepository) query(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]LegalDocument, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting legal documents")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting legal documents")
+	}
+	defer rows.Close()
+
+	documents := []LegalDocument{}
+	for rows.Next() {
+		data, err := scanLegalDocument(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error()
+			return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting legal documents")
+		}
+		documents = append(documents, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting legal documents")
+	}
+
+	return documents, nil
+}
+
+// Publish implements LegalDocumentRepository. The published document is not changed anymore, so it is published only once.
+func (r *legalDocumentRepository) Publish(ctx context.Context, ID int64, publishedAt time.Time, tx *sql.Tx) error {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		UPDATE legal_document
+		SET
+			published_at = $2,
+			updated_at = $2
+		WHERE
+			id = $1
+		AND
+			published_at IS NULL
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while publishing legal document")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, ID, publishedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while publishing legal document")
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, "legal document is not found or has been published")
+	}
+
+	return nil
+}
+
+func NewLegalDocumentRepository(logger *logrus.Logger, db *sql.DB) LegalDocumentRepository {
+	return &legalDocumentRepository{
+		logger: logger,
+		db:     db,
+	}
+}
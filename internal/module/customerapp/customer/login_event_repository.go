@@ -0,0 +1,198 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type CustomerLoginEventRepository interface {
+	Save(ctx context.Context, cle CustomerLoginEvent, tx *sql.Tx) (int64, error)
+	FindByCustomerID(ctx context.Context, customerID int64, limit, offset int, tx *sql.Tx) ([]CustomerLoginEvent, error)
+	FindKnownClient(ctx context.Context, customerID int64, deviceFingerprint, country string, tx *sql.Tx) (KnownLoginClient, error)
+	DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error
+}
+
+const customerLoginEventColumns = `
+	id, customer_id, method, outcome, failure_reason, ip_address, user_agent, device, device_fingerprint, country, created_at
+`
+
+func scanCustomerLoginEvent(row rowScanner) (CustomerLoginEvent, error) {
+	var data CustomerLoginEvent
+
+	err := row.Scan(&data.ID, &data.CustomerID, &data.Method, &data.Outcome, &data.FailureReason, &data.IPAddress, &data.UserAgent, &data.Device, &data.DeviceFingerprint, &data.Country, &data.CreatedAt)
+
+	return data, err
+}
+
+type customerLoginEventRepository struct {
+	logger *logrus.Logger
+	db     *sql.DB
+}
+
+// Save implements CustomerLoginEventRepository.
+func (r *customerLoginEventRepository) Save(ctx context.Context, cle CustomerLoginEvent, tx *sql.Tx) (int64, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		INSERT INTO customer_login_event
+		(
+			customer_id, method, outcome, failure_reason, ip_address, user_agent, device, device_fingerprint, country, created_at
+		)
+		VALUES
+		(
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)
+		RETURNING id
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's login event")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, cle.CustomerID, cle.Method, cle.Outcome, cle.FailureReason, cle.IPAddress, cle.UserAgent, cle.Device, cle.DeviceFingerprint, cle.Country, cle.CreatedAt)
+
+	var ID int64
+
+	if err := row.Scan(&ID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's login event")
+	}
+
+	return ID, nil
+}
+
+// FindByCustomerID implements CustomerLoginEventRepository. The latest event comes first, the limit of 0 returns every event.
+func (r *customerLoginEventRepository) FindByCustomerID(ctx context.Context, customerID int64, limit, offset int, tx *sql.Tx) ([]CustomerLoginEvent, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + customerLoginEventColumns + `
+		FROM customer_login_event
+		WHERE
+			customer_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT NULLIF($2, 0)
+		OFFSET $3
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's login history")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, customerID, limit, offset)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's login history")
+	}
+	defer rows.Close()
+
+	events := []CustomerLoginEvent{}
+	for rows.Next() {
+		data, err := scanCustomerLoginEvent(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error()
+			return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's login history")
+		}
+		events = append(events, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's login history")
+	}
+
+	return events, nil
+}
+
+// FindKnownClient implements CustomerLoginEventRepository. Only the successful sign ins are taken into account.
+func (r *customerLoginEventRepository) FindKnownClient(ctx context.Context, customerID int64, deviceFingerprint, country string, tx *sql.Tx) (KnownLoginClient, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT
+			COUNT(*),
+			COALESCE(BOOL_OR(device_fingerprint = $3), FALSE),
+			COALESCE(BOOL_OR(country = $4), FALSE)
+		FROM customer_login_event
+		WHERE
+			customer_id = $1
+		AND
+			outcome = $2
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return KnownLoginClient{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's login history")
+	}
+	defer stmt.Close()
+
+	var data KnownLoginClient
+
+	row := stmt.QueryRowContext(ctx, customerID, LoginOutcomeSuccess, deviceFingerprint, country)
+	if err := row.Scan(&data.SuccessCount, &data.Device, &data.Country); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return KnownLoginClient{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's login history")
+	}
+
+	return data, nil
+}
+
+// DeleteByCustomerID implements CustomerLoginEventRepository.
+func (r *customerLoginEventRepository) DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		DELETE FROM customer_login_event
+		WHERE
+			customer_id = $1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's login history")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, customerID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's login history")
+	}
+
+	return nil
+}
+
+func NewCustomerLoginEventRepository(logger *logrus.Logger, db *sql.DB) CustomerLoginEventRepository {
+	return &customerLoginEventRepository{
+		logger: logger,
+		db:     db,
+	}
+}
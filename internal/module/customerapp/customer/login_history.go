@@ -0,0 +1,180 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// GetLoginHistory implements CustomerUseCase.
+func (u *customerUseCase) GetLoginHistory(ctx context.Context, req LoginHistoryRequest) ([]LoginEventResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.customerLoginEventRepository.FindByCustomerID(ctx, acc.ID, req.Limit, req.Offset, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLoginEventResponses(events), nil
+}
+
+// GetLoginHistoryForCustomer implements CustomerUseCase. It is requested by the administrator, e.g. the support that investigates a take over.
+func (u *customerUseCase) GetLoginHistoryForCustomer(ctx context.Context, req AdminLoginHistoryRequest) ([]LoginEventResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	c, err := u.customerRepository.FindByID(ctx, req.CustomerID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.customerLoginEventRepository.FindByCustomerID(ctx, c.ID, req.Limit, req.Offset, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if acc, err := session.GetAccountFromCtx(ctx); err == nil {
+		u.logger.WithContext(ctx).WithField("admin_id", acc.ID).WithField("customer_id", c.ID).Info("customer's login history is viewed by administrator")
+	}
+
+	return newLoginEventResponses(events), nil
+}
+
+// completeSignIn issues the refresh token and creates the session of the customer that has passed every check of the method.
+// The customer that has not accepted the latest mandatory legal documents is challenged to accept them first.
+func (u *customerUseCase) completeSignIn(ctx context.Context, c Customer, method string) (SignInResponse, error) {
+	pending, err := u.legalDocumentRepository.FindPendingForCustomer(ctx, c.ID, nil)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	if len(pending) > 0 {
+		return u.challengeConsent(ctx, c, method, pending)
+	}
+
+	rt, err := u.refreshToken.Issue(ctx, fmt.Sprintf("customer:%d", c.ID), util.GenerateRandomHEX(16))
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	resp, err := u.createSession(ctx, c, rt)
+	if err != nil {
+		if errors.MatchStatus(err, status.PASSWORD_RESET_REQUIRED) {
+			return SignInResponse{}, u.loginFailed(ctx, c, method, LoginFailurePasswordResetRequired, err)
+		}
+		if errors.MatchStatus(err, status.ACCOUNT_INACTIVE) {
+			return SignInResponse{}, u.loginFailed(ctx, c, method, LoginFailureAccountInactive, err)
+		}
+		return SignInResponse{}, err
+	}
+
+	u.recordLogin(ctx, c, method, LoginOutcomeSuccess, nil)
+
+	return resp, nil
+}
+
+// loginFailed records the failed sign in attempt and returns the error as it is.
+func (u *customerUseCase) loginFailed(ctx context.Context, c Customer, method, reason string, err error) error {
+	u.recordLogin(ctx, c, method, LoginOutcomeFailure, &reason)
+
+	return err
+}
+
+// recordLogin keeps the sign in attempt along with the client that has made it. The attempt has been made, so the failure is only logged.
+func (u *customerUseCase) recordLogin(ctx context.Context, c Customer, method, outcome string, failureReason *string) {
+	ci := clientinfo.FromContext(ctx)
+	event := CustomerLoginEvent{
+		CustomerID:        c.ID,
+		Method:            method,
+		Outcome:           outcome,
+		FailureReason:     failureReason,
+		IPAddress:         ci.IPAddress,
+		UserAgent:         ci.UserAgent,
+		Device:            ci.Device,
+		DeviceFingerprint: ci.Fingerprint(),
+		Country:           ci.Country,
+		CreatedAt:         time.Now(),
+	}
+
+	// the known clients are looked up before the event is saved, otherwise the client is always known.
+	if outcome == LoginOutcomeSuccess {
+		u.notifyNewDeviceSignIn(ctx, c, event)
+	}
+
+	if _, err := u.customerLoginEventRepository.Save(ctx, event, nil); err != nil {
+		u.logger.WithContext(ctx).WithError(err).WithField("customer_id", c.ID).WithField("method", method).WithField("outcome", outcome).Error("customer's login event is not recorded")
+	}
+}
+
+// notifyNewDeviceSignIn tells the customer about the successful sign in from a device or a country that has not signed in before, so a take over is noticed.
+func (u *customerUseCase) notifyNewDeviceSignIn(ctx context.Context, c Customer, event CustomerLoginEvent) {
+	known, err := u.customerLoginEventRepository.FindKnownClient(ctx, c.ID, event.DeviceFingerprint, event.Country, nil)
+	if err != nil {
+		return
+	}
+
+	// every device is new on the first sign in.
+	if known.SuccessCount == 0 {
+		return
+	}
+
+	newDevice := !known.Device
+	newCountry := event.Country != "" && !known.Country
+	if !newDevice && !newCountry {
+		return
+	}
+
+	newDeviceSignInEvent := NewDeviceSignInEvent{
+		ID:         c.ID,
+		Name:       c.Name,
+		Email:      c.Email,
+		Method:     event.Method,
+		IPAddress:  event.IPAddress,
+		UserAgent:  event.UserAgent,
+		Device:     event.Device,
+		Country:    event.Country,
+		NewDevice:  newDevice,
+		NewCountry: newCountry,
+		SignedInAt: event.CreatedAt,
+	}
+
+	newDeviceSignInEventBuff, _ := json.Marshal(newDeviceSignInEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-new-device-sign-in", fmt.Sprintf("customer:%d", c.ID), messageHeader, newDeviceSignInEventBuff)
+}
+
+func newLoginEventResponses(events []CustomerLoginEvent) []LoginEventResponse {
+	resp := make([]LoginEventResponse, len(events))
+	for k, event := range events {
+		resp[k] = LoginEventResponse{
+			ID:            event.ID,
+			Method:        event.Method,
+			Outcome:       event.Outcome,
+			FailureReason: event.FailureReason,
+			IPAddress:     event.IPAddress,
+			UserAgent:     event.UserAgent,
+			Device:        event.Device,
+			Country:       event.Country,
+			CreatedAt:     event.CreatedAt,
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,164 @@
+package customer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// MagicLink is kept until the link is opened. Only the hash of the nonce is kept, the nonce itself stays on the requesting device.
+type MagicLink struct {
+	CustomerID int64  `json:"customer_id"`
+	NonceHash  string `json:"nonce_hash"`
+}
+
+// RequestMagicLink implements CustomerUseCase. It does not tell whether the email belongs to a customer, the nonce is returned either way.
+func (u *customerUseCase) RequestMagicLink(ctx context.Context, req MagicLinkRequest) (MagicLinkResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	req.Email = u.canonicalEmail(req.Email)
+
+	ok, err := u.cache.SetNX(ctx, fmt.Sprintf(magicLinkCooldownPrefix, req.Email), 1, magicLinkCooldown).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return MagicLinkResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's magic link")
+	}
+	if !ok {
+		return MagicLinkResponse{}, errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "magic link has been requested recently, please try again later")
+	}
+
+	now := time.Now()
+	resp := MagicLinkResponse{
+		Nonce:     util.GenerateRandomHEX(32),
+		ExpiresAt: now.Add(magicLinkExpiresIn),
+	}
+
+	c, err := u.findCustomerByEmail(ctx, req.Email, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return resp, nil
+		}
+		return MagicLinkResponse{}, err
+	}
+
+	if c.MemberStatus != MemberStatusActive {
+		return resp, nil
+	}
+
+	magicLinkToken := util.GenerateRandomHEX(32)
+	magicLink := MagicLink{
+		CustomerID: c.ID,
+		NonceHash:  hashMagicLinkNonce(resp.Nonce),
+	}
+
+	magicLinkBuff, _ := json.Marshal(magicLink)
+
+	if err := u.cache.Set(ctx, fmt.Sprintf(magicLinkKeyPrefix, magicLinkToken), magicLinkBuff, magicLinkExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return MagicLinkResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's magic link")
+	}
+
+	ci := clientinfo.FromContext(ctx)
+	magicLinkEvent := MagicLinkEvent{
+		ID:        c.ID,
+		Name:      c.Name,
+		Email:     c.Email,
+		MagicLink: fmt.Sprintf("%s%s?token=%s", u.tmuserBaseURL, MagicLinkURLPath, magicLinkToken),
+		IPAddress: ci.IPAddress,
+		Device:    ci.Device,
+		ExpiresAt: resp.ExpiresAt,
+	}
+
+	magicLinkEventBuff, _ := json.Marshal(magicLinkEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-magic-link", fmt.Sprintf("customer:%d", c.ID), messageHeader, magicLinkEventBuff)
+
+	return resp, nil
+}
+
+// VerifyMagicLink implements CustomerUseCase. The link is accepted only on the device that holds the nonce, so a forwarded email can not be used to sign in.
+func (u *customerUseCase) VerifyMagicLink(ctx context.Context, req MagicLinkVerificationRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	key := fmt.Sprintf(magicLinkKeyPrefix, req.Token)
+
+	// the link is checked before it is consumed, so opening it on another device does not burn it.
+	magicLinkBuff, err := u.cache.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired magic link")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	var magicLink MagicLink
+	json.Unmarshal(magicLinkBuff, &magicLink)
+
+	if req.Nonce == "" || subtle.ConstantTimeCompare([]byte(hashMagicLinkNonce(req.Nonce)), []byte(magicLink.NonceHash)) != 1 {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "magic link must be opened on the device that requested it")
+	}
+
+	if err := u.cache.GetDel(ctx, key).Err(); err != nil {
+		if err == redis.Nil {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired magic link")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, magicLink.CustomerID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired magic link")
+		}
+		return SignInResponse{}, err
+	}
+
+	if c.DeletedAt != nil {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired magic link")
+	}
+
+	if c.MemberStatus != MemberStatusActive {
+		return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodMagicLink, LoginFailureAccountInactive, errors.New(http.StatusForbidden, status.ACCOUNT_INACTIVE, "customer's account is deactivated, request a reactivation link to activate it again"))
+	}
+
+	// opening the link proves the ownership of the email.
+	if c.VerificationStatus == VerificationStatusUnverified {
+		c.VerificationStatus = VerficationStatusVerified
+		c.UpdatedAt = time.Now()
+
+		if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+			return SignInResponse{}, err
+		}
+	}
+
+	if c.TwoFactorEnabled {
+		return u.challengeTwoFactor(ctx, c, LoginMethodMagicLink)
+	}
+
+	return u.completeSignIn(ctx, c, LoginMethodMagicLink)
+}
+
+func hashMagicLinkNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/middleware"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type memberStatusChecker struct {
+	logger             *logrus.Logger
+	cache              redis.UniversalClient
+	customerRepository CustomerRepository
+}
+
+// NewMemberStatusChecker returns the checker of the customer's member status for the session middleware. The status is cached shortly to spare the database.
+func NewMemberStatusChecker(logger *logrus.Logger, cache redis.UniversalClient, customerRepository CustomerRepository) middleware.MemberStatusChecker {
+	return &memberStatusChecker{
+		logger:             logger,
+		cache:              cache,
+		customerRepository: customerRepository,
+	}
+}
+
+// IsActive implements middleware.MemberStatusChecker. The deleted customer is not active, whatever its member status is.
+func (m *memberStatusChecker) IsActive(ctx context.Context, ID int64) (bool, error) {
+	key := fmt.Sprintf(memberStatusKeyPrefix, ID)
+
+	memberStatus, err := m.cache.Get(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		m.logger.WithContext(ctx).WithError(err).Error()
+		return false, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while checking customer's member status")
+	}
+
+	if err == redis.Nil {
+		c, err := m.customerRepository.FindByID(ctx, ID, nil)
+		if err != nil {
+			if errors.MatchStatus(err, status.NOT_FOUND) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		memberStatus = c.MemberStatus
+		if c.DeletedAt != nil {
+			memberStatus = MemberStatusDeleted
+		}
+		if err := m.cache.Set(ctx, key, memberStatus, memberStatusCacheExpiresIn).Err(); err != nil {
+			m.logger.WithContext(ctx).WithError(err).Error()
+		}
+	}
+
+	return memberStatus == MemberStatusActive, nil
+}
+
+// invalidateMemberStatus removes the cached member status, so the session middleware reads the latest one.
+func (u *customerUseCase) invalidateMemberStatus(ctx context.Context, ID int64) {
+	if err := u.cache.Del(ctx, fmt.Sprintf(memberStatusKeyPrefix, ID)).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+}
@@ -0,0 +1,45 @@
+package customer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/module/customerapp/customer"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+func TestRefreshTokenChecksMemberStatus(t *testing.T) {
+	deletedAt := time.Now()
+
+	cases := []struct {
+		name     string
+		c        customer.Customer
+		expected string
+	}{
+		{name: "inactive", c: customer.Customer{ID: 1, MemberStatus: customer.MemberStatusInactive}, expected: status.ACCOUNT_INACTIVE},
+		{name: "deleted", c: customer.Customer{ID: 1, MemberStatus: customer.MemberStatusActive, DeletedAt: &deletedAt}, expected: status.UNAUTHORIZED},
+	}
+
+	for _, c := range cases {
+		uc, props := newTestUseCase(t, func(props *customer.CustomerUseCaseProperty) {
+			props.RefreshToken = session.NewRedisRefreshTokenStore(props.Logger, props.Cache, time.Hour)
+			props.CustomerRepository = &customerRepository{c: c.c}
+		})
+
+		ctx := context.Background()
+		rt, err := props.RefreshToken.Issue(ctx, "customer:1", "family-"+c.name)
+		assert.NoError(t, err)
+
+		_, err = uc.RefreshToken(ctx, customer.RefreshTokenRequest{RefreshToken: rt.Token})
+		assert.True(t, errors.MatchStatus(err, c.expected), c.name)
+
+		checker := customer.NewMemberStatusChecker(props.Logger, props.Cache, props.CustomerRepository)
+		active, err := checker.IsActive(ctx, c.c.ID)
+		assert.NoError(t, err)
+		assert.False(t, active, c.name)
+	}
+}
@@ -0,0 +1,177 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/passkey"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
+)
+
+// BeginPasskeyRegistration implements CustomerUseCase.
+func (u *customerUseCase) BeginPasskeyRegistration(ctx context.Context) (webauthn.CreationOptions, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	user := webauthn.User{
+		Name:        c.Email,
+		DisplayName: c.Name,
+	}
+
+	return u.passkey.BeginRegistration(ctx, fmt.Sprintf("customer:%d", c.ID), user)
+}
+
+// FinishPasskeyRegistration implements CustomerUseCase.
+func (u *customerUseCase) FinishPasskeyRegistration(ctx context.Context, req FinishPasskeyRegistrationRequest) (PasskeyResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return PasskeyResponse{}, err
+	}
+
+	cred, err := u.passkey.FinishRegistration(ctx, fmt.Sprintf("customer:%d", acc.ID), req.Name, req.Credential)
+	if err != nil {
+		return PasskeyResponse{}, err
+	}
+
+	u.recordSensitiveChange(ctx, acc.ID, SensitiveChangePasskeyAdded, nil, &cred.Name)
+
+	return newPasskeyResponse(cred), nil
+}
+
+// GetPasskeys implements CustomerUseCase.
+func (u *customerUseCase) GetPasskeys(ctx context.Context) ([]PasskeyResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := u.passkey.List(ctx, fmt.Sprintf("customer:%d", acc.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return newPasskeyResponses(creds), nil
+}
+
+// RenamePasskey implements CustomerUseCase.
+func (u *customerUseCase) RenamePasskey(ctx context.Context, req RenamePasskeyRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	return u.passkey.Rename(ctx, fmt.Sprintf("customer:%d", acc.ID), req.ID, req.Name)
+}
+
+// DeletePasskey implements CustomerUseCase.
+func (u *customerUseCase) DeletePasskey(ctx context.Context, req DeletePasskeyRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := u.passkey.Delete(ctx, fmt.Sprintf("customer:%d", acc.ID), req.ID); err != nil {
+		return err
+	}
+
+	u.recordSensitiveChange(ctx, acc.ID, SensitiveChangePasskeyRemoved, nil, nil)
+
+	return nil
+}
+
+// BeginPasskeySignIn implements CustomerUseCase.
+func (u *customerUseCase) BeginPasskeySignIn(ctx context.Context) (PasskeySignInChallengeResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	sc, err := u.passkey.BeginSignIn(ctx, "customer")
+	if err != nil {
+		return PasskeySignInChallengeResponse{}, err
+	}
+
+	resp := PasskeySignInChallengeResponse{
+		ChallengeToken: sc.Token,
+		Options:        sc.Options,
+		ExpiresAt:      sc.ExpiresAt,
+	}
+
+	return resp, nil
+}
+
+// SignInPasskey implements CustomerUseCase. The passkey verifies the customer by itself, so the two factor challenge is not required.
+func (u *customerUseCase) SignInPasskey(ctx context.Context, req PasskeySignInRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	cred, err := u.passkey.FinishSignIn(ctx, "customer", req.ChallengeToken, req.Credential)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	var ID int64
+	if _, err := fmt.Sscanf(cred.Subject, "customer:%d", &ID); err != nil {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, ID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+		}
+		return SignInResponse{}, err
+	}
+
+	if c.DeletedAt != nil {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	if c.MemberStatus != MemberStatusActive {
+		return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodPasskey, LoginFailureAccountInactive, errors.New(http.StatusForbidden, status.ACCOUNT_INACTIVE, "customer's account is deactivated, request a reactivation link to activate it again"))
+	}
+
+	return u.completeSignIn(ctx, c, LoginMethodPasskey)
+}
+
+func newPasskeyResponse(cred passkey.Credential) PasskeyResponse {
+	return PasskeyResponse{
+		ID:         cred.ID,
+		Name:       cred.Name,
+		CreatedAt:  cred.CreatedAt,
+		LastUsedAt: cred.LastUsedAt,
+	}
+}
+
+func newPasskeyResponses(creds []passkey.Credential) []PasskeyResponse {
+	resp := make([]PasskeyResponse, len(creds))
+	for k, cred := range creds {
+		resp[k] = newPasskeyResponse(cred)
+	}
+
+	return resp
+}
@@ -0,0 +1,31 @@
+package customer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/module/customerapp/customer"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+func TestForgotPasswordCooldown(t *testing.T) {
+	uc, _ := newTestUseCase(t, func(props *customer.CustomerUseCaseProperty) {
+		props.EmailOptions.GmailRules = true
+		props.CustomerRepository = &legacyCustomerRepository{c: customer.Customer{ID: 1, Email: "john.smith@gmail.com"}}
+	})
+
+	ctx := context.Background()
+
+	assert.NoError(t, uc.ForgotPassword(ctx, customer.ForgotPasswordRequest{Email: "john.smith@gmail.com"}))
+
+	// the cooldown is shared by the addresses of the same mailbox.
+	err := uc.ForgotPassword(ctx, customer.ForgotPasswordRequest{Email: "JohnSmith+x@gmail.com"})
+	assert.True(t, errors.MatchStatus(err, status.TOO_MANY_REQUESTS))
+
+	// the unknown email is limited the same, so the response does not tell whether it is registered.
+	assert.NoError(t, uc.ForgotPassword(ctx, customer.ForgotPasswordRequest{Email: "jane@example.com"}))
+	err = uc.ForgotPassword(ctx, customer.ForgotPasswordRequest{Email: "jane@example.com"})
+	assert.True(t, errors.MatchStatus(err, status.TOO_MANY_REQUESTS))
+}
@@ -0,0 +1,315 @@
+package customer
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// ChangePhone implements CustomerUseCase. The phone is not stored until the customer verifies it.
+func (u *customerUseCase) ChangePhone(ctx context.Context, req ChangePhoneRequest) (PhoneOTPResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return PhoneOTPResponse{}, err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return PhoneOTPResponse{}, err
+	}
+
+	if c.Phone != nil && *c.Phone == req.Phone {
+		return PhoneOTPResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "the new phone is the same as existing phone")
+	}
+
+	other, err := u.customerRepository.FindByPhone(ctx, req.Phone, nil)
+	if err == nil && other.ID != c.ID {
+		return PhoneOTPResponse{}, errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("phone '%s' is already registered", req.Phone))
+	}
+	if err != nil && !errors.MatchStatus(err, status.NOT_FOUND) {
+		return PhoneOTPResponse{}, err
+	}
+
+	otp := PhoneOTP{
+		CustomerID: c.ID,
+		Phone:      req.Phone,
+	}
+
+	expiresAt, err := u.sendPhoneOTP(ctx, otp, fmt.Sprintf(phoneVerificationKeyPrefix, c.ID), fmt.Sprintf(phoneVerificationAttemptsPrefix, c.ID), "Your Ticket Master verification code is %s. It expires in %d minutes, do not share it with anyone.")
+	if err != nil {
+		return PhoneOTPResponse{}, err
+	}
+
+	resp := PhoneOTPResponse{
+		ExpiresAt: expiresAt,
+	}
+
+	return resp, nil
+}
+
+// VerifyPhone implements CustomerUseCase.
+func (u *customerUseCase) VerifyPhone(ctx context.Context, req VerifyPhoneRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	otp, err := u.checkPhoneOTP(ctx, fmt.Sprintf(phoneVerificationKeyPrefix, acc.ID), fmt.Sprintf(phoneVerificationAttemptsPrefix, acc.ID), req.Code)
+	if err != nil {
+		return err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	// the phone may have been verified by another customer since the code was sent.
+	other, err := u.customerRepository.FindByPhone(ctx, otp.Phone, nil)
+	if err == nil && other.ID != c.ID {
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("phone '%s' is already registered", otp.Phone))
+	}
+	if err != nil && !errors.MatchStatus(err, status.NOT_FOUND) {
+		return err
+	}
+
+	now := time.Now()
+	existingPhone := c.Phone
+	c.Phone = &otp.Phone
+	c.PhoneVerifiedAt = &now
+	c.UpdatedAt = now
+
+	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+		return err
+	}
+
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangePhone, existingPhone, c.Phone)
+
+	phoneChangedEvent := PhoneChangedEvent{
+		ID:        c.ID,
+		Name:      c.Name,
+		Email:     c.Email,
+		Phone:     otp.Phone,
+		ChangedAt: now,
+	}
+
+	phoneChangedEventBuff, _ := json.Marshal(phoneChangedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-phone-changed", fmt.Sprintf("customer:%d", c.ID), messageHeader, phoneChangedEventBuff)
+
+	return nil
+}
+
+// RequestPhoneSignIn implements CustomerUseCase. It does not tell whether the phone belongs to a customer.
+func (u *customerUseCase) RequestPhoneSignIn(ctx context.Context, req PhoneSignInRequest) (PhoneOTPResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	// the phone is limited before it is looked up, so the unknown phone is limited the same as the registered one.
+	if err := u.limitPhoneOTP(ctx, req.Phone); err != nil {
+		return PhoneOTPResponse{}, err
+	}
+
+	resp := PhoneOTPResponse{
+		ExpiresAt: time.Now().Add(phoneOTPExpiresIn),
+	}
+
+	c, err := u.customerRepository.FindByPhone(ctx, req.Phone, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return resp, nil
+		}
+		return PhoneOTPResponse{}, err
+	}
+
+	otp := PhoneOTP{
+		CustomerID: c.ID,
+		Phone:      req.Phone,
+	}
+
+	expiresAt, err := u.deliverPhoneOTP(ctx, otp, fmt.Sprintf(phoneSignInKeyPrefix, req.Phone), fmt.Sprintf(phoneSignInAttemptsPrefix, req.Phone), "Your Ticket Master sign in code is %s. It expires in %d minutes, do not share it with anyone.")
+	if err != nil {
+		return PhoneOTPResponse{}, err
+	}
+
+	resp.ExpiresAt = expiresAt
+
+	return resp, nil
+}
+
+// SignInPhone implements CustomerUseCase.
+func (u *customerUseCase) SignInPhone(ctx context.Context, req VerifyPhoneSignInRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	otp, err := u.checkPhoneOTP(ctx, fmt.Sprintf(phoneSignInKeyPrefix, req.Phone), fmt.Sprintf(phoneSignInAttemptsPrefix, req.Phone), req.Code)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, otp.CustomerID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired one-time password")
+		}
+		return SignInResponse{}, err
+	}
+
+	// the phone may have been changed since the code was sent.
+	if c.DeletedAt != nil || c.Phone == nil || *c.Phone != otp.Phone {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired one-time password")
+	}
+
+	if c.MemberStatus != MemberStatusActive {
+		return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodPhone, LoginFailureAccountInactive, errors.New(http.StatusForbidden, status.ACCOUNT_INACTIVE, "customer's account is deactivated, request a reactivation link to activate it again"))
+	}
+
+	if c.TwoFactorEnabled {
+		return u.challengeTwoFactor(ctx, c, LoginMethodPhone)
+	}
+
+	return u.completeSignIn(ctx, c, LoginMethodPhone)
+}
+
+// sendPhoneOTP replaces the previous code of the key with a new one and sends it to the phone. The phone can only receive a code every cooldown and a limited number of codes every day.
+func (u *customerUseCase) sendPhoneOTP(ctx context.Context, otp PhoneOTP, otpKey, attemptsKey, body string) (time.Time, error) {
+	if err := u.limitPhoneOTP(ctx, otp.Phone); err != nil {
+		return time.Time{}, err
+	}
+
+	return u.deliverPhoneOTP(ctx, otp, otpKey, attemptsKey, body)
+}
+
+// limitPhoneOTP counts a code that is requested for the phone, it fails when the phone is in the cooldown or has reached the daily cap.
+func (u *customerUseCase) limitPhoneOTP(ctx context.Context, phone string) error {
+	ok, err := u.cache.SetNX(ctx, fmt.Sprintf(phoneOTPCooldownPrefix, phone), 1, phoneOTPCooldown).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while sending one-time password")
+	}
+	if !ok {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "one-time password has been requested recently, please try again later")
+	}
+
+	dailyKey := fmt.Sprintf(phoneOTPDailyPrefix, phone, time.Now().Format("2006-01-02"))
+	count, err := u.cache.Incr(ctx, dailyKey).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while sending one-time password")
+	}
+	if count == 1 {
+		u.cache.Expire(ctx, dailyKey, time.Hour*24)
+	}
+	if count > phoneOTPDailyCap {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "daily limit of one-time password has been reached, please try again tomorrow")
+	}
+
+	return nil
+}
+
+// deliverPhoneOTP replaces the previous code of the key with a new one and sends it to the phone, the phone must have been limited by limitPhoneOTP.
+func (u *customerUseCase) deliverPhoneOTP(ctx context.Context, otp PhoneOTP, otpKey, attemptsKey, body string) (time.Time, error) {
+	code := generateOTP()
+	otp.CodeHash = u.hashOTP(code)
+	otpBuff, _ := json.Marshal(otp)
+
+	_, err := u.cache.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, otpKey, otpBuff, phoneOTPExpiresIn)
+		p.Del(ctx, attemptsKey)
+		return nil
+	})
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return time.Time{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while sending one-time password")
+	}
+
+	if err := u.smsSender.Send(ctx, otp.Phone, fmt.Sprintf(body, code, int(phoneOTPExpiresIn.Minutes()))); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return time.Time{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while sending one-time password")
+	}
+
+	return time.Now().Add(phoneOTPExpiresIn), nil
+}
+
+// checkPhoneOTP consumes the code of the key when it matches. The code is invalidated after too many wrong attempts.
+func (u *customerUseCase) checkPhoneOTP(ctx context.Context, otpKey, attemptsKey, code string) (PhoneOTP, error) {
+	otpBuff, err := u.cache.Get(ctx, otpKey).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return PhoneOTP{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired one-time password")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return PhoneOTP{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while verifying one-time password")
+	}
+
+	attempts, err := u.cache.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return PhoneOTP{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while verifying one-time password")
+	}
+	u.cache.Expire(ctx, attemptsKey, phoneOTPExpiresIn)
+
+	if attempts > phoneOTPMaxAttempts {
+		u.cache.Del(ctx, otpKey)
+		return PhoneOTP{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "too many invalid one-time passwords, please request a new one")
+	}
+
+	var otp PhoneOTP
+	json.Unmarshal(otpBuff, &otp)
+
+	if subtle.ConstantTimeCompare([]byte(u.hashOTP(code)), []byte(otp.CodeHash)) != 1 {
+		return PhoneOTP{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid one-time password")
+	}
+
+	// the code is consumed only once, even by the concurrent requests.
+	if err := u.cache.GetDel(ctx, otpKey).Err(); err != nil {
+		if err == redis.Nil {
+			return PhoneOTP{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired one-time password")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return PhoneOTP{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while verifying one-time password")
+	}
+	u.cache.Del(ctx, attemptsKey)
+
+	return otp, nil
+}
+
+func (u *customerUseCase) hashOTP(code string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s%s", u.cryptoSecret, code)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// generateOTP returns a random numeric code that is padded with zeros.
+func generateOTP() string {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(phoneOTPLength), nil)
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		panic(err)
+	}
+
+	return fmt.Sprintf("%0*d", phoneOTPLength, n)
+}
@@ -0,0 +1,37 @@
+package customer_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/module/customerapp/customer"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type phoneCustomerRepository struct {
+	customer.CustomerRepository
+}
+
+func (r *phoneCustomerRepository) FindByPhone(ctx context.Context, phone string, tx *sql.Tx) (customer.Customer, error) {
+	return customer.Customer{}, errors.New(http.StatusNotFound, status.NOT_FOUND, "customer is not found")
+}
+
+func TestRequestPhoneSignInLimitsUnknownPhone(t *testing.T) {
+	uc, _ := newTestUseCase(t, func(props *customer.CustomerUseCaseProperty) {
+		props.CustomerRepository = &phoneCustomerRepository{}
+	})
+
+	ctx := context.Background()
+	req := customer.PhoneSignInRequest{Phone: "+6281234567890"}
+
+	_, err := uc.RequestPhoneSignIn(ctx, req)
+	assert.NoError(t, err)
+
+	// the registered phone is in the cooldown after a request, the unknown phone must not respond differently.
+	_, err = uc.RequestPhoneSignIn(ctx, req)
+	assert.True(t, errors.MatchStatus(err, status.TOO_MANY_REQUESTS))
+}
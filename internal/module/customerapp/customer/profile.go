@@ -0,0 +1,119 @@
+package customer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tsel-ticketmaster/tm-user/pkg/storage"
+	publicValidator "github.com/tsel-ticketmaster/tm-user/pkg/validator"
+)
+
+func newProfileResponse(c Customer, objectStorage storage.ObjectStorage) GetProfileResponse {
+	resp := GetProfileResponse{
+		ID:                 c.ID,
+		Name:               c.Name,
+		Email:              c.Email,
+		Phone:              c.Phone,
+		Gender:             c.Gender,
+		City:               c.City,
+		Province:           c.Province,
+		PreferredLanguage:  c.PreferredLanguage,
+		Timezone:           c.Timezone,
+		VerificationStatus: c.VerificationStatus,
+		MemberStatus:       c.MemberStatus,
+		CreatedAt:          c.CreatedAt,
+		UpdatedAt:          c.UpdatedAt,
+	}
+
+	resp.BirthDate = formatBirthDate(c.BirthDate)
+
+	if c.AvatarKey != nil {
+		avatarURL := objectStorage.URL(avatarObjectKey(*c.AvatarKey, avatarSizes[len(avatarSizes)-1]))
+		resp.AvatarURL = &avatarURL
+		resp.AvatarThumbnails = make(map[string]string, len(avatarSizes))
+		for _, size := range avatarSizes {
+			resp.AvatarThumbnails[strconv.Itoa(size)] = objectStorage.URL(avatarObjectKey(*c.AvatarKey, size))
+		}
+	}
+
+	return resp
+}
+
+func formatBirthDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	birthDate := t.Format(publicValidator.BirthDateLayout)
+
+	return &birthDate
+}
+
+// avatarObjectKey returns the key of the avatar's thumbnail of the size. The avatar's key is the prefix that is shared by all of its thumbnails.
+func avatarObjectKey(avatarKey string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", avatarKey, size)
+}
+
+// applyProfileUpdate changes the customer's fields that are given by the request and returns the changes keyed by the field's name.
+func applyProfileUpdate(c *Customer, req UpdateProfileRequest) map[string]ProfileChange {
+	changes := make(map[string]ProfileChange)
+
+	if req.Name != nil && *req.Name != c.Name {
+		old := c.Name
+		c.Name = *req.Name
+		changes["name"] = ProfileChange{Old: &old, New: req.Name}
+	}
+
+	if req.BirthDate != nil {
+		old := formatBirthDate(c.BirthDate)
+		updated := nullableString(req.BirthDate)
+		if !equalString(old, updated) {
+			c.BirthDate = nil
+			if updated != nil {
+				birthDate, _ := time.Parse(publicValidator.BirthDateLayout, *updated)
+				c.BirthDate = &birthDate
+			}
+			changes["birth_date"] = ProfileChange{Old: old, New: updated}
+		}
+	}
+
+	applyOptionalField(changes, "gender", &c.Gender, req.Gender)
+	applyOptionalField(changes, "city", &c.City, req.City)
+	applyOptionalField(changes, "province", &c.Province, req.Province)
+	applyOptionalField(changes, "preferred_language", &c.PreferredLanguage, req.PreferredLanguage)
+	applyOptionalField(changes, "timezone", &c.Timezone, req.Timezone)
+
+	return changes
+}
+
+func applyOptionalField(changes map[string]ProfileChange, name string, field **string, value *string) {
+	if value == nil {
+		return
+	}
+
+	updated := nullableString(value)
+	if equalString(*field, updated) {
+		return
+	}
+
+	changes[name] = ProfileChange{Old: *field, New: updated}
+	*field = updated
+}
+
+// nullableString treats the empty string as null, so the optional field is cleared.
+func nullableString(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+
+	return s
+}
+
+func equalString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
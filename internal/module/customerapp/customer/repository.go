@@ -3,19 +3,30 @@ package customer
 import (
 	"context"
 	"database/sql"
+	stdErrors "errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/sirupsen/logrus"
 	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
 	"github.com/tsel-ticketmaster/tm-user/pkg/status"
 )
 
 type CustomerRepository interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
 	Save(ctx context.Context, c Customer, tx *sql.Tx) (int64, error)
 	FindByID(ctx context.Context, ID int64, tx *sql.Tx) (Customer, error)
 	FindByEmail(ctx context.Context, email string, tx *sql.Tx) (Customer, error)
+	FindByGmailAddress(ctx context.Context, email string, tx *sql.Tx) (Customer, error)
+	FindByPhone(ctx context.Context, phone string, tx *sql.Tx) (Customer, error)
 	Update(ctx context.Context, ID int64, update Customer, tx *sql.Tx) error
+	EnableTwoFactor(ctx context.Context, ID int64, secret string, recoveryCodes RecoveryCodes, tx *sql.Tx) (bool, error)
+	DisableTwoFactor(ctx context.Context, ID int64, tx *sql.Tx) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, ID int64, hashedCode string, tx *sql.Tx) (bool, error)
+	FindErasable(ctx context.Context, deletedBefore time.Time, limit int, tx *sql.Tx) ([]Customer, error)
+	Erase(ctx context.Context, ID int64, tx *sql.Tx) error
 }
 
 type sqlCommand interface {
@@ -25,12 +36,56 @@ type sqlCommand interface {
 	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
 }
 
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// customerConflict maps the violation of the customer's unique indexes, the check before writing may be raced by another request.
+func customerConflict(err error, c Customer) error {
+	var pgErr *pgconn.PgError
+	if !stdErrors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return nil
+	}
+
+	switch pgErr.ConstraintName {
+	case "customer_phone_active_idx":
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, "customer with the phone is already registered")
+	default:
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("customer with email '%s' is already registered", c.Email))
+	}
+}
+
+// customerColumns is the list of customer's columns in the same order as scanCustomer reads them.
+const customerColumns = `
+	id, name, email, password, password_salt, verification_status, member_status,
+	two_factor_enabled, two_factor_secret, two_factor_recovery_codes,
+	phone, phone_verified_at,
+	birth_date, gender, city, province, preferred_language, timezone,
+	avatar_key, password_reset_required,
+	created_at, updated_at, deleted_at
+`
+
+func scanCustomer(row rowScanner) (Customer, error) {
+	var data Customer
+
+	err := row.Scan(
+		&data.ID, &data.Name, &data.Email, &data.Password, &data.PasswordSalt, &data.VerificationStatus, &data.MemberStatus,
+		&data.TwoFactorEnabled, &data.TwoFactorSecret, &data.TwoFactorRecoveryCodes,
+		&data.Phone, &data.PhoneVerifiedAt,
+		&data.BirthDate, &data.Gender, &data.City, &data.Province, &data.PreferredLanguage, &data.Timezone,
+		&data.AvatarKey, &data.PasswordResetRequired,
+		&data.CreatedAt, &data.UpdatedAt, &data.DeletedAt,
+	)
+
+	return data, err
+}
+
 type customerRepository struct {
 	logger *logrus.Logger
 	db     *sql.DB
 }
 
-// FindByEmail implements CustomerRepository.
+// FindByEmail implements CustomerRepository. The email is matched case-insensitively, the oldest customer is returned when the email has duplicates.
 func (r *customerRepository) FindByEmail(ctx context.Context, email string, tx *sql.Tx) (Customer, error) {
 	var cmd sqlCommand = r.db
 
@@ -39,11 +94,12 @@ func (r *customerRepository) FindByEmail(ctx context.Context, email string, tx *
 	}
 
 	query := `
-		SELECT 
-			id, name, email, password, password_salt, verification_status, member_status, created_at, updated_at
+		SELECT ` + customerColumns + `
 		FROM customer
 		WHERE
-			email = $1
+			lower(email) = lower($1)
+			AND deleted_at IS NULL
+		ORDER BY id
 		LIMIT 1
 	`
 
@@ -56,11 +112,47 @@ func (r *customerRepository) FindByEmail(ctx context.Context, email string, tx *
 
 	row := stmt.QueryRowContext(ctx, email)
 
-	var data Customer
+	data, err := scanCustomer(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Customer{}, errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("customer's properties with email '%s' is not found", email))
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return Customer{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's prorperties")
+	}
 
-	err = row.Scan(
-		&data.ID, &data.Name, &data.Email, &data.Password, &data.PasswordSalt, &data.VerificationStatus, &data.MemberStatus, &data.CreatedAt, &data.UpdatedAt,
-	)
+	return data, nil
+}
+
+// FindByGmailAddress implements CustomerRepository. The email is matched by its gmail address, i.e. ignoring the dots and the plus suffix of the local part,
+// so the customers that are stored before the gmail rules are found as well. The oldest customer is returned when the address has duplicates.
+func (r *customerRepository) FindByGmailAddress(ctx context.Context, email string, tx *sql.Tx) (Customer, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + customerColumns + `
+		FROM customer
+		WHERE
+			customer_gmail_address(email) = customer_gmail_address($1)
+			AND deleted_at IS NULL
+		ORDER BY id
+		LIMIT 1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return Customer{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's prorperties")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, email)
+
+	data, err := scanCustomer(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return Customer{}, errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("customer's properties with email '%s' is not found", email))
@@ -72,6 +164,55 @@ func (r *customerRepository) FindByEmail(ctx context.Context, email string, tx *
 	return data, nil
 }
 
+// BeginTx implements CustomerRepository.
+func (r *customerRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while beginning customer's transaction")
+	}
+
+	return tx, nil
+}
+
+// FindByPhone implements CustomerRepository.
+func (r *customerRepository) FindByPhone(ctx context.Context, phone string, tx *sql.Tx) (Customer, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + customerColumns + `
+		FROM customer
+		WHERE
+			phone = $1
+			AND deleted_at IS NULL
+		LIMIT 1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return Customer{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's prorperties")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, phone)
+
+	data, err := scanCustomer(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Customer{}, errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("customer's properties with phone '%s' is not found", phone))
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return Customer{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's prorperties")
+	}
+
+	return data, nil
+}
+
 // FindByID implements CustomerRepository.
 func (r *customerRepository) FindByID(ctx context.Context, ID int64, tx *sql.Tx) (Customer, error) {
 	var cmd sqlCommand = r.db
@@ -81,8 +222,7 @@ func (r *customerRepository) FindByID(ctx context.Context, ID int64, tx *sql.Tx)
 	}
 
 	query := `
-		SELECT 
-			id, name, email, password, password_salt, verification_status, member_status, created_at, updated_at
+		SELECT ` + customerColumns + `
 		FROM customer
 		WHERE
 			id = $1
@@ -98,11 +238,7 @@ func (r *customerRepository) FindByID(ctx context.Context, ID int64, tx *sql.Tx)
 
 	row := stmt.QueryRowContext(ctx, ID)
 
-	var data Customer
-
-	err = row.Scan(
-		&data.ID, &data.Name, &data.Email, &data.Password, &data.PasswordSalt, &data.VerificationStatus, &data.MemberStatus, &data.CreatedAt, &data.UpdatedAt,
-	)
+	data, err := scanCustomer(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return Customer{}, errors.New(http.StatusNotFound, status.NOT_FOUND, fmt.Sprintf("customer's properties with id '%d' is not found", ID))
@@ -125,11 +261,16 @@ func (r *customerRepository) Save(ctx context.Context, c Customer, tx *sql.Tx) (
 	query := `
 		INSERT INTO customer
 		(
-			name, email, password, password_salt, verification_status, member_status, created_at, updated_at
+			name, email, password, password_salt, verification_status, member_status,
+			two_factor_enabled, two_factor_secret, two_factor_recovery_codes,
+			phone, phone_verified_at,
+			birth_date, gender, city, province, preferred_language, timezone,
+			avatar_key, password_reset_required,
+			created_at, updated_at
 		)
 		VALUES
 		(
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
 		)
 		RETURNING id
 	`
@@ -139,13 +280,24 @@ func (r *customerRepository) Save(ctx context.Context, c Customer, tx *sql.Tx) (
 		r.logger.WithContext(ctx).WithError(err).Error()
 		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's prorperties")
 	}
+	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx, c.Name, c.Email, c.Password, c.PasswordSalt, c.VerificationStatus, c.MemberStatus, c.CreatedAt, c.UpdatedAt)
+	row := stmt.QueryRowContext(ctx,
+		c.Name, c.Email, c.Password, c.PasswordSalt, c.VerificationStatus, c.MemberStatus,
+		c.TwoFactorEnabled, c.TwoFactorSecret, c.TwoFactorRecoveryCodes,
+		c.Phone, c.PhoneVerifiedAt,
+		c.BirthDate, c.Gender, c.City, c.Province, c.PreferredLanguage, c.Timezone,
+		c.AvatarKey, c.PasswordResetRequired,
+		c.CreatedAt, c.UpdatedAt,
+	)
 
 	var ID int64
 
 	err = row.Scan(&ID)
 	if err != nil {
+		if conflict := customerConflict(err, c); conflict != nil {
+			return 0, conflict
+		}
 		r.logger.WithContext(ctx).WithError(err).Error()
 		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's prorperties")
 	}
@@ -153,7 +305,8 @@ func (r *customerRepository) Save(ctx context.Context, c Customer, tx *sql.Tx) (
 	return ID, nil
 }
 
-// Update implements CustomerRepository.
+// Update implements CustomerRepository. The two factor authentication is not updated, since the caller's copy of it may be stale,
+// e.g. a recovery code that is consumed in the meantime. It is changed by EnableTwoFactor, DisableTwoFactor and ConsumeRecoveryCode only.
 func (r *customerRepository) Update(ctx context.Context, ID int64, c Customer, tx *sql.Tx) error {
 	var cmd sqlCommand = r.db
 
@@ -170,9 +323,20 @@ func (r *customerRepository) Update(ctx context.Context, ID int64, c Customer, t
 			password_salt = $4,
 			verification_status = $5,
 			member_status = $6,
-			updated_at = $7
+			phone = $7,
+			phone_verified_at = $8,
+			birth_date = $9,
+			gender = $10,
+			city = $11,
+			province = $12,
+			preferred_language = $13,
+			timezone = $14,
+			avatar_key = $15,
+			password_reset_required = $16,
+			updated_at = $17,
+			deleted_at = $18
 		WHERE
-			id = $8
+			id = $19
 	`
 
 	stmt, err := cmd.PrepareContext(ctx, query)
@@ -180,9 +344,19 @@ func (r *customerRepository) Update(ctx context.Context, ID int64, c Customer, t
 		r.logger.WithContext(ctx).WithError(err).Error()
 		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
 	}
+	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, c.Name, c.Email, c.Password, c.PasswordSalt, c.VerificationStatus, c.MemberStatus, c.UpdatedAt, ID)
+	_, err = stmt.ExecContext(ctx,
+		c.Name, c.Email, c.Password, c.PasswordSalt, c.VerificationStatus, c.MemberStatus,
+		c.Phone, c.PhoneVerifiedAt,
+		c.BirthDate, c.Gender, c.City, c.Province, c.PreferredLanguage, c.Timezone,
+		c.AvatarKey, c.PasswordResetRequired,
+		c.UpdatedAt, c.DeletedAt, ID,
+	)
 	if err != nil {
+		if conflict := customerConflict(err, c); conflict != nil {
+			return conflict
+		}
 		r.logger.WithContext(ctx).WithError(err).Error()
 		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
 	}
@@ -190,6 +364,221 @@ func (r *customerRepository) Update(ctx context.Context, ID int64, c Customer, t
 	return nil
 }
 
+// EnableTwoFactor implements CustomerRepository. It returns false when the two factor authentication has been enabled by another request.
+func (r *customerRepository) EnableTwoFactor(ctx context.Context, ID int64, secret string, recoveryCodes RecoveryCodes, tx *sql.Tx) (bool, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		UPDATE customer
+		SET
+			two_factor_enabled = TRUE,
+			two_factor_secret = $2,
+			two_factor_recovery_codes = $3,
+			updated_at = $4
+		WHERE
+			id = $1
+			AND two_factor_enabled = FALSE
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return false, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, ID, secret, recoveryCodes, time.Now())
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return false, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
+	}
+
+	affected, _ := result.RowsAffected()
+
+	return affected > 0, nil
+}
+
+// DisableTwoFactor implements CustomerRepository. It removes the secret and the recovery codes, it returns false when the two factor authentication is not enabled.
+func (r *customerRepository) DisableTwoFactor(ctx context.Context, ID int64, tx *sql.Tx) (bool, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		UPDATE customer
+		SET
+			two_factor_enabled = FALSE,
+			two_factor_secret = '',
+			two_factor_recovery_codes = '[]',
+			updated_at = $2
+		WHERE
+			id = $1
+			AND two_factor_enabled = TRUE
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return false, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, ID, time.Now())
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return false, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
+	}
+
+	affected, _ := result.RowsAffected()
+
+	return affected > 0, nil
+}
+
+// ConsumeRecoveryCode implements CustomerRepository. The code is removed only when the customer still has it, so the concurrent attempts can not consume the same code twice.
+func (r *customerRepository) ConsumeRecoveryCode(ctx context.Context, ID int64, hashedCode string, tx *sql.Tx) (bool, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		UPDATE customer
+		SET
+			two_factor_recovery_codes = (
+				SELECT COALESCE(json_agg(code ORDER BY position), '[]')::text
+				FROM json_array_elements_text(two_factor_recovery_codes::json) WITH ORDINALITY AS codes (code, position)
+				WHERE code <> $2
+			),
+			updated_at = $3
+		WHERE
+			id = $1
+			AND two_factor_recovery_codes::jsonb @> jsonb_build_array($2::text)
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return false, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, ID, hashedCode, time.Now())
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return false, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating customer's prorperties")
+	}
+
+	affected, _ := result.RowsAffected()
+
+	return affected > 0, nil
+}
+
+// FindErasable implements CustomerRepository. It returns the deleted customers whose personal data has not been erased yet.
+func (r *customerRepository) FindErasable(ctx context.Context, deletedBefore time.Time, limit int, tx *sql.Tx) ([]Customer, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + customerColumns + `
+		FROM customer
+		WHERE
+			deleted_at <= $1
+			AND erased_at IS NULL
+		ORDER BY deleted_at ASC
+		LIMIT $2
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting erasable customers")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, deletedBefore, limit)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting erasable customers")
+	}
+	defer rows.Close()
+
+	customers := []Customer{}
+	for rows.Next() {
+		data, err := scanCustomer(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error()
+			return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting erasable customers")
+		}
+		customers = append(customers, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting erasable customers")
+	}
+
+	return customers, nil
+}
+
+// Erase implements CustomerRepository. It anonymises the personal data of the customer, the row is kept for the referential integrity.
+func (r *customerRepository) Erase(ctx context.Context, ID int64, tx *sql.Tx) error {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		UPDATE customer
+		SET
+			name = '',
+			email = 'erased-' || id || '@erased.invalid',
+			password = '',
+			password_salt = '',
+			two_factor_enabled = FALSE,
+			two_factor_secret = '',
+			two_factor_recovery_codes = '[]',
+			phone = NULL,
+			phone_verified_at = NULL,
+			birth_date = NULL,
+			gender = NULL,
+			city = NULL,
+			province = NULL,
+			preferred_language = NULL,
+			timezone = NULL,
+			avatar_key = NULL,
+			erased_at = $1,
+			updated_at = $1
+		WHERE
+			id = $2
+			AND deleted_at IS NOT NULL
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while erasing customer's prorperties")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, time.Now(), ID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while erasing customer's prorperties")
+	}
+
+	return nil
+}
+
 func NewCustomerRepository(logger *logrus.Logger, db *sql.DB) CustomerRepository {
 	return &customerRepository{
 		logger: logger,
@@ -1,9 +1,13 @@
 package customer
 
+import "github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
+
 type SignUpRequest struct {
-	Name     string `json:"name" validate:"required"`
-	Email    string `json:"email" validate:"email"`
-	Password string `json:"password" validate:"required"`
+	Name              string `json:"name" validate:"required"`
+	Email             string `json:"email" validate:"email"`
+	Password          string `json:"password" validate:"required,password=Email Name"`
+	AcceptTerms       bool   `json:"accept_terms"`
+	PromoterMarketing bool   `json:"promoter_marketing"`
 }
 
 type SignInRequest struct {
@@ -15,8 +19,20 @@ type VerifyRequest struct {
 	Token string
 }
 
+// UploadAvatarRequest holds the content of the uploaded avatar. Its type is sniffed from the content.
+type UploadAvatarRequest struct {
+	Content []byte
+}
+
+// UpdateProfileRequest only changes the given fields. The optional fields are cleared when they are given as empty string.
 type UpdateProfileRequest struct {
-	Name string `json:"name"`
+	Name              *string `json:"name" validate:"omitnil,min=1,max=100"`
+	BirthDate         *string `json:"birth_date" validate:"omitnil,eq=|birth_date"`
+	Gender            *string `json:"gender" validate:"omitnil,oneof='' MALE FEMALE OTHER PREFER_NOT_TO_SAY"`
+	City              *string `json:"city" validate:"omitnil,max=100"`
+	Province          *string `json:"province" validate:"omitnil,max=100"`
+	PreferredLanguage *string `json:"preferred_language" validate:"omitnil,eq=|bcp47_language_tag"`
+	Timezone          *string `json:"timezone" validate:"omitnil,eq=|timezone"`
 }
 
 type ChangeEmailRequest struct {
@@ -25,9 +41,178 @@ type ChangeEmailRequest struct {
 
 type ChangePasswordRequest struct {
 	ExistingPassword string `json:"existing_password" validate:"required"`
-	NewPassword      string `json:"new_password" validate:"required"`
+	NewPassword      string `json:"new_password" validate:"required,password"`
 }
 
 type ChangeEmailVerificationRequest struct {
 	Token string
 }
+
+type FinishPasskeyRegistrationRequest struct {
+	Name       string                       `json:"name" validate:"required,max=100"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+
+type RenamePasskeyRequest struct {
+	ID   int64
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type DeletePasskeyRequest struct {
+	ID int64
+}
+
+type PasskeySignInRequest struct {
+	ChallengeToken string                     `json:"challenge_token" validate:"required"`
+	Credential     webauthn.AssertionResponse `json:"credential"`
+}
+
+type ChangeEmailRevertRequest struct {
+	Token string
+}
+
+type AdminSensitiveChangesRequest struct {
+	CustomerID int64
+}
+
+type ConsentRequest struct {
+	Type    string `json:"type" validate:"oneof=TERMS_OF_SERVICE PRIVACY_POLICY PROMOTER_MARKETING"`
+	Granted bool   `json:"granted"`
+}
+
+type UpdateConsentsRequest struct {
+	Consents []ConsentRequest `json:"consents" validate:"required,min=1,dive"`
+}
+
+type SignInConsentRequest struct {
+	ChallengeToken string  `json:"challenge_token" validate:"required"`
+	DocumentIDs    []int64 `json:"document_ids" validate:"required,min=1"`
+}
+
+type CreateLegalDocumentRequest struct {
+	Type      string `json:"type" validate:"oneof=TERMS_OF_SERVICE PRIVACY_POLICY PROMOTER_MARKETING"`
+	Version   string `json:"version" validate:"required,max=32"`
+	Title     string `json:"title" validate:"required,max=255"`
+	URL       string `json:"url" validate:"required,url,max=2048"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+type PublishLegalDocumentRequest struct {
+	ID int64
+}
+
+type LoginHistoryRequest struct {
+	Limit  int `validate:"min=1,max=100"`
+	Offset int `validate:"min=0"`
+}
+
+type AdminLoginHistoryRequest struct {
+	CustomerID int64
+	Limit      int `validate:"min=1,max=100"`
+	Offset     int `validate:"min=0"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type RevokeSessionRequest struct {
+	ID string
+}
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type SignInTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,password"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type DataExportRequest struct {
+	Format string `json:"format" validate:"omitempty,oneof=JSON ZIP"`
+}
+
+type AdminDataExportRequest struct {
+	CustomerID int64
+	Format     string `json:"format" validate:"omitempty,oneof=JSON ZIP"`
+}
+
+type DownloadDataExportRequest struct {
+	ID        string
+	Expires   int64
+	Signature string
+}
+
+type DeactivateRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type RequestReactivationRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type ReactivationVerificationRequest struct {
+	Token string
+}
+
+type OIDCAuthorizationRequest struct {
+	Provider string
+}
+
+type OIDCCallbackRequest struct {
+	Provider string
+	Code     string `json:"code" validate:"required"`
+	State    string `json:"state" validate:"required"`
+}
+
+type UnlinkIdentityRequest struct {
+	Provider string
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type MagicLinkVerificationRequest struct {
+	Token string
+	Nonce string
+}
+
+type ChangePhoneRequest struct {
+	Phone string `json:"phone" validate:"required,e164"`
+}
+
+type VerifyPhoneRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type PhoneSignInRequest struct {
+	Phone string `json:"phone" validate:"required,e164"`
+}
+
+type VerifyPhoneSignInRequest struct {
+	Phone string `json:"phone" validate:"required,e164"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
@@ -1,26 +1,167 @@
 package customer
 
-import "time"
+import (
+	"time"
+
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
+)
 
 type SignUpResponse struct {
 	VerificationExpiresAt time.Time `json:"verification_expires_at"`
 }
 
 type SignInResponse struct {
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
+	Token                 string                  `json:"token"`
+	ExpiresAt             time.Time               `json:"expires_at"`
+	RefreshToken          string                  `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time               `json:"refresh_token_expires_at"`
+	TwoFactorRequired     bool                    `json:"two_factor_required"`
+	ConsentRequired       bool                    `json:"consent_required"`
+	RequiredDocuments     []LegalDocumentResponse `json:"required_documents,omitempty"`
+	ChallengeToken        string                  `json:"challenge_token,omitempty"`
+	ChallengeExpiresAt    *time.Time              `json:"challenge_expires_at,omitempty"`
 }
 
 type GetProfileResponse struct {
-	ID                 int64     `json:"id"`
-	Name               string    `json:"name"`
-	Email              string    `json:"email"`
-	VerificationStatus string    `json:"verification_status"`
-	MemberStatus       string    `json:"member_status"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID                int64   `json:"id"`
+	Name              string  `json:"name"`
+	Email             string  `json:"email"`
+	Phone             *string `json:"phone"`
+	BirthDate         *string `json:"birth_date"`
+	Gender            *string `json:"gender"`
+	City              *string `json:"city"`
+	Province          *string `json:"province"`
+	PreferredLanguage *string `json:"preferred_language"`
+	Timezone          *string `json:"timezone"`
+	AvatarURL         *string `json:"avatar_url"`
+	// AvatarThumbnails are the urls of the avatar's thumbnails keyed by their size in pixels.
+	AvatarThumbnails   map[string]string `json:"avatar_thumbnails,omitempty"`
+	VerificationStatus string            `json:"verification_status"`
+	MemberStatus       string            `json:"member_status"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+type UploadAvatarResponse struct {
+	AvatarURL        string            `json:"avatar_url"`
+	AvatarThumbnails map[string]string `json:"avatar_thumbnails"`
+}
+
+type SensitiveChangeResponse struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	IPAddress string    `json:"ip_address"`
+	Device    string    `json:"device"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LegalDocumentResponse struct {
+	ID          int64      `json:"id"`
+	Type        string     `json:"type"`
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Mandatory   bool       `json:"mandatory"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type ConsentResponse struct {
+	Type             string     `json:"type"`
+	Granted          bool       `json:"granted"`
+	Version          *string    `json:"version"`
+	UpdatedAt        *time.Time `json:"updated_at"`
+	LatestVersion    *string    `json:"latest_version"`
+	ReacceptRequired bool       `json:"reaccept_required"`
+}
+
+type ConsentLogResponse struct {
+	Type      string    `json:"type"`
+	Granted   bool      `json:"granted"`
+	Version   *string   `json:"version"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginEventResponse struct {
+	ID            int64     `json:"id"`
+	Method        string    `json:"method"`
+	Outcome       string    `json:"outcome"`
+	FailureReason *string   `json:"failure_reason"`
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	Device        string    `json:"device"`
+	Country       string    `json:"country"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PasskeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type PasskeySignInChallengeResponse struct {
+	ChallengeToken string                  `json:"challenge_token"`
+	Options        webauthn.RequestOptions `json:"options"`
+	ExpiresAt      time.Time               `json:"expires_at"`
 }
 
 type ChangeEmailResponse struct {
 	VerificationExpiresAt time.Time `json:"verification_expires_at"`
 }
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type EnrolTwoFactorResponse struct {
+	Secret    string    `json:"secret"`
+	URI       string    `json:"uri"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ConfirmTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DeleteAccountResponse struct {
+	DeletedAt          time.Time `json:"deleted_at"`
+	ErasureScheduledAt time.Time `json:"erasure_scheduled_at"`
+}
+
+type DataExportResponse struct {
+	ID     string `json:"id"`
+	Format string `json:"format"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type IdentityResponse struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MagicLinkResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type PhoneOTPResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,169 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+// RevertEmailChange implements CustomerUseCase. The link is sent to the existing email, so the owner is able to take the account back from whoever has changed it.
+// The sessions are revoked and the password must be reset, since the one that has made the change may know the password.
+func (u *customerUseCase) RevertEmailChange(ctx context.Context, req ChangeEmailRevertRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	key := fmt.Sprintf(emailChangeRevertKeyPrefix, req.Token)
+
+	revertBuff, err := u.cache.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "invalid or expired revert email change token")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while reverting customer's email change")
+	}
+
+	var revert EmailChangeRevert
+	json.Unmarshal(revertBuff, &revert)
+
+	c, err := u.customerRepository.FindByID(ctx, revert.CustomerID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+		}
+		return err
+	}
+
+	if c.DeletedAt != nil {
+		return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+	}
+
+	revertedEmail := c.Email
+	if c.Email != revert.ExistingEmail {
+		if err := u.checkEmailAvailability(ctx, revert.ExistingEmail, c.ID, nil); err != nil {
+			return err
+		}
+	}
+
+	// the token is consumed only once the revert is able to proceed, so the owner keeps the link when it fails, e.g. the existing email is taken meanwhile.
+	if err := u.cache.GetDel(ctx, key).Err(); err != nil {
+		if err == redis.Nil {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "invalid or expired revert email change token")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while reverting customer's email change")
+	}
+
+	// the change may not have been verified yet, so it must not be verified afterwards.
+	if err := u.cache.Del(ctx, fmt.Sprintf(changeEmailVerificationKeyPrefix, revert.VerificationToken)).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		u.restoreEmailChangeRevert(ctx, key, revert, revertBuff)
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while reverting customer's email change")
+	}
+
+	u.releaseEmail(ctx, revert.NewEmail, c.ID)
+
+	now := time.Now()
+	c.Email = revert.ExistingEmail
+	c.PasswordResetRequired = true
+	c.UpdatedAt = now
+
+	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+		u.restoreEmailChangeRevert(ctx, key, revert, revertBuff)
+		return err
+	}
+
+	if err := u.revokeSessions(ctx, c.ID); err != nil {
+		return err
+	}
+
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangeEmailReverted, &revertedEmail, &c.Email)
+
+	changeEmailRevertedEvent := ChangeEmailRevertedEvent{
+		ID:            c.ID,
+		RevertedEmail: revertedEmail,
+		Email:         c.Email,
+		RevertedAt:    now,
+	}
+
+	changeEmailRevertedEventBuff, _ := json.Marshal(changeEmailRevertedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-change-email-reverted", fmt.Sprintf("customer:%d", c.ID), messageHeader, changeEmailRevertedEventBuff)
+
+	return u.sendResetPasswordLink(ctx, c)
+}
+
+// restoreEmailChangeRevert puts the consumed revert token back until its original expiry, so the owner is able to retry the revert.
+func (u *customerUseCase) restoreEmailChangeRevert(ctx context.Context, key string, revert EmailChangeRevert, revertBuff []byte) {
+	expiresIn := time.Until(revert.RequestedAt.Add(emailChangeRevertExpiresIn))
+	if expiresIn <= 0 {
+		return
+	}
+
+	if err := u.cache.Set(ctx, key, revertBuff, expiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+}
+
+// GetSensitiveChangesForCustomer implements CustomerUseCase. It is requested by the administrator, e.g. the support that investigates a take over.
+func (u *customerUseCase) GetSensitiveChangesForCustomer(ctx context.Context, req AdminSensitiveChangesRequest) ([]SensitiveChangeResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	c, err := u.customerRepository.FindByID(ctx, req.CustomerID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := u.customerSensitiveChangeRepository.FindByCustomerID(ctx, c.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if acc, err := session.GetAccountFromCtx(ctx); err == nil {
+		u.logger.WithContext(ctx).WithField("admin_id", acc.ID).WithField("customer_id", c.ID).Info("customer's sensitive changes are viewed by administrator")
+	}
+
+	resp := make([]SensitiveChangeResponse, len(changes))
+	for k, change := range changes {
+		resp[k] = SensitiveChangeResponse{
+			ID:        change.ID,
+			Type:      change.Type,
+			OldValue:  change.OldValue,
+			NewValue:  change.NewValue,
+			IPAddress: change.IPAddress,
+			Device:    change.Device,
+			CreatedAt: change.CreatedAt,
+		}
+	}
+
+	return resp, nil
+}
+
+// recordSensitiveChange keeps the history of the change along with the client that has made it. The change has been made, so the failure is only logged by the repository.
+func (u *customerUseCase) recordSensitiveChange(ctx context.Context, customerID int64, changeType string, oldValue, newValue *string) {
+	ci := clientinfo.FromContext(ctx)
+	change := CustomerSensitiveChange{
+		CustomerID: customerID,
+		Type:       changeType,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+		IPAddress:  ci.IPAddress,
+		Device:     ci.Device,
+		CreatedAt:  time.Now(),
+	}
+
+	u.customerSensitiveChangeRepository.Save(ctx, change, nil)
+}
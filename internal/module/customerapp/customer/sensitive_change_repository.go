@@ -0,0 +1,157 @@
+package customer
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type CustomerSensitiveChangeRepository interface {
+	Save(ctx context.Context, csc CustomerSensitiveChange, tx *sql.Tx) (int64, error)
+	FindByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerSensitiveChange, error)
+	DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error
+}
+
+const customerSensitiveChangeColumns = `
+	id, customer_id, type, old_value, new_value, ip_address, device, created_at
+`
+
+func scanCustomerSensitiveChange(row rowScanner) (CustomerSensitiveChange, error) {
+	var data CustomerSensitiveChange
+
+	err := row.Scan(&data.ID, &data.CustomerID, &data.Type, &data.OldValue, &data.NewValue, &data.IPAddress, &data.Device, &data.CreatedAt)
+
+	return data, err
+}
+
+type customerSensitiveChangeRepository struct {
+	logger *logrus.Logger
+	db     *sql.DB
+}
+
+// Save implements CustomerSensitiveChangeRepository.
+func (r *customerSensitiveChangeRepository) Save(ctx context.Context, csc CustomerSensitiveChange, tx *sql.Tx) (int64, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		INSERT INTO customer_sensitive_change
+		(
+			customer_id, type, old_value, new_value, ip_address, device, created_at
+		)
+		VALUES
+		(
+			$1, $2, $3, $4, $5, $6, $7
+		)
+		RETURNING id
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's sensitive change")
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, csc.CustomerID, csc.Type, csc.OldValue, csc.NewValue, csc.IPAddress, csc.Device, csc.CreatedAt)
+
+	var ID int64
+
+	if err := row.Scan(&ID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving customer's sensitive change")
+	}
+
+	return ID, nil
+}
+
+// FindByCustomerID implements CustomerSensitiveChangeRepository. The latest change comes first.
+func (r *customerSensitiveChangeRepository) FindByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) ([]CustomerSensitiveChange, error) {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		SELECT ` + customerSensitiveChangeColumns + `
+		FROM customer_sensitive_change
+		WHERE
+			customer_id = $1
+		ORDER BY created_at DESC
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's sensitive changes")
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, customerID)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's sensitive changes")
+	}
+	defer rows.Close()
+
+	changes := []CustomerSensitiveChange{}
+	for rows.Next() {
+		data, err := scanCustomerSensitiveChange(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error()
+			return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's sensitive changes")
+		}
+		changes = append(changes, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting customer's sensitive changes")
+	}
+
+	return changes, nil
+}
+
+// DeleteByCustomerID implements CustomerSensitiveChangeRepository.
+func (r *customerSensitiveChangeRepository) DeleteByCustomerID(ctx context.Context, customerID int64, tx *sql.Tx) error {
+	var cmd sqlCommand = r.db
+
+	if tx != nil {
+		cmd = tx
+	}
+
+	query := `
+		DELETE FROM customer_sensitive_change
+		WHERE
+			customer_id = $1
+	`
+
+	stmt, err := cmd.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's sensitive changes")
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, customerID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting customer's sensitive changes")
+	}
+
+	return nil
+}
+
+func NewCustomerSensitiveChangeRepository(logger *logrus.Logger, db *sql.DB) CustomerSensitiveChangeRepository {
+	return &customerSensitiveChangeRepository{
+		logger: logger,
+		db:     db,
+	}
+}
@@ -0,0 +1,55 @@
+package customer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/module/customerapp/customer"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+func TestSignInTwoFactorLocksOutAcrossChallenges(t *testing.T) {
+	secret, err := util.Encrypt("JBSWY3DPEHPK3PXP", cryptoSecret)
+	assert.NoError(t, err)
+
+	uc, _ := newTestUseCase(t, func(props *customer.CustomerUseCaseProperty) {
+		props.CustomerRepository = &customerRepository{c: customer.Customer{
+			ID:                 1,
+			Email:              "john@example.com",
+			Password:           "secret",
+			VerificationStatus: customer.VerficationStatusVerified,
+			MemberStatus:       customer.MemberStatusActive,
+			TwoFactorEnabled:   true,
+			TwoFactorSecret:    secret,
+		}}
+	})
+
+	ctx := context.Background()
+	signIn := customer.SignInRequest{Email: "john@example.com", Password: "secret"}
+
+	// every challenge allows a few codes, the failures must add up across the challenges.
+	failures := 0
+	for failures < 4 {
+		resp, err := uc.SignIn(ctx, signIn)
+		assert.NoError(t, err)
+		assert.True(t, resp.TwoFactorRequired)
+
+		for i := 0; i < 2 && failures < 4; i++ {
+			_, err := uc.SignInTwoFactor(ctx, customer.SignInTwoFactorRequest{ChallengeToken: resp.ChallengeToken, Code: "000000x"})
+			assert.True(t, errors.MatchStatus(err, status.BAD_REQUEST))
+			failures++
+		}
+	}
+
+	resp, err := uc.SignIn(ctx, signIn)
+	assert.NoError(t, err)
+
+	_, err = uc.SignInTwoFactor(ctx, customer.SignInTwoFactorRequest{ChallengeToken: resp.ChallengeToken, Code: "000000x"})
+	assert.True(t, errors.MatchStatus(err, status.ACCOUNT_LOCKED))
+
+	_, err = uc.SignIn(ctx, signIn)
+	assert.True(t, errors.MatchStatus(err, status.ACCOUNT_LOCKED))
+}
@@ -2,19 +2,35 @@ package customer
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/jwt"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/lockout"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/passkey"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/password"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
 	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/oidc"
 	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/sms"
 	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	"github.com/tsel-ticketmaster/tm-user/pkg/storage"
+	"github.com/tsel-ticketmaster/tm-user/pkg/totp"
+	publicValidator "github.com/tsel-ticketmaster/tm-user/pkg/validator"
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
 )
 
 type CustomerUseCase interface {
@@ -23,36 +39,115 @@ type CustomerUseCase interface {
 	SignOut(ctx context.Context) error
 	GetProfile(ctx context.Context) (GetProfileResponse, error)
 	UpdateProfile(ctx context.Context, req UpdateProfileRequest) error
+	UploadAvatar(ctx context.Context, req UploadAvatarRequest) (UploadAvatarResponse, error)
 	ChangeEmail(ctx context.Context, req ChangeEmailRequest) (ChangeEmailResponse, error)
 	ChangePassword(ctx context.Context, req ChangePasswordRequest) error
 	Verify(ctx context.Context, req VerifyRequest) error
 	VerifyChangeEmail(ctx context.Context, req ChangeEmailVerificationRequest) error
+	RevertEmailChange(ctx context.Context, req ChangeEmailRevertRequest) error
+	GetSensitiveChangesForCustomer(ctx context.Context, req AdminSensitiveChangesRequest) ([]SensitiveChangeResponse, error)
+	RefreshToken(ctx context.Context, req RefreshTokenRequest) (SignInResponse, error)
+	GetSessions(ctx context.Context) ([]SessionResponse, error)
+	RevokeSession(ctx context.Context, req RevokeSessionRequest) error
+	RevokeOtherSessions(ctx context.Context) error
+	EnrolTwoFactor(ctx context.Context) (EnrolTwoFactorResponse, error)
+	ConfirmTwoFactor(ctx context.Context, req ConfirmTwoFactorRequest) (ConfirmTwoFactorResponse, error)
+	DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) error
+	SignInTwoFactor(ctx context.Context, req SignInTwoFactorRequest) (SignInResponse, error)
+	ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+	ResendVerification(ctx context.Context, req ResendVerificationRequest) error
+	DeleteAccount(ctx context.Context, req DeleteAccountRequest) (DeleteAccountResponse, error)
+	ExportData(ctx context.Context, req DataExportRequest) (DataExportResponse, error)
+	ExportDataForCustomer(ctx context.Context, req AdminDataExportRequest) (DataExportResponse, error)
+	DownloadDataExport(ctx context.Context, req DownloadDataExportRequest) (DataExportArchive, error)
+	Deactivate(ctx context.Context, req DeactivateRequest) error
+	RequestReactivation(ctx context.Context, req RequestReactivationRequest) error
+	VerifyReactivation(ctx context.Context, req ReactivationVerificationRequest) error
+	AuthorizeOIDC(ctx context.Context, req OIDCAuthorizationRequest) (OIDCAuthorizationResponse, error)
+	SignInOIDC(ctx context.Context, req OIDCCallbackRequest) (SignInResponse, error)
+	AuthorizeOIDCLink(ctx context.Context, req OIDCAuthorizationRequest) (OIDCAuthorizationResponse, error)
+	LinkOIDC(ctx context.Context, req OIDCCallbackRequest) error
+	GetIdentities(ctx context.Context) ([]IdentityResponse, error)
+	UnlinkIdentity(ctx context.Context, req UnlinkIdentityRequest) error
+	RequestMagicLink(ctx context.Context, req MagicLinkRequest) (MagicLinkResponse, error)
+	VerifyMagicLink(ctx context.Context, req MagicLinkVerificationRequest) (SignInResponse, error)
+	ChangePhone(ctx context.Context, req ChangePhoneRequest) (PhoneOTPResponse, error)
+	VerifyPhone(ctx context.Context, req VerifyPhoneRequest) error
+	RequestPhoneSignIn(ctx context.Context, req PhoneSignInRequest) (PhoneOTPResponse, error)
+	SignInPhone(ctx context.Context, req VerifyPhoneSignInRequest) (SignInResponse, error)
+	BeginPasskeyRegistration(ctx context.Context) (webauthn.CreationOptions, error)
+	FinishPasskeyRegistration(ctx context.Context, req FinishPasskeyRegistrationRequest) (PasskeyResponse, error)
+	GetPasskeys(ctx context.Context) ([]PasskeyResponse, error)
+	RenamePasskey(ctx context.Context, req RenamePasskeyRequest) error
+	DeletePasskey(ctx context.Context, req DeletePasskeyRequest) error
+	BeginPasskeySignIn(ctx context.Context) (PasskeySignInChallengeResponse, error)
+	SignInPasskey(ctx context.Context, req PasskeySignInRequest) (SignInResponse, error)
+	GetLoginHistory(ctx context.Context, req LoginHistoryRequest) ([]LoginEventResponse, error)
+	GetLoginHistoryForCustomer(ctx context.Context, req AdminLoginHistoryRequest) ([]LoginEventResponse, error)
+	GetLegalDocuments(ctx context.Context) ([]LegalDocumentResponse, error)
+	GetAllLegalDocuments(ctx context.Context) ([]LegalDocumentResponse, error)
+	CreateLegalDocument(ctx context.Context, req CreateLegalDocumentRequest) (LegalDocumentResponse, error)
+	PublishLegalDocument(ctx context.Context, req PublishLegalDocumentRequest) (LegalDocumentResponse, error)
+	GetConsents(ctx context.Context) ([]ConsentResponse, error)
+	UpdateConsents(ctx context.Context, req UpdateConsentsRequest) error
+	SignInConsent(ctx context.Context, req SignInConsentRequest) (SignInResponse, error)
 }
 
 type CustomerUseCaseProperty struct {
-	AppName            string
-	Logger             *logrus.Logger
-	Timeout            time.Duration
-	TMUserBaseURL      string
-	CryptoSecret       string
-	JSONWebToken       *jwt.JSONWebToken
-	Session            session.Session
-	Cache              redis.UniversalClient
-	Publisher          pubsub.Publisher
-	CustomerRepository CustomerRepository
+	AppName                           string
+	Logger                            *logrus.Logger
+	Timeout                           time.Duration
+	TMUserBaseURL                     string
+	CryptoSecret                      string
+	JSONWebToken                      *jwt.JSONWebToken
+	Session                           session.Session
+	RefreshToken                      session.RefreshTokenStore
+	Lockout                           lockout.Lockout
+	PasswordHasher                    password.Hasher
+	DeletionGracePeriod               time.Duration
+	OIDCProviders                     map[string]*oidc.Provider
+	SMSSender                         sms.SMSSender
+	Passkey                           passkey.Passkey
+	EmailOptions                      emailaddress.Options
+	DisposableEmailDomains            *emailaddress.Blocklist
+	ObjectStorage                     storage.ObjectStorage
+	Cache                             redis.UniversalClient
+	Publisher                         pubsub.Publisher
+	CustomerRepository                CustomerRepository
+	CustomerIdentityRepository        CustomerIdentityRepository
+	CustomerSensitiveChangeRepository CustomerSensitiveChangeRepository
+	CustomerLoginEventRepository      CustomerLoginEventRepository
+	CustomerConsentRepository         CustomerConsentRepository
+	LegalDocumentRepository           LegalDocumentRepository
 }
 
 type customerUseCase struct {
-	appName            string
-	logger             *logrus.Logger
-	timeout            time.Duration
-	tmuserBaseURL      string
-	cryptoSecret       string
-	jsonWebToken       *jwt.JSONWebToken
-	session            session.Session
-	cache              redis.UniversalClient
-	publisher          pubsub.Publisher
-	customerRepository CustomerRepository
+	appName                           string
+	logger                            *logrus.Logger
+	timeout                           time.Duration
+	tmuserBaseURL                     string
+	cryptoSecret                      string
+	jsonWebToken                      *jwt.JSONWebToken
+	session                           session.Session
+	refreshToken                      session.RefreshTokenStore
+	lockout                           lockout.Lockout
+	passwordHasher                    password.Hasher
+	deletionGracePeriod               time.Duration
+	oidcProviders                     map[string]*oidc.Provider
+	smsSender                         sms.SMSSender
+	passkey                           passkey.Passkey
+	emailOptions                      emailaddress.Options
+	disposableEmailDomains            *emailaddress.Blocklist
+	objectStorage                     storage.ObjectStorage
+	cache                             redis.UniversalClient
+	publisher                         pubsub.Publisher
+	customerRepository                CustomerRepository
+	customerIdentityRepository        CustomerIdentityRepository
+	customerSensitiveChangeRepository CustomerSensitiveChangeRepository
+	customerLoginEventRepository      CustomerLoginEventRepository
+	customerConsentRepository         CustomerConsentRepository
+	legalDocumentRepository           LegalDocumentRepository
 }
 
 // ChangeEmail implements CustomerUseCase.
@@ -60,6 +155,11 @@ func (u *customerUseCase) ChangeEmail(ctx context.Context, req ChangeEmailReques
 	ctx, cancel := context.WithTimeout(ctx, u.timeout)
 	defer cancel()
 
+	req.Email = u.canonicalEmail(req.Email)
+	if err := u.checkEmailDomain(req.Email); err != nil {
+		return ChangeEmailResponse{}, err
+	}
+
 	acc, err := session.GetAccountFromCtx(ctx)
 	if err != nil {
 		return ChangeEmailResponse{}, err
@@ -70,13 +170,21 @@ func (u *customerUseCase) ChangeEmail(ctx context.Context, req ChangeEmailReques
 		return ChangeEmailResponse{}, err
 	}
 
-	if req.Email == c.Email {
+	if req.Email == u.canonicalEmail(c.Email) {
 		return ChangeEmailResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "the new email is the same as existing email")
 	}
 
+	if err := u.checkEmailAvailability(ctx, req.Email, c.ID, nil); err != nil {
+		return ChangeEmailResponse{}, err
+	}
+
 	now := time.Now()
 	linkExpiresIn := time.Minute * 5
 	linkExpiresAt := now.Add(linkExpiresIn)
+
+	if err := u.reserveEmail(ctx, req.Email, c.ID, linkExpiresIn); err != nil {
+		return ChangeEmailResponse{}, err
+	}
 	verificationToken := util.GenerateRandomHEX(32)
 	verificationKey := fmt.Sprintf(changeEmailVerificationKeyPrefix, verificationToken)
 	verificationLink := fmt.Sprintf("%s%s?token=%s", u.tmuserBaseURL, ChangeEmailVerificationURLPath, verificationToken)
@@ -95,12 +203,45 @@ func (u *customerUseCase) ChangeEmail(ctx context.Context, req ChangeEmailReques
 		return ChangeEmailResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while changing customer's email")
 	}
 
+	revertToken := util.GenerateRandomHEX(32)
+	revert := EmailChangeRevert{
+		CustomerID:        c.ID,
+		ExistingEmail:     c.Email,
+		NewEmail:          req.Email,
+		VerificationToken: verificationToken,
+		RequestedAt:       now,
+	}
+
+	revertBuff, _ := json.Marshal(revert)
+
+	if err := u.cache.Set(ctx, fmt.Sprintf(emailChangeRevertKeyPrefix, revertToken), revertBuff, emailChangeRevertExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return ChangeEmailResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while changing customer's email")
+	}
+
+	ci := clientinfo.FromContext(ctx)
+	changeEmailNoticeEvent := ChangeEmailNoticeEvent{
+		ID:            c.ID,
+		Name:          c.Name,
+		ExistingEmail: c.Email,
+		NewEmail:      req.Email,
+		RevertLink:    fmt.Sprintf("%s%s?token=%s", u.tmuserBaseURL, ChangeEmailRevertURLPath, revertToken),
+		IPAddress:     ci.IPAddress,
+		Device:        ci.Device,
+		ExpiresAt:     now.Add(emailChangeRevertExpiresIn),
+	}
+
+	changeEmailNoticeEventBuff, _ := json.Marshal(changeEmailNoticeEvent)
+
 	messageHeader := pubsub.MessageHeaders{
 		"origin": u.appName,
 	}
 	u.publisher.Publish(ctx, "customer-change-email", fmt.Sprintf("customer:%d", c.ID), messageHeader, changeEmailEventBuff)
+	u.publisher.Publish(ctx, "customer-change-email-notice", fmt.Sprintf("customer:%d", c.ID), messageHeader, changeEmailNoticeEventBuff)
+
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangeEmailRequested, &c.Email, &req.Email)
 
-	if err := u.session.Delete(ctx, fmt.Sprintf("customer:%d", c.ID)); err != nil {
+	if err := u.revokeSessions(ctx, c.ID); err != nil {
 		return ChangeEmailResponse{}, err
 	}
 
@@ -126,22 +267,19 @@ func (u *customerUseCase) ChangePassword(ctx context.Context, req ChangePassword
 		return err
 	}
 
-	hashedExistingPassword := util.GenerateSecret(fmt.Sprintf("%s%s", u.cryptoSecret, req.ExistingPassword), c.PasswordSalt, 256)
-	if hashedExistingPassword != c.Password {
+	if !u.matchPassword(c, req.ExistingPassword) {
 		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid customer's existing password")
 	}
 
-	newPasswordSalt := util.GenerateRandomHEX(16)
-	newHashedPassword := util.GenerateSecret(fmt.Sprintf("%s%s", u.cryptoSecret, req.NewPassword), newPasswordSalt, 256)
-
-	c.Password = newHashedPassword
-	c.PasswordSalt = newPasswordSalt
+	c.Password, c.PasswordSalt = u.hashPassword(req.NewPassword)
 
 	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
 		return err
 	}
 
-	if err := u.session.Delete(ctx, fmt.Sprintf("customer:%d", c.ID)); err != nil {
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangePassword, nil, nil)
+
+	if err := u.revokeSessions(ctx, c.ID); err != nil {
 		return err
 	}
 
@@ -163,17 +301,7 @@ func (u *customerUseCase) GetProfile(ctx context.Context) (GetProfileResponse, e
 		return GetProfileResponse{}, err
 	}
 
-	resp := GetProfileResponse{
-		ID:                 c.ID,
-		Name:               c.Name,
-		Email:              c.Email,
-		VerificationStatus: c.VerificationStatus,
-		MemberStatus:       c.MemberStatus,
-		CreatedAt:          c.CreatedAt,
-		UpdatedAt:          c.UpdatedAt,
-	}
-
-	return resp, nil
+	return newProfileResponse(c, u.objectStorage), nil
 }
 
 // SignIn implements CustomerUseCase.
@@ -181,18 +309,128 @@ func (u *customerUseCase) SignIn(ctx context.Context, req SignInRequest) (SignIn
 	ctx, cancel := context.WithTimeout(ctx, u.timeout)
 	defer cancel()
 
-	c, err := u.customerRepository.FindByEmail(ctx, req.Email, nil)
+	req.Email = u.canonicalEmail(req.Email)
+
+	account := lockoutAccount(req.Email)
+	ip := clientinfo.FromContext(ctx).IPAddress
+
+	if err := u.lockout.Check(ctx, account, ip); err != nil {
+		return SignInResponse{}, err
+	}
+
+	c, err := u.findCustomerByEmail(ctx, req.Email, nil)
 	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			u.lockout.Fail(ctx, account, ip)
+		}
 		return SignInResponse{}, err
 	}
 
 	if c.VerificationStatus == VerificationStatusUnverified {
-		return SignInResponse{}, errors.New(http.StatusForbidden, status.FORBIDDEN, "customer is not verified")
+		return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodPassword, LoginFailureUnverified, errors.New(http.StatusForbidden, status.FORBIDDEN, "customer is not verified"))
+	}
+
+	match, rehash := u.passwordHasher.Verify(req.Password, c.Password, c.PasswordSalt)
+	if !match {
+		lockedUntil, err := u.lockout.Fail(ctx, account, ip)
+		if err != nil {
+			return SignInResponse{}, err
+		}
+
+		if !lockedUntil.IsZero() {
+			u.publishAccountLocked(ctx, c, lockedUntil)
+			return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodPassword, LoginFailureAccountLocked, errors.New(http.StatusLocked, status.ACCOUNT_LOCKED, "account is temporarily locked due to too many failed sign in attempts"))
+		}
+
+		return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodPassword, LoginFailureInvalidCredentials, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid customer's email or password"))
+	}
+
+	if c.MemberStatus != MemberStatusActive {
+		return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodPassword, LoginFailureAccountInactive, errors.New(http.StatusForbidden, status.ACCOUNT_INACTIVE, "customer's account is deactivated, request a reactivation link to activate it again"))
+	}
+
+	if rehash {
+		c.Password, c.PasswordSalt = u.hashPassword(req.Password)
+		c.UpdatedAt = time.Now()
+		// the customer is still able to sign in with the old hash, so the failure is not returned.
+		u.customerRepository.Update(ctx, c.ID, c, nil)
+	}
+
+	// the failures are cleared once the second factor is passed, otherwise the password opens the challenges to guess the code without limit.
+	if c.TwoFactorEnabled {
+		return u.challengeTwoFactor(ctx, c, LoginMethodPassword)
+	}
+
+	if err := u.lockout.Succeed(ctx, account); err != nil {
+		return SignInResponse{}, err
+	}
+
+	return u.completeSignIn(ctx, c, LoginMethodPassword)
+}
+
+// publishAccountLocked notifies the customer that the account has been locked, so the customer is aware of the attempts.
+func (u *customerUseCase) publishAccountLocked(ctx context.Context, c Customer, lockedUntil time.Time) {
+	accountLockedEvent := AccountLockedEvent{
+		ID:          c.ID,
+		Name:        c.Name,
+		Email:       c.Email,
+		IPAddress:   clientinfo.FromContext(ctx).IPAddress,
+		LockedUntil: lockedUntil,
+	}
+
+	accountLockedEventBuff, _ := json.Marshal(accountLockedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-account-locked", fmt.Sprintf("customer:%d", c.ID), messageHeader, accountLockedEventBuff)
+}
+
+// RefreshToken implements CustomerUseCase.
+func (u *customerUseCase) RefreshToken(ctx context.Context, req RefreshTokenRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	rt, err := u.refreshToken.Rotate(ctx, req.RefreshToken)
+	if err != nil {
+		if rt.FamilyID != "" {
+			u.session.Delete(ctx, rt.Subject, rt.FamilyID)
+		}
+		return SignInResponse{}, err
+	}
+
+	var ID int64
+	if _, err := fmt.Sscanf(rt.Subject, "customer:%d", &ID); err != nil {
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid refresh token")
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, ID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid refresh token")
+		}
+		return SignInResponse{}, err
+	}
+
+	return u.createSession(ctx, c, rt)
+}
+
+// createSession signs a new id token for the customer and stores its session. The session is identified by the refresh token's family, so it lives as long as the refresh token can be rotated.
+func (u *customerUseCase) createSession(ctx context.Context, c Customer, rt session.RefreshToken) (SignInResponse, error) {
+	// every way of signing in ends here, so none of them is able to skip the member status or the reset, including the refresh of the session.
+	if c.DeletedAt != nil || c.MemberStatus == MemberStatusDeleted {
+		u.refreshToken.RevokeAll(ctx, fmt.Sprintf("customer:%d", c.ID))
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "customer's account has been deleted")
+	}
+
+	if c.MemberStatus != MemberStatusActive {
+		u.refreshToken.RevokeAll(ctx, fmt.Sprintf("customer:%d", c.ID))
+		return SignInResponse{}, errors.New(http.StatusForbidden, status.ACCOUNT_INACTIVE, "customer's account is deactivated, request a reactivation link to activate it again")
 	}
 
-	hashedPassword := util.GenerateSecret(fmt.Sprintf("%s%s", u.cryptoSecret, req.Password), c.PasswordSalt, 256)
-	if c.Password != hashedPassword {
-		return SignInResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid customer's email or password")
+	if c.PasswordResetRequired {
+		u.refreshToken.RevokeAll(ctx, fmt.Sprintf("customer:%d", c.ID))
+		return SignInResponse{}, errors.New(http.StatusForbidden, status.PASSWORD_RESET_REQUIRED, "customer's password must be reset, follow the reset password link that has been sent to the email")
 	}
 
 	now := time.Now()
@@ -202,6 +440,7 @@ func (u *customerUseCase) SignIn(ctx context.Context, req SignInRequest) (SignIn
 	userType := "CUSTOMER"
 
 	claim := jwt.Claim{}
+	claim.Id = rt.FamilyID
 	claim.Subject = subject
 	claim.IssuedAt = now.Unix()
 	claim.ExpiresAt = expiresAt.Unix()
@@ -216,23 +455,128 @@ func (u *customerUseCase) SignIn(ctx context.Context, req SignInRequest) (SignIn
 		return SignInResponse{}, err
 	}
 
-	if err := u.session.Set(ctx, fmt.Sprintf("%s:%d", "customer", c.ID), session.Account{
-		ID:    c.ID,
-		Email: c.Email,
-		Name:  c.Name,
-		Type:  userType,
-	}, expiresIn); err != nil {
+	ci := clientinfo.FromContext(ctx)
+	acc := session.Account{
+		ID:         c.ID,
+		Email:      c.Email,
+		Name:       c.Name,
+		Type:       userType,
+		SessionID:  rt.FamilyID,
+		Device:     ci.Device,
+		IPAddress:  ci.IPAddress,
+		UserAgent:  ci.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	if existing, err := u.session.Get(ctx, subject, rt.FamilyID); err == nil {
+		acc.CreatedAt = existing.CreatedAt
+	}
+
+	if err := u.session.Set(ctx, subject, acc, time.Until(rt.ExpiresAt)); err != nil {
 		return SignInResponse{}, err
 	}
 
 	resp := SignInResponse{
-		Token:     idToken,
-		ExpiresAt: expiresAt,
+		Token:                 idToken,
+		ExpiresAt:             expiresAt,
+		RefreshToken:          rt.Token,
+		RefreshTokenExpiresAt: rt.ExpiresAt,
+	}
+
+	return resp, nil
+}
+
+// revokeSessions kills every session of the customer and invalidates every refresh token that has been issued, except the given sessions.
+func (u *customerUseCase) revokeSessions(ctx context.Context, ID int64, exceptSessionIDs ...string) error {
+	subject := fmt.Sprintf("customer:%d", ID)
+
+	if err := u.session.DeleteAll(ctx, subject, exceptSessionIDs...); err != nil {
+		return err
+	}
+
+	if err := u.refreshToken.RevokeAll(ctx, subject, exceptSessionIDs...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSessions implements CustomerUseCase.
+func (u *customerUseCase) GetSessions(ctx context.Context) ([]SessionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accs, err := u.session.List(ctx, fmt.Sprintf("customer:%d", acc.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(accs, func(i, j int) bool {
+		return accs[i].LastSeenAt.After(accs[j].LastSeenAt)
+	})
+
+	resp := make([]SessionResponse, len(accs))
+	for k, a := range accs {
+		resp[k] = SessionResponse{
+			ID:         a.SessionID,
+			Device:     a.Device,
+			IPAddress:  a.IPAddress,
+			UserAgent:  a.UserAgent,
+			Current:    a.SessionID == acc.SessionID,
+			CreatedAt:  a.CreatedAt,
+			LastSeenAt: a.LastSeenAt,
+		}
 	}
 
 	return resp, nil
 }
 
+// RevokeSession implements CustomerUseCase.
+func (u *customerUseCase) RevokeSession(ctx context.Context, req RevokeSessionRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("customer:%d", acc.ID)
+
+	if _, err := u.session.Get(ctx, subject, req.ID); err != nil {
+		return err
+	}
+
+	if err := u.session.Delete(ctx, subject, req.ID); err != nil {
+		return err
+	}
+
+	if err := u.refreshToken.Revoke(ctx, req.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions implements CustomerUseCase.
+func (u *customerUseCase) RevokeOtherSessions(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	return u.revokeSessions(ctx, acc.ID, acc.SessionID)
+}
+
 // SignOut implements CustomerUseCase.
 func (u *customerUseCase) SignOut(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, u.timeout)
@@ -248,8 +592,12 @@ func (u *customerUseCase) SignOut(ctx context.Context) error {
 		return err
 	}
 
-	key := fmt.Sprintf("customer:%d", acc.ID)
-	if err := u.session.Delete(ctx, key); err != nil {
+	subject := fmt.Sprintf("customer:%d", acc.ID)
+	if err := u.session.Delete(ctx, subject, acc.SessionID); err != nil {
+		return err
+	}
+
+	if err := u.refreshToken.Revoke(ctx, acc.SessionID); err != nil {
 		return err
 	}
 
@@ -261,7 +609,12 @@ func (u *customerUseCase) SignUp(ctx context.Context, req SignUpRequest) (SignUp
 	ctx, cancel := context.WithTimeout(ctx, u.timeout)
 	defer cancel()
 
-	_, err := u.customerRepository.FindByEmail(ctx, req.Email, nil)
+	req.Email = u.canonicalEmail(req.Email)
+	if err := u.checkEmailDomain(req.Email); err != nil {
+		return SignUpResponse{}, err
+	}
+
+	_, err := u.findCustomerByEmail(ctx, req.Email, nil)
 	if err == nil {
 		return SignUpResponse{}, errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("customer with email '%s' is already registered", req.Email))
 	}
@@ -270,9 +623,24 @@ func (u *customerUseCase) SignUp(ctx context.Context, req SignUpRequest) (SignUp
 		return SignUpResponse{}, err
 	}
 
+	// the email may be held by a pending change of another customer.
+	if err := u.checkEmailReservation(ctx, req.Email, 0); err != nil {
+		return SignUpResponse{}, err
+	}
+
+	documents, err := u.legalDocumentRepository.FindLatestPublished(ctx, nil)
+	if err != nil {
+		return SignUpResponse{}, err
+	}
+
+	for _, ld := range documents {
+		if isTermsDocumentType(ld.Type) && !req.AcceptTerms {
+			return SignUpResponse{}, errors.New(http.StatusBadRequest, status.CONSENT_REQUIRED, "the terms of service and the privacy policy must be accepted")
+		}
+	}
+
 	now := time.Now()
-	passwordSalt := util.GenerateRandomHEX(32)
-	hashedPassword := util.GenerateSecret(fmt.Sprintf("%s%s", u.cryptoSecret, req.Password), passwordSalt, 256)
+	hashedPassword, passwordSalt := u.hashPassword(req.Password)
 	c := Customer{
 		Name:               req.Name,
 		Email:              req.Email,
@@ -284,17 +652,54 @@ func (u *customerUseCase) SignUp(ctx context.Context, req SignUpRequest) (SignUp
 		UpdatedAt:          now,
 	}
 
-	ID, err := u.customerRepository.Save(ctx, c, nil)
+	tx, err := u.customerRepository.BeginTx(ctx)
+	if err != nil {
+		return SignUpResponse{}, err
+	}
+	defer tx.Rollback()
+
+	ID, err := u.customerRepository.Save(ctx, c, tx)
 	if err != nil {
 		return SignUpResponse{}, err
 	}
 
 	c.ID = ID
 
+	consents, err := u.grantSignUpConsents(ctx, c.ID, documents, req.PromoterMarketing, tx)
+	if err != nil {
+		return SignUpResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignUpResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing up customer")
+	}
+
+	for _, cc := range consents {
+		u.publishConsentUpdated(ctx, cc)
+	}
+
+	linkExpiresAt, err := u.sendVerificationLink(ctx, c)
+	if err != nil {
+		return SignUpResponse{}, err
+	}
+
+	resp := SignUpResponse{
+		VerificationExpiresAt: linkExpiresAt,
+	}
+
+	return resp, nil
+
+}
+
+// sendVerificationLink publishes the verification link of the customer. The previous link of the customer is invalidated.
+func (u *customerUseCase) sendVerificationLink(ctx context.Context, c Customer) (time.Time, error) {
+	now := time.Now()
 	linkExpiresIn := time.Minute * 5
 	linkExpiresAt := now.Add(linkExpiresIn)
 	verificationToken := util.GenerateRandomHEX(32)
 	verificationKey := fmt.Sprintf(verificationKeyPrefix, verificationToken)
+	verificationCustomerKey := fmt.Sprintf(verificationCustomerKeyPrefix, c.ID)
 	verificationLink := fmt.Sprintf("%s%s?token=%s", u.tmuserBaseURL, VerificationURLPath, verificationToken)
 	signUpEvent := SignUpEvent{
 		ID:                 c.ID,
@@ -308,9 +713,23 @@ func (u *customerUseCase) SignUp(ctx context.Context, req SignUpRequest) (SignUp
 
 	signUpEventBuff, _ := json.Marshal(signUpEvent)
 
-	if err := u.cache.Set(ctx, verificationKey, signUpEventBuff, linkExpiresIn).Err(); err != nil {
+	previousToken, err := u.cache.Get(ctx, verificationCustomerKey).Result()
+	if err != nil && err != redis.Nil {
 		u.logger.WithContext(ctx).WithError(err).Error()
-		return SignUpResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing up customer")
+		return time.Time{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while sending customer's verification link")
+	}
+
+	_, err = u.cache.Pipelined(ctx, func(p redis.Pipeliner) error {
+		if previousToken != "" {
+			p.Del(ctx, fmt.Sprintf(verificationKeyPrefix, previousToken))
+		}
+		p.Set(ctx, verificationKey, signUpEventBuff, linkExpiresIn)
+		p.Set(ctx, verificationCustomerKey, verificationToken, linkExpiresIn)
+		return nil
+	})
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return time.Time{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while sending customer's verification link")
 	}
 
 	messageHeader := pubsub.MessageHeaders{
@@ -318,12 +737,56 @@ func (u *customerUseCase) SignUp(ctx context.Context, req SignUpRequest) (SignUp
 	}
 	u.publisher.Publish(ctx, "customer-sign-up", fmt.Sprintf("customer:%d", c.ID), messageHeader, signUpEventBuff)
 
-	resp := SignUpResponse{
-		VerificationExpiresAt: linkExpiresAt,
+	return linkExpiresAt, nil
+}
+
+// ResendVerification implements CustomerUseCase. The limits are applied by the email regardless it is registered or not, so it does not tell whether the email is registered.
+func (u *customerUseCase) ResendVerification(ctx context.Context, req ResendVerificationRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	req.Email = u.canonicalEmail(req.Email)
+
+	cooldownKey := fmt.Sprintf(verificationResendCooldownPrefix, req.Email)
+	ok, err := u.cache.SetNX(ctx, cooldownKey, 1, verificationResendCooldown).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while resending customer's verification link")
+	}
+	if !ok {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "verification link has been sent recently, please try again later")
 	}
 
-	return resp, nil
+	dailyKey := fmt.Sprintf(verificationResendDailyPrefix, req.Email, time.Now().Format("2006-01-02"))
+	count, err := u.cache.Incr(ctx, dailyKey).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while resending customer's verification link")
+	}
+	if count == 1 {
+		u.cache.Expire(ctx, dailyKey, time.Hour*24)
+	}
+	if count > verificationResendDailyCap {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "daily limit of verification link has been reached, please try again tomorrow")
+	}
+
+	c, err := u.findCustomerByEmail(ctx, req.Email, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return nil
+		}
+		return err
+	}
+
+	if c.VerificationStatus != VerificationStatusUnverified {
+		return nil
+	}
 
+	if _, err := u.sendVerificationLink(ctx, c); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // UpdateProfile implements CustomerUseCase.
@@ -341,12 +804,31 @@ func (u *customerUseCase) UpdateProfile(ctx context.Context, req UpdateProfileRe
 		return err
 	}
 
-	c.Name = req.Name
+	changes := applyProfileUpdate(&c, req)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	c.UpdatedAt = now
 
 	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
 		return err
 	}
 
+	profileUpdatedEvent := ProfileUpdatedEvent{
+		ID:        c.ID,
+		Changes:   changes,
+		UpdatedAt: now,
+	}
+
+	profileUpdatedEventBuff, _ := json.Marshal(profileUpdatedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-profile-updated", fmt.Sprintf("customer:%d", c.ID), messageHeader, profileUpdatedEventBuff)
+
 	return nil
 }
 
@@ -375,6 +857,10 @@ func (u *customerUseCase) Verify(ctx context.Context, req VerifyRequest) error {
 		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while verifying user after sign up")
 	}
 
+	if c.DeletedAt != nil {
+		return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+	}
+
 	now := time.Now()
 	c.VerificationStatus = VerficationStatusVerified
 	c.UpdatedAt = now
@@ -383,7 +869,12 @@ func (u *customerUseCase) Verify(ctx context.Context, req VerifyRequest) error {
 		return err
 	}
 
-	if err := u.cache.Del(ctx, key).Err(); err != nil {
+	_, err = u.cache.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, key)
+		p.Del(ctx, fmt.Sprintf(verificationCustomerKeyPrefix, c.ID))
+		return nil
+	})
+	if err != nil {
 		u.logger.WithContext(ctx).WithError(err).Error()
 	}
 
@@ -407,7 +898,17 @@ func (u *customerUseCase) VerifyChangeEmail(ctx context.Context, req ChangeEmail
 	var changeEmailEvent ChangeEmailEvent
 	json.Unmarshal(changeEmailEventBuff, &changeEmailEvent)
 
-	c, err := u.customerRepository.FindByID(ctx, changeEmailEvent.ID, nil)
+	if err := u.checkEmailReservation(ctx, changeEmailEvent.NewEmail, changeEmailEvent.ID); err != nil {
+		return err
+	}
+
+	tx, err := u.customerRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	c, err := u.customerRepository.FindByID(ctx, changeEmailEvent.ID, tx)
 	if err != nil {
 		if errors.MatchStatus(err, status.NOT_FOUND) {
 			return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
@@ -415,33 +916,765 @@ func (u *customerUseCase) VerifyChangeEmail(ctx context.Context, req ChangeEmail
 		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured whil verifying user after sign up")
 	}
 
+	if c.DeletedAt != nil {
+		return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+	}
+
+	// the email may have been registered since the change was requested, the unique index catches the request that races this one.
+	if err := u.checkEmailAvailability(ctx, changeEmailEvent.NewEmail, c.ID, tx); err != nil {
+		return err
+	}
+
 	now := time.Now()
+	existingEmail := c.Email
 	c.Email = changeEmailEvent.NewEmail
 	c.VerificationStatus = VerficationStatusVerified
 	c.UpdatedAt = now
 
-	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+	if err := u.customerRepository.Update(ctx, c.ID, c, tx); err != nil {
 		return err
 	}
 
-	if err := u.cache.Del(ctx, key).Err(); err != nil {
+	if err := tx.Commit(); err != nil {
 		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while verifying user after changing email")
 	}
 
-	return nil
+	u.releaseEmail(ctx, c.Email, c.ID)
+
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangeEmail, &existingEmail, &c.Email)
+
+	if err := u.cache.Del(ctx, key).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+
+	return nil
+}
+
+// lockoutAccount is the account of the customer's email on the lockout.
+func lockoutAccount(email string) string {
+	return fmt.Sprintf("customer:%s", email)
+}
+
+// canonicalEmail returns the form of the email that is stored and looked up.
+func (u *customerUseCase) canonicalEmail(email string) string {
+	return emailaddress.Canonicalize(email, u.emailOptions)
+}
+
+// findCustomerByEmail looks up the customer of the canonical email. With the gmail rules, the gmail address is matched against the stored emails,
+// so the customers that are stored with the dots or a plus suffix before the rules are turned on are still found.
+func (u *customerUseCase) findCustomerByEmail(ctx context.Context, email string, tx *sql.Tx) (Customer, error) {
+	if u.emailOptions.GmailRules && emailaddress.Domain(email) == "gmail.com" {
+		return u.customerRepository.FindByGmailAddress(ctx, email, tx)
+	}
+
+	return u.customerRepository.FindByEmail(ctx, email, tx)
+}
+
+// checkEmailDomain fails when the email belongs to a disposable domain. It is checked on registering an email only, the existing customers are still able to sign in.
+func (u *customerUseCase) checkEmailDomain(email string) error {
+	if u.disposableEmailDomains != nil && u.disposableEmailDomains.Contains(email) {
+		return errors.New(http.StatusUnprocessableEntity, status.UNPROCESSABLE_ENTITY, "email of a disposable provider is not allowed")
+	}
+
+	return nil
+}
+
+// checkEmailAvailability fails when the email has been registered by another customer.
+func (u *customerUseCase) checkEmailAvailability(ctx context.Context, email string, customerID int64, tx *sql.Tx) error {
+	other, err := u.findCustomerByEmail(ctx, email, tx)
+	if err == nil && other.ID != customerID {
+		return errors.New(http.StatusConflict, status.ALREADY_EXIST, fmt.Sprintf("customer with email '%s' is already registered", email))
+	}
+	if err != nil && !errors.MatchStatus(err, status.NOT_FOUND) {
+		return err
+	}
+
+	return nil
+}
+
+// ForgotPassword implements CustomerUseCase. It does not tell whether the email is registered or not, the email is limited before it is looked up.
+func (u *customerUseCase) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	req.Email = u.canonicalEmail(req.Email)
+
+	cooldownKey := fmt.Sprintf(resetPasswordCooldownPrefix, req.Email)
+	ok, err := u.cache.SetNX(ctx, cooldownKey, 1, resetPasswordCooldown).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's reset password")
+	}
+	if !ok {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "reset password link has been requested recently, please try again later")
+	}
+
+	dailyKey := fmt.Sprintf(resetPasswordDailyPrefix, req.Email, time.Now().Format("2006-01-02"))
+	count, err := u.cache.Incr(ctx, dailyKey).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's reset password")
+	}
+	if count == 1 {
+		u.cache.Expire(ctx, dailyKey, time.Hour*24)
+	}
+	if count > resetPasswordDailyCap {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "daily limit of reset password link has been reached, please try again tomorrow")
+	}
+
+	c, err := u.findCustomerByEmail(ctx, req.Email, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return nil
+		}
+		return err
+	}
+
+	return u.sendResetPasswordLink(ctx, c)
+}
+
+// sendResetPasswordLink publishes a single-use reset password link. The previous link of the customer is invalidated.
+func (u *customerUseCase) sendResetPasswordLink(ctx context.Context, c Customer) error {
+	now := time.Now()
+	linkExpiresIn := time.Minute * 15
+	linkExpiresAt := now.Add(linkExpiresIn)
+	resetPasswordToken := util.GenerateRandomHEX(32)
+	resetPasswordKey := fmt.Sprintf(resetPasswordKeyPrefix, resetPasswordToken)
+	resetPasswordCustomerKey := fmt.Sprintf(resetPasswordCustomerKeyPrefix, c.ID)
+	resetPasswordLink := fmt.Sprintf("%s%s?token=%s", u.tmuserBaseURL, ResetPasswordURLPath, resetPasswordToken)
+	resetPasswordEvent := ResetPasswordEvent{
+		ID:                c.ID,
+		Name:              c.Name,
+		Email:             c.Email,
+		ResetPasswordLink: resetPasswordLink,
+		ExpiresAt:         linkExpiresAt,
+	}
+
+	resetPasswordEventBuff, _ := json.Marshal(resetPasswordEvent)
+
+	previousToken, err := u.cache.Get(ctx, resetPasswordCustomerKey).Result()
+	if err != nil && err != redis.Nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's reset password")
+	}
+
+	_, err = u.cache.Pipelined(ctx, func(p redis.Pipeliner) error {
+		if previousToken != "" {
+			p.Del(ctx, fmt.Sprintf(resetPasswordKeyPrefix, previousToken))
+		}
+		p.Set(ctx, resetPasswordKey, resetPasswordEventBuff, linkExpiresIn)
+		p.Set(ctx, resetPasswordCustomerKey, resetPasswordToken, linkExpiresIn)
+		return nil
+	})
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's reset password")
+	}
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-reset-password", fmt.Sprintf("customer:%d", c.ID), messageHeader, resetPasswordEventBuff)
+
+	return nil
+}
+
+// ResetPassword implements CustomerUseCase.
+func (u *customerUseCase) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	key := fmt.Sprintf(resetPasswordKeyPrefix, req.Token)
+
+	// the identities are checked before the token is consumed, so the customer is able to retry with another password.
+	resetPasswordEventBuff, err := u.cache.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "invalid reset password token")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while resetting customer's password")
+	}
+
+	var resetPasswordEvent ResetPasswordEvent
+	json.Unmarshal(resetPasswordEventBuff, &resetPasswordEvent)
+
+	if reasons := publicValidator.CheckPassword(req.NewPassword, resetPasswordEvent.Email, resetPasswordEvent.Name); len(reasons) > 0 {
+		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, fmt.Sprintf("invalid 'NewPassword' (%s)", strings.Join(reasons, ", ")))
+	}
+
+	resetPasswordEventBuff, err = u.cache.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "invalid reset password token")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while resetting customer's password")
+	}
+
+	json.Unmarshal(resetPasswordEventBuff, &resetPasswordEvent)
+
+	c, err := u.customerRepository.FindByID(ctx, resetPasswordEvent.ID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+		}
+		return err
+	}
+
+	if c.DeletedAt != nil {
+		return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+	}
+
+	c.Password, c.PasswordSalt = u.hashPassword(req.NewPassword)
+	c.PasswordResetRequired = false
+	c.UpdatedAt = time.Now()
+
+	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+		return err
+	}
+
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangePasswordReset, nil, nil)
+
+	if err := u.cache.Del(ctx, fmt.Sprintf(resetPasswordCustomerKeyPrefix, c.ID)).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+
+	if err := u.revokeSessions(ctx, c.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteAccount implements CustomerUseCase. The customer is marked as deleted immediately, the personal data is erased by the erasure job after the grace period.
+func (u *customerUseCase) DeleteAccount(ctx context.Context, req DeleteAccountRequest) (DeleteAccountResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return DeleteAccountResponse{}, err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return DeleteAccountResponse{}, err
+	}
+
+	if c.DeletedAt != nil {
+		return DeleteAccountResponse{}, errors.New(http.StatusNotFound, status.NOT_FOUND, "customer's account has been deleted")
+	}
+
+	if !u.matchPassword(c, req.Password) {
+		return DeleteAccountResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid customer's password")
+	}
+
+	now := time.Now()
+	c.MemberStatus = MemberStatusDeleted
+	c.DeletedAt = &now
+	c.UpdatedAt = now
+
+	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+		return DeleteAccountResponse{}, err
+	}
+
+	u.invalidateMemberStatus(ctx, c.ID)
+
+	if err := u.revokeSessions(ctx, c.ID); err != nil {
+		return DeleteAccountResponse{}, err
+	}
+
+	customerDeletedEvent := CustomerDeletedEvent{
+		ID:                 c.ID,
+		Name:               c.Name,
+		Email:              c.Email,
+		DeletedAt:          now,
+		ErasureScheduledAt: now.Add(u.deletionGracePeriod),
+	}
+
+	customerDeletedEventBuff, _ := json.Marshal(customerDeletedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-deleted", fmt.Sprintf("customer:%d", c.ID), messageHeader, customerDeletedEventBuff)
+
+	resp := DeleteAccountResponse{
+		DeletedAt:          customerDeletedEvent.DeletedAt,
+		ErasureScheduledAt: customerDeletedEvent.ErasureScheduledAt,
+	}
+
+	return resp, nil
+}
+
+// Deactivate implements CustomerUseCase. Every session of the customer is revoked, the customer is able to reactivate the account through an emailed link.
+func (u *customerUseCase) Deactivate(ctx context.Context, req DeactivateRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	if !u.matchPassword(c, req.Password) {
+		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid customer's password")
+	}
+
+	c.MemberStatus = MemberStatusInactive
+	c.UpdatedAt = time.Now()
+
+	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+		return err
+	}
+
+	u.invalidateMemberStatus(ctx, c.ID)
+
+	if err := u.revokeSessions(ctx, c.ID); err != nil {
+		return err
+	}
+
+	customerDeactivatedEvent := CustomerDeactivatedEvent{
+		ID:            c.ID,
+		Name:          c.Name,
+		Email:         c.Email,
+		DeactivatedAt: c.UpdatedAt,
+	}
+
+	customerDeactivatedEventBuff, _ := json.Marshal(customerDeactivatedEvent)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-deactivated", fmt.Sprintf("customer:%d", c.ID), messageHeader, customerDeactivatedEventBuff)
+
+	return nil
+}
+
+// RequestReactivation implements CustomerUseCase. It does not tell whether the email belongs to a deactivated customer.
+func (u *customerUseCase) RequestReactivation(ctx context.Context, req RequestReactivationRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	req.Email = u.canonicalEmail(req.Email)
+
+	ok, err := u.cache.SetNX(ctx, fmt.Sprintf(reactivationCooldownPrefix, req.Email), 1, reactivationCooldown).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's reactivation")
+	}
+	if !ok {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, "reactivation link has been requested recently, please try again later")
+	}
+
+	c, err := u.findCustomerByEmail(ctx, req.Email, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return nil
+		}
+		return err
+	}
+
+	if c.MemberStatus != MemberStatusInactive {
+		return nil
+	}
+
+	now := time.Now()
+	reactivationToken := util.GenerateRandomHEX(32)
+	reactivationEvent := ReactivationEvent{
+		ID:               c.ID,
+		Name:             c.Name,
+		Email:            c.Email,
+		ReactivationLink: fmt.Sprintf("%s%s?token=%s", u.tmuserBaseURL, ReactivationURLPath, reactivationToken),
+		ExpiresAt:        now.Add(reactivationExpiresIn),
+	}
+
+	reactivationEventBuff, _ := json.Marshal(reactivationEvent)
+
+	if err := u.cache.Set(ctx, fmt.Sprintf(reactivationKeyPrefix, reactivationToken), reactivationEventBuff, reactivationExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while requesting customer's reactivation")
+	}
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": u.appName,
+	}
+	u.publisher.Publish(ctx, "customer-reactivation", fmt.Sprintf("customer:%d", c.ID), messageHeader, reactivationEventBuff)
+
+	return nil
+}
+
+// VerifyReactivation implements CustomerUseCase.
+func (u *customerUseCase) VerifyReactivation(ctx context.Context, req ReactivationVerificationRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	key := fmt.Sprintf(reactivationKeyPrefix, req.Token)
+	reactivationEventBuff, err := u.cache.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "invalid reactivation token")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while reactivating customer")
+	}
+
+	var reactivationEvent ReactivationEvent
+	json.Unmarshal(reactivationEventBuff, &reactivationEvent)
+
+	c, err := u.customerRepository.FindByID(ctx, reactivationEvent.ID, nil)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+		}
+		return err
+	}
+
+	if c.DeletedAt != nil || c.MemberStatus != MemberStatusInactive {
+		return errors.New(http.StatusForbidden, status.FORBIDDEN, "token is not match any customer data")
+	}
+
+	c.MemberStatus = MemberStatusActive
+	c.UpdatedAt = time.Now()
+
+	if err := u.customerRepository.Update(ctx, c.ID, c, nil); err != nil {
+		return err
+	}
+
+	u.invalidateMemberStatus(ctx, c.ID)
+
+	return nil
+}
+
+// hashPassword returns the hashed password and its salt. The salt is embedded in the hashed password, so the returned salt is always empty.
+func (u *customerUseCase) hashPassword(plain string) (string, string) {
+	return u.passwordHasher.Hash(plain), ""
+}
+
+// matchPassword checks the plain password against the customer's hashed password.
+func (u *customerUseCase) matchPassword(c Customer, plain string) bool {
+	match, _ := u.passwordHasher.Verify(plain, c.Password, c.PasswordSalt)
+
+	return match
+}
+
+// EnrolTwoFactor implements CustomerUseCase.
+func (u *customerUseCase) EnrolTwoFactor(ctx context.Context) (EnrolTwoFactorResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return EnrolTwoFactorResponse{}, err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return EnrolTwoFactorResponse{}, err
+	}
+
+	if c.TwoFactorEnabled {
+		return EnrolTwoFactorResponse{}, errors.New(http.StatusConflict, status.ALREADY_EXIST, "customer's two factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return EnrolTwoFactorResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while enrolling customer's two factor authentication")
+	}
+
+	encryptedSecret, err := util.Encrypt(secret, u.cryptoSecret)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return EnrolTwoFactorResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while enrolling customer's two factor authentication")
+	}
+
+	enrolmentExpiresIn := time.Minute * 10
+	enrolmentKey := fmt.Sprintf(twoFactorEnrolmentKeyPrefix, c.ID)
+	if err := u.cache.Set(ctx, enrolmentKey, encryptedSecret, enrolmentExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return EnrolTwoFactorResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while enrolling customer's two factor authentication")
+	}
+
+	resp := EnrolTwoFactorResponse{
+		Secret:    secret,
+		URI:       totp.URI(twoFactorIssuer, c.Email, secret),
+		ExpiresAt: time.Now().Add(enrolmentExpiresIn),
+	}
+
+	return resp, nil
+}
+
+// ConfirmTwoFactor implements CustomerUseCase.
+func (u *customerUseCase) ConfirmTwoFactor(ctx context.Context, req ConfirmTwoFactorRequest) (ConfirmTwoFactorResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return ConfirmTwoFactorResponse{}, err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return ConfirmTwoFactorResponse{}, err
+	}
+
+	if c.TwoFactorEnabled {
+		return ConfirmTwoFactorResponse{}, errors.New(http.StatusConflict, status.ALREADY_EXIST, "customer's two factor authentication is already enabled")
+	}
+
+	enrolmentKey := fmt.Sprintf(twoFactorEnrolmentKeyPrefix, c.ID)
+	encryptedSecret, err := u.cache.Get(ctx, enrolmentKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return ConfirmTwoFactorResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "customer's two factor enrolment is not found or expired")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return ConfirmTwoFactorResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while confirming customer's two factor authentication")
+	}
+
+	c.TwoFactorSecret = encryptedSecret
+
+	if !u.verifyTOTP(ctx, c, req.Code) {
+		return ConfirmTwoFactorResponse{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid two factor authentication code")
+	}
+
+	recoveryCodes := make([]string, twoFactorRecoveryCodeCount)
+	hashedRecoveryCodes := make(RecoveryCodes, twoFactorRecoveryCodeCount)
+	for k := range recoveryCodes {
+		code := util.GenerateRandomHEX(5)
+		recoveryCodes[k] = fmt.Sprintf("%s-%s", code[:5], code[5:])
+		hashedRecoveryCodes[k] = u.hashRecoveryCode(recoveryCodes[k])
+	}
+
+	enabled, err := u.customerRepository.EnableTwoFactor(ctx, c.ID, encryptedSecret, hashedRecoveryCodes, nil)
+	if err != nil {
+		return ConfirmTwoFactorResponse{}, err
+	}
+	if !enabled {
+		return ConfirmTwoFactorResponse{}, errors.New(http.StatusConflict, status.ALREADY_EXIST, "customer's two factor authentication is already enabled")
+	}
+
+	if err := u.cache.Del(ctx, enrolmentKey).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangeTwoFactorEnabled, nil, nil)
+
+	resp := ConfirmTwoFactorResponse{
+		RecoveryCodes: recoveryCodes,
+	}
+
+	return resp, nil
+}
+
+// DisableTwoFactor implements CustomerUseCase.
+func (u *customerUseCase) DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	acc, err := session.GetAccountFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, acc.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	if !u.matchPassword(c, req.Password) {
+		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid customer's password")
+	}
+
+	if !c.TwoFactorEnabled {
+		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "customer's two factor authentication is not enabled")
+	}
+
+	disabled, err := u.customerRepository.DisableTwoFactor(ctx, c.ID, nil)
+	if err != nil {
+		return err
+	}
+	if !disabled {
+		return errors.New(http.StatusBadRequest, status.BAD_REQUEST, "customer's two factor authentication is not enabled")
+	}
+
+	u.recordSensitiveChange(ctx, c.ID, SensitiveChangeTwoFactorDisabled, nil, nil)
+
+	return nil
+}
+
+// SignInTwoFactor implements CustomerUseCase.
+func (u *customerUseCase) SignInTwoFactor(ctx context.Context, req SignInTwoFactorRequest) (SignInResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
+
+	challengeKey := fmt.Sprintf(twoFactorChallengeKeyPrefix, req.ChallengeToken)
+	attemptsKey := fmt.Sprintf(twoFactorChallengeAttemptsPrefix, req.ChallengeToken)
+
+	ID, err := u.cache.Get(ctx, challengeKey).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired two factor challenge token")
+		}
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	attempts, err := u.cache.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+	u.cache.Expire(ctx, attemptsKey, twoFactorChallengeExpiresIn)
+
+	if attempts > twoFactorChallengeMaxAttempts {
+		u.cache.Del(ctx, challengeKey)
+		return SignInResponse{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "too many invalid two factor authentication codes, please sign in again")
+	}
+
+	c, err := u.customerRepository.FindByID(ctx, ID, nil)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	// the codes are counted by the account and the source ip as well, because a new challenge is opened on every sign in.
+	account := lockoutAccount(u.canonicalEmail(c.Email))
+	ip := clientinfo.FromContext(ctx).IPAddress
+
+	if err := u.lockout.Check(ctx, account, ip); err != nil {
+		return SignInResponse{}, err
+	}
+
+	if !u.verifyTOTP(ctx, c, req.Code) {
+		ok, err := u.consumeRecoveryCode(ctx, c, req.Code)
+		if err != nil {
+			return SignInResponse{}, err
+		}
+		if !ok {
+			lockedUntil, err := u.lockout.Fail(ctx, account, ip)
+			if err != nil {
+				return SignInResponse{}, err
+			}
+
+			if !lockedUntil.IsZero() {
+				u.cache.Del(ctx, challengeKey)
+				u.publishAccountLocked(ctx, c, lockedUntil)
+				return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodTwoFactor, LoginFailureAccountLocked, errors.New(http.StatusLocked, status.ACCOUNT_LOCKED, "account is temporarily locked due to too many failed sign in attempts"))
+			}
+
+			return SignInResponse{}, u.loginFailed(ctx, c, LoginMethodTwoFactor, LoginFailureInvalidCode, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "invalid two factor authentication code"))
+		}
+	}
+
+	if err := u.lockout.Succeed(ctx, account); err != nil {
+		return SignInResponse{}, err
+	}
+
+	_, err = u.cache.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, challengeKey)
+		p.Del(ctx, attemptsKey)
+		return nil
+	})
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+	}
+
+	return u.completeSignIn(ctx, c, LoginMethodTwoFactor)
+}
+
+// challengeTwoFactor holds the sign in until the customer passes the two factor authentication.
+func (u *customerUseCase) challengeTwoFactor(ctx context.Context, c Customer, method string) (SignInResponse, error) {
+	challengeToken := util.GenerateRandomHEX(32)
+	challengeExpiresAt := time.Now().Add(twoFactorChallengeExpiresIn)
+
+	if err := u.cache.Set(ctx, fmt.Sprintf(twoFactorChallengeKeyPrefix, challengeToken), c.ID, twoFactorChallengeExpiresIn).Err(); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return SignInResponse{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in customer")
+	}
+
+	u.recordLogin(ctx, c, method, LoginOutcomeTwoFactorRequired, nil)
+
+	resp := SignInResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     challengeToken,
+		ChallengeExpiresAt: &challengeExpiresAt,
+	}
+
+	return resp, nil
+}
+
+// verifyTOTP checks the code against the customer's secret. A code is accepted only once to prevent it from being replayed.
+func (u *customerUseCase) verifyTOTP(ctx context.Context, c Customer, code string) bool {
+	secret, err := util.Decrypt(c.TwoFactorSecret, u.cryptoSecret)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return false
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), 1)
+	if !ok {
+		return false
+	}
+
+	usedCodeKey := fmt.Sprintf(twoFactorUsedCodeKeyPrefix, c.ID, step)
+	ok, err = u.cache.SetNX(ctx, usedCodeKey, 1, time.Second*totp.Period*3).Result()
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error()
+		return false
+	}
+
+	return ok
+}
+
+// consumeRecoveryCode removes the matched recovery code from the customer, so it can not be used anymore. The code is removed by the database,
+// otherwise the concurrent attempts with the same code would all pass.
+func (u *customerUseCase) consumeRecoveryCode(ctx context.Context, c Customer, code string) (bool, error) {
+	hashedCode := u.hashRecoveryCode(code)
+
+	return u.customerRepository.ConsumeRecoveryCode(ctx, c.ID, hashedCode, nil)
+}
+
+func (u *customerUseCase) hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s%s", u.cryptoSecret, strings.ToLower(strings.TrimSpace(code)))))
+
+	return hex.EncodeToString(sum[:])
 }
 
 func NewCustomerUseCase(props CustomerUseCaseProperty) CustomerUseCase {
 	return &customerUseCase{
-		appName:            props.AppName,
-		logger:             props.Logger,
-		timeout:            props.Timeout,
-		tmuserBaseURL:      props.TMUserBaseURL,
-		cryptoSecret:       props.CryptoSecret,
-		jsonWebToken:       props.JSONWebToken,
-		session:            props.Session,
-		cache:              props.Cache,
-		publisher:          props.Publisher,
-		customerRepository: props.CustomerRepository,
+		appName:                           props.AppName,
+		logger:                            props.Logger,
+		timeout:                           props.Timeout,
+		tmuserBaseURL:                     props.TMUserBaseURL,
+		cryptoSecret:                      props.CryptoSecret,
+		jsonWebToken:                      props.JSONWebToken,
+		session:                           props.Session,
+		refreshToken:                      props.RefreshToken,
+		lockout:                           props.Lockout,
+		passwordHasher:                    props.PasswordHasher,
+		deletionGracePeriod:               props.DeletionGracePeriod,
+		oidcProviders:                     props.OIDCProviders,
+		smsSender:                         props.SMSSender,
+		passkey:                           props.Passkey,
+		emailOptions:                      props.EmailOptions,
+		disposableEmailDomains:            props.DisposableEmailDomains,
+		objectStorage:                     props.ObjectStorage,
+		cache:                             props.Cache,
+		publisher:                         props.Publisher,
+		customerRepository:                props.CustomerRepository,
+		customerIdentityRepository:        props.CustomerIdentityRepository,
+		customerSensitiveChangeRepository: props.CustomerSensitiveChangeRepository,
+		customerLoginEventRepository:      props.CustomerLoginEventRepository,
+		customerConsentRepository:         props.CustomerConsentRepository,
+		legalDocumentRepository:           props.LegalDocumentRepository,
 	}
 }
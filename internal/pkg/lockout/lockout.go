@@ -0,0 +1,176 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+var (
+	accountFailureKeyPrefix string = "lockout:failure:account:%s"
+	accountDelayKeyPrefix   string = "lockout:delay:account:%s"
+	accountLockKeyPrefix    string = "lockout:lock:account:%s"
+	ipFailureKeyPrefix      string = "lockout:failure:ip:%s"
+	ipLockKeyPrefix         string = "lockout:lock:ip:%s"
+)
+
+// Config is the policy of the lockout.
+type Config struct {
+	// MaxAttempts is the number of failures within the window that locks the account.
+	MaxAttempts int
+	// IPMaxAttempts is the number of failures within the window that blocks the source ip.
+	IPMaxAttempts int
+	// DelayAfter is the number of failures within the window before the progressive delay is applied.
+	DelayAfter int
+	// BaseDelay is the first delay, it is doubled on every next failure until it reaches MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Duration is how long the account or the source ip stays locked.
+	Duration time.Duration
+	// Window is the period of failures counting, it is extended on every failure.
+	Window time.Duration
+}
+
+// Lockout is a collection of behavior to throttle the failed sign in attempts by account and by source ip.
+type Lockout interface {
+	// Check returns an error if the account or the source ip is not allowed to attempt to sign in.
+	Check(ctx context.Context, account, ip string) error
+	// Fail records a failed attempt. It returns the time until the account is locked if the attempt locks the account.
+	Fail(ctx context.Context, account, ip string) (lockedUntil time.Time, err error)
+	// Succeed clears the failures of the account.
+	Succeed(ctx context.Context, account string) error
+	// Unlock releases the lock and clears the failures of the account.
+	Unlock(ctx context.Context, account string) error
+}
+
+type redisLockout struct {
+	l   *logrus.Logger
+	r   redis.UniversalClient
+	cfg Config
+}
+
+// Check implements Lockout.
+func (lo *redisLockout) Check(ctx context.Context, account, ip string) error {
+	var accountLockTTL, accountDelayTTL, ipLockTTL *redis.DurationCmd
+
+	_, err := lo.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		accountLockTTL = p.TTL(ctx, fmt.Sprintf(accountLockKeyPrefix, account))
+		accountDelayTTL = p.PTTL(ctx, fmt.Sprintf(accountDelayKeyPrefix, account))
+		ipLockTTL = p.TTL(ctx, fmt.Sprintf(ipLockKeyPrefix, ip))
+		return nil
+	})
+	if err != nil {
+		lo.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	if ttl := accountLockTTL.Val(); ttl > 0 {
+		return errors.New(http.StatusLocked, status.ACCOUNT_LOCKED, fmt.Sprintf("account is temporarily locked due to too many failed sign in attempts, please try again in %s", ttl.Round(time.Second)))
+	}
+
+	if ttl := ipLockTTL.Val(); ttl > 0 {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, fmt.Sprintf("too many failed sign in attempts, please try again in %s", ttl.Round(time.Second)))
+	}
+
+	if ttl := accountDelayTTL.Val(); ttl > 0 {
+		return errors.New(http.StatusTooManyRequests, status.TOO_MANY_REQUESTS, fmt.Sprintf("too many failed sign in attempts, please try again in %s", ttl.Round(time.Second)))
+	}
+
+	return nil
+}
+
+// Fail implements Lockout.
+func (lo *redisLockout) Fail(ctx context.Context, account, ip string) (time.Time, error) {
+	accountFailureKey := fmt.Sprintf(accountFailureKeyPrefix, account)
+	ipFailureKey := fmt.Sprintf(ipFailureKeyPrefix, ip)
+
+	var accountFailures, ipFailures *redis.IntCmd
+
+	_, err := lo.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		accountFailures = p.Incr(ctx, accountFailureKey)
+		p.Expire(ctx, accountFailureKey, lo.cfg.Window)
+		ipFailures = p.Incr(ctx, ipFailureKey)
+		p.Expire(ctx, ipFailureKey, lo.cfg.Window)
+		return nil
+	})
+	if err != nil {
+		lo.l.WithContext(ctx).WithError(err).Error()
+		return time.Time{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	if ipFailures.Val() >= int64(lo.cfg.IPMaxAttempts) {
+		if err := lo.r.Set(ctx, fmt.Sprintf(ipLockKeyPrefix, ip), ipFailures.Val(), lo.cfg.Duration).Err(); err != nil {
+			lo.l.WithContext(ctx).WithError(err).Error()
+		}
+		lo.r.Del(ctx, ipFailureKey)
+	}
+
+	if accountFailures.Val() >= int64(lo.cfg.MaxAttempts) {
+		if err := lo.r.Set(ctx, fmt.Sprintf(accountLockKeyPrefix, account), accountFailures.Val(), lo.cfg.Duration).Err(); err != nil {
+			lo.l.WithContext(ctx).WithError(err).Error()
+			return time.Time{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+		}
+		lo.r.Del(ctx, accountFailureKey)
+
+		return time.Now().Add(lo.cfg.Duration), nil
+	}
+
+	if exceeded := accountFailures.Val() - int64(lo.cfg.DelayAfter); exceeded > 0 {
+		delay := time.Duration(float64(lo.cfg.BaseDelay) * math.Pow(2, float64(exceeded-1)))
+		if delay > lo.cfg.MaxDelay {
+			delay = lo.cfg.MaxDelay
+		}
+
+		if err := lo.r.Set(ctx, fmt.Sprintf(accountDelayKeyPrefix, account), accountFailures.Val(), delay).Err(); err != nil {
+			lo.l.WithContext(ctx).WithError(err).Error()
+		}
+	}
+
+	return time.Time{}, nil
+}
+
+// Succeed implements Lockout.
+func (lo *redisLockout) Succeed(ctx context.Context, account string) error {
+	_, err := lo.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, fmt.Sprintf(accountFailureKeyPrefix, account))
+		p.Del(ctx, fmt.Sprintf(accountDelayKeyPrefix, account))
+		return nil
+	})
+	if err != nil {
+		lo.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	return nil
+}
+
+// Unlock implements Lockout.
+func (lo *redisLockout) Unlock(ctx context.Context, account string) error {
+	_, err := lo.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, fmt.Sprintf(accountLockKeyPrefix, account))
+		p.Del(ctx, fmt.Sprintf(accountFailureKeyPrefix, account))
+		p.Del(ctx, fmt.Sprintf(accountDelayKeyPrefix, account))
+		return nil
+	})
+	if err != nil {
+		lo.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	return nil
+}
+
+func NewRedisLockout(l *logrus.Logger, r redis.UniversalClient, cfg Config) Lockout {
+	return &redisLockout{
+		l:   l,
+		r:   r,
+		cfg: cfg,
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+)
+
+// KeyByAccount identifies the requester by the signed in account. It must be chained after the session middleware.
+func KeyByAccount(r *http.Request) string {
+	acc, err := session.GetAccountFromCtx(r.Context())
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", acc.Type, acc.ID)
+}
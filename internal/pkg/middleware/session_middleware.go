@@ -4,13 +4,18 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/jwt"
 	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
 	"github.com/tsel-ticketmaster/tm-user/pkg/response"
 	"github.com/tsel-ticketmaster/tm-user/pkg/status"
 )
 
+// lastSeenInterval is the minimum interval to refresh the last seen time of a customer's session.
+const lastSeenInterval = time.Minute
+
 type AdminSession struct {
 	jsonWebToken *jwt.JSONWebToken
 	sess         session.Session
@@ -49,7 +54,7 @@ func (s *AdminSession) Verify(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		acc, err := s.sess.Get(ctx, claim.Subject)
+		acc, err := s.sess.Get(ctx, claim.Subject, claim.Id)
 		if err != nil {
 			respondUnauthorized(w, err.Error())
 			return
@@ -74,15 +79,22 @@ func respondUnauthorized(w http.ResponseWriter, message string) {
 	})
 }
 
+// MemberStatusChecker tells whether the customer is still allowed to use the existing sessions.
+type MemberStatusChecker interface {
+	IsActive(ctx context.Context, ID int64) (bool, error)
+}
+
 type CustomerSession struct {
 	jsonWebToken *jwt.JSONWebToken
 	sess         session.Session
+	memberStatus MemberStatusChecker
 }
 
-func NewCustomerSessionMiddleware(jsonWebToken *jwt.JSONWebToken, sess session.Session) *CustomerSession {
+func NewCustomerSessionMiddleware(jsonWebToken *jwt.JSONWebToken, sess session.Session, memberStatus MemberStatusChecker) *CustomerSession {
 	return &CustomerSession{
 		jsonWebToken: jsonWebToken,
 		sess:         sess,
+		memberStatus: memberStatus,
 	}
 }
 
@@ -112,7 +124,7 @@ func (s *CustomerSession) Verify(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		acc, err := s.sess.Get(ctx, claim.Subject)
+		acc, err := s.sess.Get(ctx, claim.Subject, claim.Id)
 		if err != nil {
 			respondUnauthorized(w, err.Error())
 			return
@@ -123,6 +135,26 @@ func (s *CustomerSession) Verify(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		active, err := s.memberStatus.IsActive(ctx, acc.ID)
+		if err != nil {
+			ae := errors.Destruct(err)
+			response.JSON(w, ae.HTTPStatusCode, response.RESTEnvelope{
+				Status:  ae.Status,
+				Message: ae.Message,
+			})
+			return
+		}
+
+		if !active {
+			s.sess.DeleteAll(ctx, claim.Subject)
+			respondUnauthorized(w, "customer's account is not active")
+			return
+		}
+
+		if time.Since(acc.LastSeenAt) > lastSeenInterval {
+			s.sess.Touch(ctx, claim.Subject, claim.Id)
+		}
+
 		ctx = context.WithValue(ctx, session.AccountContextKey{}, acc)
 		r = r.WithContext(ctx)
 
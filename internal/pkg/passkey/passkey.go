@@ -0,0 +1,261 @@
+package passkey
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
+)
+
+var (
+	registrationKeyPrefix string = "passkey:registration:subject:%s"
+	signInKeyPrefix       string = "passkey:signin:%s:token:%s"
+)
+
+// Credential is the passkey of the subject, e.g. customer:1 or admin:1.
+type Credential struct {
+	ID           int64
+	Subject      string
+	CredentialID []byte
+	PublicKey    []byte
+	SignCount    uint32
+	AAGUID       []byte
+	Transports   []string
+	Name         string
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+// SignInChallenge is the challenge of the assertion ceremony. The token is sent back along with the assertion.
+type SignInChallenge struct {
+	Token     string
+	Options   webauthn.RequestOptions
+	ExpiresAt time.Time
+}
+
+// Passkey is a collection of behavior to register the passkeys of the subjects and sign them in. The subject has the same format as the session's subject.
+type Passkey interface {
+	// BeginRegistration stores the challenge of the subject and returns the options of the registration ceremony.
+	BeginRegistration(ctx context.Context, subject string, user webauthn.User) (webauthn.CreationOptions, error)
+	// FinishRegistration verifies the response against the stored challenge and saves the credential.
+	FinishRegistration(ctx context.Context, subject, name string, resp webauthn.AttestationResponse) (Credential, error)
+	// BeginSignIn stores the challenge for the type of subject, e.g. customer, and returns the options of the assertion ceremony.
+	BeginSignIn(ctx context.Context, subjectType string) (SignInChallenge, error)
+	// FinishSignIn verifies the assertion against the stored challenge and returns the credential, its subject is the signed in subject.
+	FinishSignIn(ctx context.Context, subjectType, token string, resp webauthn.AssertionResponse) (Credential, error)
+	List(ctx context.Context, subject string) ([]Credential, error)
+	Rename(ctx context.Context, subject string, ID int64, name string) error
+	Delete(ctx context.Context, subject string, ID int64) error
+	DeleteAll(ctx context.Context, subject string) error
+}
+
+type passkey struct {
+	l      *logrus.Logger
+	r      redis.UniversalClient
+	rp     *webauthn.RelyingParty
+	repo   *credentialRepository
+	secret string
+}
+
+// userHandle is the id of the subject on the authenticator. It is derived from the subject, so it does not reveal the subject.
+func (p *passkey) userHandle(subject string) []byte {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write([]byte(subject))
+
+	return mac.Sum(nil)
+}
+
+// BeginRegistration implements Passkey.
+func (p *passkey) BeginRegistration(ctx context.Context, subject string, user webauthn.User) (webauthn.CreationOptions, error) {
+	existing, err := p.repo.findBySubject(ctx, subject)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	challenge, err := webauthn.GenerateChallenge()
+	if err != nil {
+		p.l.WithContext(ctx).WithError(err).Error()
+		return webauthn.CreationOptions{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while registering passkey")
+	}
+
+	if err := p.r.Set(ctx, fmt.Sprintf(registrationKeyPrefix, subject), challenge, p.rp.Timeout()).Err(); err != nil {
+		p.l.WithContext(ctx).WithError(err).Error()
+		return webauthn.CreationOptions{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while registering passkey")
+	}
+
+	user.ID = p.userHandle(subject)
+
+	return p.rp.CreationOptions(user, challenge, toWebAuthn(existing)), nil
+}
+
+// FinishRegistration implements Passkey.
+func (p *passkey) FinishRegistration(ctx context.Context, subject, name string, resp webauthn.AttestationResponse) (Credential, error) {
+	challenge, err := p.r.GetDel(ctx, fmt.Sprintf(registrationKeyPrefix, subject)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return Credential{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, "passkey registration is not found or expired")
+		}
+		p.l.WithContext(ctx).WithError(err).Error()
+		return Credential{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while registering passkey")
+	}
+
+	cred, err := p.rp.VerifyRegistration(resp, challenge)
+	if err != nil {
+		return Credential{}, errors.New(http.StatusBadRequest, status.BAD_REQUEST, err.Error())
+	}
+
+	now := time.Now()
+	c := Credential{
+		Subject:      subject,
+		CredentialID: cred.ID,
+		PublicKey:    cred.PublicKey,
+		SignCount:    cred.SignCount,
+		AAGUID:       cred.AAGUID,
+		Transports:   cred.Transports,
+		Name:         name,
+		CreatedAt:    now,
+	}
+
+	ID, err := p.repo.save(ctx, c)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	c.ID = ID
+
+	return c, nil
+}
+
+// BeginSignIn implements Passkey. The credentials are not listed, so the challenge does not reveal whether an account exists.
+func (p *passkey) BeginSignIn(ctx context.Context, subjectType string) (SignInChallenge, error) {
+	challenge, err := webauthn.GenerateChallenge()
+	if err != nil {
+		p.l.WithContext(ctx).WithError(err).Error()
+		return SignInChallenge{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in with passkey")
+	}
+
+	token := util.GenerateRandomHEX(32)
+
+	if err := p.r.Set(ctx, fmt.Sprintf(signInKeyPrefix, subjectType, token), challenge, p.rp.Timeout()).Err(); err != nil {
+		p.l.WithContext(ctx).WithError(err).Error()
+		return SignInChallenge{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in with passkey")
+	}
+
+	sc := SignInChallenge{
+		Token:     token,
+		Options:   p.rp.RequestOptions(challenge, nil),
+		ExpiresAt: time.Now().Add(p.rp.Timeout()),
+	}
+
+	return sc, nil
+}
+
+// FinishSignIn implements Passkey.
+func (p *passkey) FinishSignIn(ctx context.Context, subjectType, token string, resp webauthn.AssertionResponse) (Credential, error) {
+	challenge, err := p.r.GetDel(ctx, fmt.Sprintf(signInKeyPrefix, subjectType, token)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return Credential{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid or expired passkey challenge")
+		}
+		p.l.WithContext(ctx).WithError(err).Error()
+		return Credential{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occured while signing in with passkey")
+	}
+
+	credentialID, err := resp.CredentialID()
+	if err != nil {
+		return Credential{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	c, err := p.repo.findByCredentialID(ctx, credentialID)
+	if err != nil {
+		if errors.MatchStatus(err, status.NOT_FOUND) {
+			return Credential{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+		}
+		return Credential{}, err
+	}
+
+	// the passkey of an administrator must not sign in to the customer's app and vice versa.
+	if !strings.HasPrefix(c.Subject, subjectType+":") {
+		return Credential{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	if userHandle, err := resp.UserHandle(); err != nil || (userHandle != nil && !hmac.Equal(userHandle, p.userHandle(c.Subject))) {
+		return Credential{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	signCount, err := p.rp.VerifyAssertion(resp, challenge, webauthn.Credential{ID: c.CredentialID, PublicKey: c.PublicKey, SignCount: c.SignCount})
+	if err != nil {
+		if stdErrors.Is(err, webauthn.ErrSignCountNotIncreased) {
+			p.l.WithContext(ctx).WithField("subject", c.Subject).WithField("passkey_id", c.ID).Warn(err.Error())
+		}
+		return Credential{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid passkey")
+	}
+
+	now := time.Now()
+	c.SignCount = signCount
+	c.LastUsedAt = &now
+
+	if err := p.repo.updateUsage(ctx, c); err != nil {
+		return Credential{}, err
+	}
+
+	return c, nil
+}
+
+// List implements Passkey.
+func (p *passkey) List(ctx context.Context, subject string) ([]Credential, error) {
+	return p.repo.findBySubject(ctx, subject)
+}
+
+// Rename implements Passkey.
+func (p *passkey) Rename(ctx context.Context, subject string, ID int64, name string) error {
+	return p.repo.rename(ctx, subject, ID, name)
+}
+
+// Delete implements Passkey.
+func (p *passkey) Delete(ctx context.Context, subject string, ID int64) error {
+	return p.repo.delete(ctx, subject, ID)
+}
+
+// DeleteAll implements Passkey.
+func (p *passkey) DeleteAll(ctx context.Context, subject string) error {
+	return p.repo.deleteBySubject(ctx, subject)
+}
+
+func toWebAuthn(creds []Credential) []webauthn.Credential {
+	result := make([]webauthn.Credential, len(creds))
+	for k, c := range creds {
+		result[k] = webauthn.Credential{
+			ID:         c.CredentialID,
+			PublicKey:  c.PublicKey,
+			SignCount:  c.SignCount,
+			Transports: c.Transports,
+		}
+	}
+
+	return result
+}
+
+// NewPasskey creates the passkey that keeps the challenges in redis and the credentials in the webauthn_credential table.
+// The secret derives the user handle of the subject.
+func NewPasskey(logger *logrus.Logger, rc redis.UniversalClient, db *sql.DB, rp *webauthn.RelyingParty, secret string) Passkey {
+	return &passkey{
+		l:      logger,
+		r:      rc,
+		rp:     rp,
+		repo:   &credentialRepository{logger: logger, db: db},
+		secret: secret,
+	}
+}
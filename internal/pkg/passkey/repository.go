@@ -0,0 +1,205 @@
+package passkey
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+const credentialColumns = `
+	id, subject, credential_id, public_key, sign_count, aaguid, transports, name, created_at, last_used_at
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCredential(row rowScanner) (Credential, error) {
+	var (
+		data       Credential
+		signCount  int64
+		transports string
+	)
+
+	err := row.Scan(&data.ID, &data.Subject, &data.CredentialID, &data.PublicKey, &signCount, &data.AAGUID, &transports, &data.Name, &data.CreatedAt, &data.LastUsedAt)
+
+	data.SignCount = uint32(signCount)
+	if transports != "" {
+		data.Transports = strings.Split(transports, ",")
+	}
+
+	return data, err
+}
+
+type credentialRepository struct {
+	logger *logrus.Logger
+	db     *sql.DB
+}
+
+func (r *credentialRepository) save(ctx context.Context, c Credential) (int64, error) {
+	query := `
+		INSERT INTO webauthn_credential
+		(
+			subject, credential_id, public_key, sign_count, aaguid, transports, name, created_at
+		)
+		VALUES
+		(
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		RETURNING id
+	`
+
+	row := r.db.QueryRowContext(ctx, query, c.Subject, c.CredentialID, c.PublicKey, int64(c.SignCount), c.AAGUID, strings.Join(c.Transports, ","), c.Name, c.CreatedAt)
+
+	var ID int64
+
+	if err := row.Scan(&ID); err != nil {
+		var pgErr *pgconn.PgError
+		if stdErrors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, errors.New(http.StatusConflict, status.ALREADY_EXIST, "passkey has already been registered")
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return 0, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while saving passkey")
+	}
+
+	return ID, nil
+}
+
+func (r *credentialRepository) findByCredentialID(ctx context.Context, credentialID []byte) (Credential, error) {
+	query := `
+		SELECT ` + credentialColumns + `
+		FROM webauthn_credential
+		WHERE
+			credential_id = $1
+		LIMIT 1
+	`
+
+	data, err := scanCredential(r.db.QueryRowContext(ctx, query, credentialID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Credential{}, errors.New(http.StatusNotFound, status.NOT_FOUND, "passkey is not found")
+		}
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return Credential{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting passkey")
+	}
+
+	return data, nil
+}
+
+func (r *credentialRepository) findBySubject(ctx context.Context, subject string) ([]Credential, error) {
+	query := `
+		SELECT ` + credentialColumns + `
+		FROM webauthn_credential
+		WHERE
+			subject = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, subject)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting passkeys")
+	}
+	defer rows.Close()
+
+	creds := []Credential{}
+	for rows.Next() {
+		data, err := scanCredential(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error()
+			return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting passkeys")
+		}
+		creds = append(creds, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while getting passkeys")
+	}
+
+	return creds, nil
+}
+
+func (r *credentialRepository) updateUsage(ctx context.Context, c Credential) error {
+	query := `
+		UPDATE webauthn_credential
+		SET
+			sign_count = $1,
+			last_used_at = $2
+		WHERE
+			id = $3
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, int64(c.SignCount), c.LastUsedAt, c.ID); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while updating passkey")
+	}
+
+	return nil
+}
+
+func (r *credentialRepository) rename(ctx context.Context, subject string, ID int64, name string) error {
+	query := `
+		UPDATE webauthn_credential
+		SET
+			name = $1
+		WHERE
+			id = $2
+			AND subject = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, name, ID, subject)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while renaming passkey")
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New(http.StatusNotFound, status.NOT_FOUND, "passkey is not found")
+	}
+
+	return nil
+}
+
+func (r *credentialRepository) delete(ctx context.Context, subject string, ID int64) error {
+	query := `
+		DELETE FROM webauthn_credential
+		WHERE
+			id = $1
+			AND subject = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, ID, subject)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting passkey")
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New(http.StatusNotFound, status.NOT_FOUND, "passkey is not found")
+	}
+
+	return nil
+}
+
+func (r *credentialRepository) deleteBySubject(ctx context.Context, subject string) error {
+	query := `
+		DELETE FROM webauthn_credential
+		WHERE
+			subject = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, subject); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "an error occurred while deleting passkeys")
+	}
+
+	return nil
+}
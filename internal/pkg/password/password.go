@@ -0,0 +1,142 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Params is the tunable cost of argon2id.
+type Params struct {
+	// Memory in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// LegacyPBKDF2 describes how the password was hashed with util.GenerateSecret before argon2id is introduced.
+// The hash and its salt are stored in separated columns.
+type LegacyPBKDF2 struct {
+	Pepper    string
+	KeyLength int
+}
+
+// Hasher is a collection of behavior of password hashing.
+type Hasher interface {
+	// Hash returns the password in PHC string format, e.g. $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>.
+	Hash(plain string) string
+	// Verify checks the plain password against the hashed password. The salt is only used by legacy hashes.
+	// It tells whether the hashed password should be replaced by the current algorithm or parameters.
+	Verify(plain, hashed, salt string) (match bool, rehash bool)
+}
+
+type argon2idHasher struct {
+	params Params
+	pepper string
+	legacy LegacyPBKDF2
+}
+
+// NewArgon2idHasher returns argon2id hasher. The pepper is mixed into every new password, it has to be kept outside the database.
+func NewArgon2idHasher(params Params, pepper string, legacy LegacyPBKDF2) Hasher {
+	return &argon2idHasher{
+		params: params,
+		pepper: pepper,
+		legacy: legacy,
+	}
+}
+
+func (h *argon2idHasher) key(plain string, salt []byte, p Params) []byte {
+	return argon2.IDKey([]byte(fmt.Sprintf("%s%s", h.pepper, plain)), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+}
+
+// Hash implements Hasher.
+func (h *argon2idHasher) Hash(plain string) string {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+
+	key := h.key(plain, salt, h.params)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+// Verify implements Hasher.
+func (h *argon2idHasher) Verify(plain, hashed, salt string) (bool, bool) {
+	if !strings.HasPrefix(hashed, argon2idPrefix) {
+		legacyHashed := util.GenerateSecret(fmt.Sprintf("%s%s", h.legacy.Pepper, plain), salt, h.legacy.KeyLength)
+
+		return subtle.ConstantTimeCompare([]byte(legacyHashed), []byte(hashed)) == 1, true
+	}
+
+	p, decodedSalt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		return false, false
+	}
+
+	otherKey := h.key(plain, decodedSalt, p)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return false, false
+	}
+
+	rehash := p.Memory != h.params.Memory ||
+		p.Iterations != h.params.Iterations ||
+		p.Parallelism != h.params.Parallelism ||
+		p.SaltLength != h.params.SaltLength ||
+		p.KeyLength != h.params.KeyLength
+
+	return true, rehash
+}
+
+func decodeArgon2id(hashed string) (Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", "<salt>", "<hash>"
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 {
+		return Params{}, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Params{}, nil, nil, err
+	}
+	if version != argon2.Version {
+		return Params{}, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var p Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Params{}, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Params{}, nil, nil, err
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,71 @@
+package password_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/password"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+)
+
+var params = password.Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestHashAndVerify(t *testing.T) {
+	h := password.NewArgon2idHasher(params, "pepper", password.LegacyPBKDF2{})
+
+	hashed := h.Hash("secret")
+	assert.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.NotEqual(t, hashed, h.Hash("secret"))
+
+	match, rehash := h.Verify("secret", hashed, "")
+	assert.True(t, match)
+	assert.False(t, rehash)
+
+	match, _ = h.Verify("wrong", hashed, "")
+	assert.False(t, match)
+
+	match, _ = password.NewArgon2idHasher(params, "other", password.LegacyPBKDF2{}).Verify("secret", hashed, "")
+	assert.False(t, match)
+}
+
+func TestVerifyRehashOnChangedParams(t *testing.T) {
+	hashed := password.NewArgon2idHasher(params, "pepper", password.LegacyPBKDF2{}).Hash("secret")
+
+	stronger := params
+	stronger.Iterations = 2
+
+	match, rehash := password.NewArgon2idHasher(stronger, "pepper", password.LegacyPBKDF2{}).Verify("secret", hashed, "")
+	assert.True(t, match)
+	assert.True(t, rehash)
+}
+
+func TestVerifyLegacy(t *testing.T) {
+	legacy := password.LegacyPBKDF2{Pepper: "pepper", KeyLength: 256}
+	h := password.NewArgon2idHasher(params, "pepper", legacy)
+
+	salt := util.GenerateRandomHEX(32)
+	hashed := util.GenerateSecret(fmt.Sprintf("%s%s", "pepper", "secret"), salt, 256)
+
+	match, rehash := h.Verify("secret", hashed, salt)
+	assert.True(t, match)
+	assert.True(t, rehash)
+
+	match, _ = h.Verify("wrong", hashed, salt)
+	assert.False(t, match)
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	h := password.NewArgon2idHasher(params, "pepper", password.LegacyPBKDF2{})
+
+	match, rehash := h.Verify("secret", "$argon2id$v=19$m=1024$invalid", "")
+	assert.False(t, match)
+	assert.False(t, rehash)
+}
@@ -0,0 +1,203 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/util"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+var (
+	refreshTokenKeyPrefix        string = "session:refresh_token:token:%s"
+	refreshTokenUsedKeyPrefix    string = "session:refresh_token:used:%s"
+	refreshTokenFamilyKeyPrefix  string = "session:refresh_token:family:%s"
+	refreshTokenSubjectKeyPrefix string = "session:refresh_token:subject:%s"
+)
+
+// RefreshToken is an opaque token that can be exchanged once for a new id token. Every token that is rotated from the same sign in belongs to the same family.
+type RefreshToken struct {
+	Token     string    `json:"-"`
+	FamilyID  string    `json:"family_id"`
+	Subject   string    `json:"subject"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RefreshTokenStore is a collection of behavior to issue, rotate and revoke refresh tokens.
+type RefreshTokenStore interface {
+	// Issue creates a refresh token within a new family for the given subject. The family is usually identified by the session's id.
+	Issue(ctx context.Context, subject, familyID string) (RefreshToken, error)
+	// Rotate exchanges the given token with a new one within the same family. Presenting a token that has been rotated before revokes the whole family,
+	// the presented token is returned along with the error so the caller is able to clean up the related session.
+	Rotate(ctx context.Context, token string) (RefreshToken, error)
+	// Revoke invalidates every token of the family.
+	Revoke(ctx context.Context, familyID string) error
+	// RevokeAll invalidates every family that belongs to the subject except the given ones.
+	RevokeAll(ctx context.Context, subject string, exceptFamilyIDs ...string) error
+}
+
+type redisRefreshTokenStore struct {
+	l   *logrus.Logger
+	r   redis.UniversalClient
+	ttl time.Duration
+}
+
+func (s *redisRefreshTokenStore) issue(ctx context.Context, familyID, subject string) (RefreshToken, error) {
+	rt := RefreshToken{
+		Token:     util.GenerateRandomHEX(32),
+		FamilyID:  familyID,
+		Subject:   subject,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+
+	rtBuff, _ := json.Marshal(rt)
+	subjectKey := fmt.Sprintf(refreshTokenSubjectKeyPrefix, subject)
+
+	// the keys may live on different nodes of a cluster, so they are not written within a transaction. The token is written last,
+	// a token is never exchangeable without its family, and a family that is left without a token expires on its own.
+	_, err := s.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, fmt.Sprintf(refreshTokenFamilyKeyPrefix, familyID), subject, s.ttl)
+		p.SAdd(ctx, subjectKey, familyID)
+		p.Expire(ctx, subjectKey, s.ttl)
+		return nil
+	})
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return RefreshToken{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	if err := s.r.Set(ctx, fmt.Sprintf(refreshTokenKeyPrefix, rt.Token), rtBuff, s.ttl).Err(); err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return RefreshToken{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	return rt, nil
+}
+
+// Issue implements RefreshTokenStore.
+func (s *redisRefreshTokenStore) Issue(ctx context.Context, subject, familyID string) (RefreshToken, error) {
+	return s.issue(ctx, familyID, subject)
+}
+
+// Rotate implements RefreshTokenStore.
+func (s *redisRefreshTokenStore) Rotate(ctx context.Context, token string) (RefreshToken, error) {
+	rtBuff, err := s.r.Get(ctx, fmt.Sprintf(refreshTokenKeyPrefix, token)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return RefreshToken{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid refresh token")
+		}
+		s.l.WithContext(ctx).WithError(err).Error()
+		return RefreshToken{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	var rt RefreshToken
+	if err := json.Unmarshal(rtBuff, &rt); err != nil || rt.FamilyID == "" {
+		return RefreshToken{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "invalid refresh token")
+	}
+
+	// the family is missing when it has been revoked, or when the issuing has not been completed.
+	n, err := s.r.Exists(ctx, fmt.Sprintf(refreshTokenFamilyKeyPrefix, rt.FamilyID)).Result()
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return RefreshToken{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+	if n == 0 {
+		return RefreshToken{}, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "refresh token has been revoked")
+	}
+
+	ok, err := s.r.SetNX(ctx, fmt.Sprintf(refreshTokenUsedKeyPrefix, token), time.Now().Unix(), s.ttl).Result()
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return RefreshToken{}, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+	if !ok {
+		s.l.WithContext(ctx).WithFields(logrus.Fields{
+			"subject":   rt.Subject,
+			"family_id": rt.FamilyID,
+		}).Warn("refresh token reuse is detected")
+
+		if err := s.Revoke(ctx, rt.FamilyID); err != nil {
+			return RefreshToken{}, err
+		}
+
+		rt.Token = token
+
+		return rt, errors.New(http.StatusUnauthorized, status.UNAUTHORIZED, "refresh token has been used")
+	}
+
+	return s.issue(ctx, rt.FamilyID, rt.Subject)
+}
+
+// Revoke implements RefreshTokenStore.
+func (s *redisRefreshTokenStore) Revoke(ctx context.Context, familyID string) error {
+	familyKey := fmt.Sprintf(refreshTokenFamilyKeyPrefix, familyID)
+
+	subject, err := s.r.Get(ctx, familyKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil
+		}
+		s.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	// the family is deleted first, it alone revokes the tokens. A family that is left in the subject's set is harmless, RevokeAll deletes the missing key again.
+	_, err = s.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, familyKey)
+		p.SRem(ctx, fmt.Sprintf(refreshTokenSubjectKeyPrefix, subject), familyID)
+		return nil
+	})
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	return nil
+}
+
+// RevokeAll implements RefreshTokenStore.
+func (s *redisRefreshTokenStore) RevokeAll(ctx context.Context, subject string, exceptFamilyIDs ...string) error {
+	subjectKey := fmt.Sprintf(refreshTokenSubjectKeyPrefix, subject)
+
+	familyIDs, err := s.r.SMembers(ctx, subjectKey).Result()
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	excepted := make(map[string]bool)
+	for _, familyID := range exceptFamilyIDs {
+		excepted[familyID] = true
+	}
+
+	_, err = s.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, familyID := range familyIDs {
+			if excepted[familyID] {
+				continue
+			}
+			p.Del(ctx, fmt.Sprintf(refreshTokenFamilyKeyPrefix, familyID))
+			p.SRem(ctx, subjectKey, familyID)
+		}
+		return nil
+	})
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	return nil
+}
+
+func NewRedisRefreshTokenStore(l *logrus.Logger, r redis.UniversalClient, ttl time.Duration) RefreshTokenStore {
+	return &redisRefreshTokenStore{
+		l:   l,
+		r:   r,
+		ttl: ttl,
+	}
+}
@@ -0,0 +1,55 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/internal/pkg/session"
+	"github.com/tsel-ticketmaster/tm-user/pkg/errors"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+func TestRefreshTokenRotate(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	store := session.NewRedisRefreshTokenStore(logrus.New(), rc, time.Hour)
+	ctx := context.Background()
+
+	rt, err := store.Issue(ctx, "customer:1", "family-1")
+	assert.Nil(t, err)
+
+	rotated, err := store.Rotate(ctx, rt.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, "family-1", rotated.FamilyID)
+
+	// the reuse of a rotated token revokes the whole family.
+	_, err = store.Rotate(ctx, rt.Token)
+	assert.True(t, errors.MatchStatus(err, status.UNAUTHORIZED))
+
+	_, err = store.Rotate(ctx, rotated.Token)
+	assert.True(t, errors.MatchStatus(err, status.UNAUTHORIZED))
+}
+
+func TestRefreshTokenWithoutFamily(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	store := session.NewRedisRefreshTokenStore(logrus.New(), rc, time.Hour)
+	ctx := context.Background()
+
+	rt, err := store.Issue(ctx, "customer:1", "family-1")
+	assert.Nil(t, err)
+
+	// the family is deleted while the subject's set still holds it, e.g. the revoking has not been completed.
+	mr.Del("session:refresh_token:family:family-1")
+
+	_, err = store.Rotate(ctx, rt.Token)
+	assert.True(t, errors.MatchStatus(err, status.UNAUTHORIZED))
+
+	assert.Nil(t, store.RevokeAll(ctx, "customer:1"))
+	assert.False(t, mr.Exists("session:refresh_token:subject:customer:1"))
+}
@@ -14,22 +14,33 @@ import (
 )
 
 var (
-	sessionKeyPrefix string = "session:user:%s"
+	sessionKeyPrefix      string = "session:user:%s:%s"
+	sessionIndexKeyPrefix string = "session:index:%s"
 )
 
 type AccountContextKey struct{}
 
 type Account struct {
-	ID    int64
-	Email string
-	Name  string
-	Type  string
+	ID         int64
+	Email      string
+	Name       string
+	Type       string
+	SessionID  string
+	Device     string
+	IPAddress  string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
 }
 
+// Session is a collection of behavior to manage the sessions of an account. The subject may hold many sessions at once, one for each signed in device.
 type Session interface {
-	Set(ctx context.Context, key string, acc Account, ttl time.Duration) error
-	Delete(ctx context.Context, key string) error
-	Get(ctx context.Context, key string) (Account, error)
+	Set(ctx context.Context, subject string, acc Account, ttl time.Duration) error
+	Get(ctx context.Context, subject, ID string) (Account, error)
+	Touch(ctx context.Context, subject, ID string) error
+	List(ctx context.Context, subject string) ([]Account, error)
+	Delete(ctx context.Context, subject, ID string) error
+	DeleteAll(ctx context.Context, subject string, exceptIDs ...string) error
 }
 
 type redisSessionStore struct {
@@ -38,10 +49,46 @@ type redisSessionStore struct {
 }
 
 // Delete implements Session.
-func (s *redisSessionStore) Delete(ctx context.Context, key string) error {
-	sessionKey := fmt.Sprintf(sessionKeyPrefix, key)
+func (s *redisSessionStore) Delete(ctx context.Context, subject, ID string) error {
+	_, err := s.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, fmt.Sprintf(sessionKeyPrefix, subject, ID))
+		p.SRem(ctx, fmt.Sprintf(sessionIndexKeyPrefix, subject), ID)
+		return nil
+	})
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	return nil
+}
 
-	if err := s.r.Del(ctx, sessionKey).Err(); err != nil {
+// DeleteAll implements Session.
+func (s *redisSessionStore) DeleteAll(ctx context.Context, subject string, exceptIDs ...string) error {
+	indexKey := fmt.Sprintf(sessionIndexKeyPrefix, subject)
+
+	IDs, err := s.r.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	excepted := make(map[string]bool)
+	for _, ID := range exceptIDs {
+		excepted[ID] = true
+	}
+
+	_, err = s.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, ID := range IDs {
+			if excepted[ID] {
+				continue
+			}
+			p.Del(ctx, fmt.Sprintf(sessionKeyPrefix, subject, ID))
+			p.SRem(ctx, indexKey, ID)
+		}
+		return nil
+	})
+	if err != nil {
 		s.l.WithContext(ctx).WithError(err).Error()
 		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
 	}
@@ -50,8 +97,8 @@ func (s *redisSessionStore) Delete(ctx context.Context, key string) error {
 }
 
 // Get implements Session.
-func (s *redisSessionStore) Get(ctx context.Context, key string) (Account, error) {
-	sessionKey := fmt.Sprintf(sessionKeyPrefix, key)
+func (s *redisSessionStore) Get(ctx context.Context, subject, ID string) (Account, error) {
+	sessionKey := fmt.Sprintf(sessionKeyPrefix, subject, ID)
 	acc := Account{}
 	dataBuff, err := s.r.Get(ctx, sessionKey).Bytes()
 	if err != nil {
@@ -68,11 +115,81 @@ func (s *redisSessionStore) Get(ctx context.Context, key string) (Account, error
 	return acc, nil
 }
 
+// List implements Session. The sessions that have been expired are removed from the subject's index.
+func (s *redisSessionStore) List(ctx context.Context, subject string) ([]Account, error) {
+	indexKey := fmt.Sprintf(sessionIndexKeyPrefix, subject)
+
+	IDs, err := s.r.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	cmds := make([]*redis.StringCmd, len(IDs))
+	_, err = s.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for k, ID := range IDs {
+			cmds[k] = p.Get(ctx, fmt.Sprintf(sessionKeyPrefix, subject, ID))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return nil, errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	accs := make([]Account, 0, len(IDs))
+	expiredIDs := make([]interface{}, 0)
+	for k, cmd := range cmds {
+		dataBuff, err := cmd.Bytes()
+		if err != nil {
+			expiredIDs = append(expiredIDs, IDs[k])
+			continue
+		}
+
+		var acc Account
+		json.Unmarshal(dataBuff, &acc)
+		accs = append(accs, acc)
+	}
+
+	if len(expiredIDs) > 0 {
+		if err := s.r.SRem(ctx, indexKey, expiredIDs...).Err(); err != nil {
+			s.l.WithContext(ctx).WithError(err).Error()
+		}
+	}
+
+	return accs, nil
+}
+
 // Set implements Session.
-func (s *redisSessionStore) Set(ctx context.Context, key string, acc Account, ttl time.Duration) error {
-	sessionKey := fmt.Sprintf(sessionKeyPrefix, key)
+func (s *redisSessionStore) Set(ctx context.Context, subject string, acc Account, ttl time.Duration) error {
+	indexKey := fmt.Sprintf(sessionIndexKeyPrefix, subject)
+	accBuff, _ := json.Marshal(acc)
+
+	_, err := s.r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, fmt.Sprintf(sessionKeyPrefix, subject, acc.SessionID), accBuff, ttl)
+		p.SAdd(ctx, indexKey, acc.SessionID)
+		p.Expire(ctx, indexKey, ttl)
+		return nil
+	})
+	if err != nil {
+		s.l.WithContext(ctx).WithError(err).Error()
+		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
+	}
+
+	return nil
+}
+
+// Touch implements Session. It marks the session as recently seen without extending its lifetime.
+func (s *redisSessionStore) Touch(ctx context.Context, subject, ID string) error {
+	acc, err := s.Get(ctx, subject, ID)
+	if err != nil {
+		return err
+	}
+
+	acc.LastSeenAt = time.Now()
 	accBuff, _ := json.Marshal(acc)
-	if err := s.r.Set(ctx, sessionKey, accBuff, ttl).Err(); err != nil {
+
+	if err := s.r.SetXX(ctx, fmt.Sprintf(sessionKeyPrefix, subject, ID), accBuff, redis.KeepTTL).Err(); err != nil {
 		s.l.WithContext(ctx).WithError(err).Error()
 		return errors.New(http.StatusInternalServerError, status.INTERNAL_SERVER_ERROR, "")
 	}
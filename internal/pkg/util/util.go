@@ -1,7 +1,10 @@
 package util
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
@@ -13,7 +16,7 @@ import (
 
 // GenerateRandomHEX returns random hex number in string format by the given size (in bytes).
 func GenerateRandomHEX(size int) string {
-	b := make([]byte, 16)
+	b := make([]byte, size)
 	if _, err := rand.Read(b); err != nil {
 		panic(err)
 	}
@@ -34,3 +37,55 @@ func GenerateTimestampWithPrefix(prefix string) string {
 
 	return fmt.Sprintf("%s%d", prefix, micro)
 }
+
+// Encrypt returns the cipher of the plain in base64 format. The used algorithm is aes-256-gcm and the key is derived from the secret with sha256.
+func Encrypt(plain, secret string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	b := gcm.Seal(nonce, nonce, []byte(plain), nil)
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Decrypt returns the plain of the cipher that is produced by Encrypt.
+func Decrypt(encrypted, secret string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	if len(b) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid cipher")
+	}
+
+	plain, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func newGCM(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
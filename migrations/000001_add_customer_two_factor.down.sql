@@ -0,0 +1,4 @@
+ALTER TABLE customer
+    DROP COLUMN two_factor_recovery_codes,
+    DROP COLUMN two_factor_secret,
+    DROP COLUMN two_factor_enabled;
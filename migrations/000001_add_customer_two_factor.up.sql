@@ -0,0 +1,4 @@
+ALTER TABLE customer
+    ADD COLUMN two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN two_factor_secret TEXT NOT NULL DEFAULT '',
+    ADD COLUMN two_factor_recovery_codes TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+-- the previous column types are kept as TEXT, narrowing them would truncate the argon2id hashes.
+SELECT 1;
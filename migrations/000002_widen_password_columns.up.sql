@@ -0,0 +1,3 @@
+-- argon2id hashes are stored in PHC string format which is longer than the legacy admin hash.
+ALTER TABLE admin ALTER COLUMN password TYPE TEXT;
+ALTER TABLE customer ALTER COLUMN password TYPE TEXT;
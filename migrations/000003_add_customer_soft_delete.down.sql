@@ -0,0 +1,7 @@
+-- the unique constraint of the email is not restored, a deleted and an active customer may share the same email.
+DROP INDEX IF EXISTS customer_deleted_at_idx;
+DROP INDEX IF EXISTS customer_email_active_idx;
+
+ALTER TABLE customer
+    DROP COLUMN deleted_at,
+    DROP COLUMN erased_at;
@@ -0,0 +1,8 @@
+ALTER TABLE customer
+    ADD COLUMN deleted_at TIMESTAMPTZ NULL,
+    ADD COLUMN erased_at TIMESTAMPTZ NULL;
+
+-- the email of a deleted customer can be registered again.
+ALTER TABLE customer DROP CONSTRAINT IF EXISTS customer_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS customer_email_active_idx ON customer (email) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS customer_deleted_at_idx ON customer (deleted_at) WHERE deleted_at IS NOT NULL AND erased_at IS NULL;
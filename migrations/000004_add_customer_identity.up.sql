@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS customer_identity (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customer (id) ON DELETE CASCADE,
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT customer_identity_provider_subject_key UNIQUE (provider, subject),
+    -- a customer links at most one account of each provider.
+    CONSTRAINT customer_identity_customer_id_provider_key UNIQUE (customer_id, provider)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS customer_phone_active_idx;
+
+ALTER TABLE customer
+    DROP COLUMN phone,
+    DROP COLUMN phone_verified_at;
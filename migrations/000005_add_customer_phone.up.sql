@@ -0,0 +1,6 @@
+ALTER TABLE customer
+    ADD COLUMN phone VARCHAR(16) NULL,
+    ADD COLUMN phone_verified_at TIMESTAMPTZ NULL;
+
+-- only the verified phone is stored, it identifies the customer on sign in.
+CREATE UNIQUE INDEX IF NOT EXISTS customer_phone_active_idx ON customer (phone) WHERE phone IS NOT NULL AND deleted_at IS NULL;
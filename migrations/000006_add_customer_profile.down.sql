@@ -0,0 +1,7 @@
+ALTER TABLE customer
+    DROP COLUMN birth_date,
+    DROP COLUMN gender,
+    DROP COLUMN city,
+    DROP COLUMN province,
+    DROP COLUMN preferred_language,
+    DROP COLUMN timezone;
@@ -0,0 +1,7 @@
+ALTER TABLE customer
+    ADD COLUMN birth_date DATE NULL,
+    ADD COLUMN gender VARCHAR(32) NULL,
+    ADD COLUMN city VARCHAR(100) NULL,
+    ADD COLUMN province VARCHAR(100) NULL,
+    ADD COLUMN preferred_language VARCHAR(35) NULL,
+    ADD COLUMN timezone VARCHAR(64) NULL;
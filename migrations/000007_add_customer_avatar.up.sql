@@ -0,0 +1,2 @@
+ALTER TABLE customer
+    ADD COLUMN avatar_key VARCHAR(255) NULL;
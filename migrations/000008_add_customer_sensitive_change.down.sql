@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS customer_sensitive_change;
+
+ALTER TABLE customer
+    DROP COLUMN password_reset_required;
@@ -0,0 +1,15 @@
+ALTER TABLE customer
+    ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS customer_sensitive_change (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customer (id) ON DELETE CASCADE,
+    type VARCHAR(64) NOT NULL,
+    old_value VARCHAR(255) NULL,
+    new_value VARCHAR(255) NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    device VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS customer_sensitive_change_customer_id_created_at_idx ON customer_sensitive_change (customer_id, created_at);
@@ -0,0 +1,16 @@
+-- the credentials of the customers and the administrators, the subject is either customer:<id> or admin:<id>.
+CREATE TABLE IF NOT EXISTS webauthn_credential (
+    id BIGSERIAL PRIMARY KEY,
+    subject VARCHAR(64) NOT NULL,
+    credential_id BYTEA NOT NULL,
+    public_key BYTEA NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    aaguid BYTEA NULL,
+    transports VARCHAR(255) NOT NULL DEFAULT '',
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ NULL,
+    CONSTRAINT webauthn_credential_credential_id_key UNIQUE (credential_id)
+);
+
+CREATE INDEX IF NOT EXISTS webauthn_credential_subject_idx ON webauthn_credential (subject);
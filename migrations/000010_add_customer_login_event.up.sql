@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS customer_login_event (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customer (id) ON DELETE CASCADE,
+    method VARCHAR(32) NOT NULL,
+    outcome VARCHAR(32) NOT NULL,
+    failure_reason VARCHAR(64) NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    device VARCHAR(255) NOT NULL DEFAULT '',
+    device_fingerprint VARCHAR(64) NOT NULL DEFAULT '',
+    country VARCHAR(2) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS customer_login_event_customer_id_created_at_idx ON customer_login_event (customer_id, created_at);
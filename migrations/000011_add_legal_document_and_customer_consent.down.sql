@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS customer_consent;
+
+DROP TABLE IF EXISTS legal_document;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS legal_document (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    version VARCHAR(32) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    mandatory BOOLEAN NOT NULL DEFAULT FALSE,
+    published_at TIMESTAMPTZ NULL,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (type, version)
+);
+
+CREATE INDEX IF NOT EXISTS legal_document_type_published_at_idx ON legal_document (type, published_at);
+
+-- the log is append only, the latest entry of the type is the customer's current consent.
+CREATE TABLE IF NOT EXISTS customer_consent (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL REFERENCES customer (id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    document_id BIGINT NULL REFERENCES legal_document (id),
+    granted BOOLEAN NOT NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS customer_consent_customer_id_type_created_at_idx ON customer_consent (customer_id, type, created_at);
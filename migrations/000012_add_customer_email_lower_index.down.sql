@@ -0,0 +1,28 @@
+-- the owners are lower-cased, so they may share the exact email with their duplicates. The rollback is refused until the duplicates are resolved,
+-- otherwise the unique index on the email cannot be created.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM customer
+        WHERE deleted_at IS NULL
+        GROUP BY email
+        HAVING COUNT(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'customers share the same email, resolve customer_email_duplicate_report before the rollback';
+    END IF;
+END $$;
+
+DROP VIEW IF EXISTS customer_gmail_duplicate_report;
+DROP VIEW IF EXISTS customer_email_duplicate_report;
+
+DROP INDEX IF EXISTS customer_gmail_address_idx;
+DROP FUNCTION IF EXISTS customer_gmail_address(TEXT);
+
+DROP INDEX IF EXISTS customer_email_lower_idx;
+DROP INDEX IF EXISTS customer_email_lower_active_idx;
+
+ALTER TABLE customer DROP COLUMN IF EXISTS email_duplicate_of;
+
+-- the lower-cased emails are not restored.
+CREATE UNIQUE INDEX IF NOT EXISTS customer_email_active_idx ON customer (email) WHERE deleted_at IS NULL;
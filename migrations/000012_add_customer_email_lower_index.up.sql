@@ -0,0 +1,84 @@
+-- the emails that differ by case only belong to the same mailbox. The oldest customer keeps the email, the others are marked as its duplicates.
+ALTER TABLE customer ADD COLUMN email_duplicate_of BIGINT NULL REFERENCES customer (id);
+
+UPDATE customer c
+SET email_duplicate_of = d.owner_id
+FROM (
+    SELECT id, MIN(id) OVER (PARTITION BY lower(trim(email))) AS owner_id
+    FROM customer
+    WHERE deleted_at IS NULL
+) d
+WHERE c.id = d.id AND d.owner_id <> d.id;
+
+DROP INDEX IF EXISTS customer_email_active_idx;
+
+-- the new emails are stored canonical by the app, the optional gmail rules are not applied to the existing emails.
+UPDATE customer
+SET email = lower(trim(email))
+WHERE deleted_at IS NULL AND email_duplicate_of IS NULL AND email <> lower(trim(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS customer_email_lower_active_idx ON customer (lower(email)) WHERE deleted_at IS NULL AND email_duplicate_of IS NULL;
+CREATE INDEX IF NOT EXISTS customer_email_lower_idx ON customer (lower(email)) WHERE deleted_at IS NULL;
+
+-- the gmail address of the email, i.e. without the dots and the plus suffix of the local part, the same as the app canonicalises it with the gmail rules.
+-- the existing emails are left as they are, since the rules are optional. The app matches them by this address while the rules are turned on.
+CREATE OR REPLACE FUNCTION customer_gmail_address(email TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE AS $$
+    SELECT CASE
+        WHEN substring(e FROM '@([^@]+)$') IN ('gmail.com', 'googlemail.com')
+            AND replace(split_part(regexp_replace(e, '@[^@]+$', ''), '+', 1), '.', '') <> ''
+        THEN replace(split_part(regexp_replace(e, '@[^@]+$', ''), '+', 1), '.', '') || '@gmail.com'
+        ELSE e
+    END
+    FROM (SELECT lower(trim(email)) AS e) AS normalized
+$$;
+
+CREATE INDEX IF NOT EXISTS customer_gmail_address_idx ON customer (customer_gmail_address(email)) WHERE deleted_at IS NULL;
+
+-- the duplicates are left to the administrators to resolve, e.g. by deleting the unused accounts.
+CREATE OR REPLACE VIEW customer_email_duplicate_report AS
+SELECT
+    o.id AS owner_id,
+    o.email AS owner_email,
+    o.created_at AS owner_created_at,
+    d.id AS duplicate_id,
+    d.email AS duplicate_email,
+    d.member_status AS duplicate_member_status,
+    d.created_at AS duplicate_created_at
+FROM customer d
+JOIN customer o ON o.id = d.email_duplicate_of
+WHERE d.deleted_at IS NULL;
+
+-- the gmail addresses that are shared by several customers, only the oldest customer is found while the gmail rules are turned on.
+CREATE OR REPLACE VIEW customer_gmail_duplicate_report AS
+SELECT
+    d.gmail_address,
+    d.owner_id,
+    d.id AS duplicate_id,
+    d.email AS duplicate_email,
+    d.member_status AS duplicate_member_status,
+    d.created_at AS duplicate_created_at
+FROM (
+    SELECT
+        id, email, member_status, created_at,
+        customer_gmail_address(email) AS gmail_address,
+        MIN(id) OVER (PARTITION BY customer_gmail_address(email)) AS owner_id
+    FROM customer
+    WHERE deleted_at IS NULL AND customer_gmail_address(email) LIKE '%@gmail.com'
+) d
+WHERE d.id <> d.owner_id;
+
+DO $$
+DECLARE
+    duplicates BIGINT;
+BEGIN
+    SELECT COUNT(*) INTO duplicates FROM customer_email_duplicate_report;
+    IF duplicates > 0 THEN
+        RAISE NOTICE '% customers share the email with another customer, see customer_email_duplicate_report', duplicates;
+    END IF;
+
+    SELECT COUNT(*) INTO duplicates FROM customer_gmail_duplicate_report;
+    IF duplicates > 0 THEN
+        RAISE NOTICE '% customers share the gmail address with another customer, see customer_gmail_duplicate_report', duplicates;
+    END IF;
+END $$;
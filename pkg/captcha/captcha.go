@@ -0,0 +1,198 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The providers that are supported.
+const (
+	ProviderReCAPTCHA  = "recaptcha"
+	ProviderTurnstile  = "turnstile"
+	ProviderAlwaysPass = "always-pass"
+	ProviderAlwaysFail = "always-fail"
+)
+
+// The verify endpoints of the providers.
+const (
+	ReCAPTCHAVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+	TurnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+// Errors.
+var (
+	ErrUnsupportedProvider error = fmt.Errorf("unsupported captcha provider")
+)
+
+// Result is the outcome of the verification of a token.
+type Result struct {
+	Success bool
+	// Score is the likelihood of a human between 0 and 1, it is nil when the provider does not score the token, e.g. an interactive challenge.
+	Score      *float64
+	Action     string
+	Hostname   string
+	ErrorCodes []string
+}
+
+// HumanVerifier tells whether the token that is solved by the client proves a human.
+type HumanVerifier interface {
+	// Verify checks the token against the provider. The error is returned only when the provider is unavailable, a rejected token is not an error.
+	Verify(ctx context.Context, token, remoteIP string) (Result, error)
+}
+
+// Policy is the verification of a route. The zero value verifies the token without a score threshold.
+type Policy struct {
+	Disabled bool `json:"disabled"`
+	// MinScore is the minimum score of the token. The request with a lower score is escalated to the stricter challenge.
+	MinScore float64 `json:"min_score"`
+	// Hostnames are the sites that the token must be solved on, the token of any site is accepted when it is empty.
+	Hostnames []string `json:"hostnames"`
+	// FailOpen lets the request through when the provider is unavailable, otherwise it is rejected.
+	FailOpen bool `json:"fail_open"`
+}
+
+// AllowsHostname tells whether the token that is solved on the hostname is accepted by the policy.
+func (p Policy) AllowsHostname(hostname string) bool {
+	if len(p.Hostnames) == 0 {
+		return true
+	}
+
+	for _, h := range p.Hostnames {
+		if strings.EqualFold(h, hostname) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config is the registration of the site on the provider. The verify url defaults to the provider's endpoint.
+type Config struct {
+	Provider  string
+	Secret    string
+	VerifyURL string
+}
+
+// NewHumanVerifier creates the verifier of the provider. It returns nil when the provider is empty, i.e. the verification is turned off.
+func NewHumanVerifier(cfg Config, httpClient *http.Client) (HumanVerifier, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderAlwaysPass:
+		return AlwaysPass(), nil
+	case ProviderAlwaysFail:
+		return AlwaysFail(), nil
+	case ProviderReCAPTCHA:
+		if cfg.VerifyURL == "" {
+			cfg.VerifyURL = ReCAPTCHAVerifyURL
+		}
+	case ProviderTurnstile:
+		if cfg.VerifyURL == "" {
+			cfg.VerifyURL = TurnstileVerifyURL
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, cfg.Provider)
+	}
+
+	return NewSiteVerifier(cfg.VerifyURL, cfg.Secret, httpClient), nil
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	Action     string   `json:"action"`
+	Hostname   string   `json:"hostname"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+type siteVerifier struct {
+	verifyURL  string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewSiteVerifier returns the verifier of a siteverify style endpoint, e.g. reCAPTCHA and Turnstile.
+func NewSiteVerifier(verifyURL, secret string, httpClient *http.Client) HumanVerifier {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Second * 5}
+	}
+
+	return &siteVerifier{
+		verifyURL:  verifyURL,
+		secret:     secret,
+		httpClient: httpClient,
+	}
+}
+
+// Verify implements HumanVerifier.
+func (v *siteVerifier) Verify(ctx context.Context, token, remoteIP string) (Result, error) {
+	values := url.Values{}
+	values.Set("secret", v.secret)
+	values.Set("response", token)
+	if remoteIP != "" {
+		values.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := v.httpClient.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return Result{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("verify endpoint responds with status %d: %s", res.StatusCode, body)
+	}
+
+	var vr siteVerifyResponse
+	if err := json.Unmarshal(body, &vr); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Success:    vr.Success,
+		Score:      vr.Score,
+		Action:     vr.Action,
+		Hostname:   vr.Hostname,
+		ErrorCodes: vr.ErrorCodes,
+	}
+
+	return result, nil
+}
+
+// StaticVerifier returns the same result for every token. It is meant for tests and local development.
+type StaticVerifier struct {
+	Result Result
+}
+
+// Verify implements HumanVerifier.
+func (v StaticVerifier) Verify(ctx context.Context, token, remoteIP string) (Result, error) {
+	return v.Result, nil
+}
+
+// AlwaysPass returns the verifier that accepts every token. The token is not scored, so it is not bound to an action either.
+func AlwaysPass() HumanVerifier {
+	return StaticVerifier{Result: Result{Success: true}}
+}
+
+// AlwaysFail returns the verifier that rejects every token.
+func AlwaysFail() HumanVerifier {
+	return StaticVerifier{Result: Result{Success: false, ErrorCodes: []string{"invalid-input-response"}}}
+}
@@ -0,0 +1,81 @@
+package captcha_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/captcha"
+)
+
+func newVerifyServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "site-secret", r.Form.Get("secret"))
+
+		switch r.Form.Get("response") {
+		case "human":
+			assert.Equal(t, "10.0.0.1", r.Form.Get("remoteip"))
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "score": 0.9, "action": "signup", "hostname": "ticketmaster.example"})
+		case "challenge":
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error-codes": []string{"invalid-input-response"}})
+		}
+	}))
+}
+
+func TestSiteVerifier(t *testing.T) {
+	server := newVerifyServer(t)
+	defer server.Close()
+
+	verifier, err := captcha.NewHumanVerifier(captcha.Config{Provider: captcha.ProviderTurnstile, Secret: "site-secret", VerifyURL: server.URL}, server.Client())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	result, err := verifier.Verify(ctx, "human", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 0.9, *result.Score)
+	assert.Equal(t, "signup", result.Action)
+
+	result, err = verifier.Verify(ctx, "challenge", "")
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Nil(t, result.Score)
+
+	result, err = verifier.Verify(ctx, "bot", "")
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, []string{"invalid-input-response"}, result.ErrorCodes)
+
+	_, err = verifier.Verify(ctx, "unavailable", "")
+	assert.Error(t, err)
+}
+
+func TestNewHumanVerifier(t *testing.T) {
+	ctx := context.Background()
+
+	verifier, err := captcha.NewHumanVerifier(captcha.Config{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, verifier)
+
+	_, err = captcha.NewHumanVerifier(captcha.Config{Provider: "unknown"}, nil)
+	assert.ErrorIs(t, err, captcha.ErrUnsupportedProvider)
+
+	verifier, err = captcha.NewHumanVerifier(captcha.Config{Provider: captcha.ProviderAlwaysPass}, nil)
+	assert.NoError(t, err)
+	result, _ := verifier.Verify(ctx, "", "")
+	assert.True(t, result.Success)
+
+	verifier, err = captcha.NewHumanVerifier(captcha.Config{Provider: captcha.ProviderAlwaysFail}, nil)
+	assert.NoError(t, err)
+	result, _ = verifier.Verify(ctx, "", "")
+	assert.False(t, result.Success)
+}
@@ -0,0 +1,161 @@
+package clientinfo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+type contextKey struct{}
+
+// The maximum lengths of the client's properties, in characters. They are the sizes of the columns that store them.
+const (
+	MaxIPAddressLength = 64
+	MaxUserAgentLength = 512
+	MaxDeviceLength    = 255
+)
+
+// trustedProxyHops is the number of proxies in front of the service that append the client's ip address to X-Forwarded-For header.
+var trustedProxyHops int
+
+// SetTrustedProxyHops replaces the number of the trusted proxies, the default is none. It must be called before the requests are served.
+// The ip address is taken from X-Forwarded-For header only when there is a trusted proxy, otherwise the header is sent by the client and can be forged.
+func SetTrustedProxyHops(hops int) {
+	trustedProxyHops = hops
+}
+
+// ClientInfo is a set of properties that describes the client of the incoming request.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	Device    string
+	DeviceID  string
+	Country   string
+}
+
+// FromRequest extracts the client's properties from the request. The ip address is taken from the entry of X-Forwarded-For header that is appended by the outermost trusted proxy.
+// The country is resolved by the load balancer from the ip address and is sent in X-Country-Code header, it is left empty when it is not an ISO 3166-1 alpha-2 code.
+// The country is read only when there is a trusted proxy, otherwise the header is sent by the client and can be forged.
+// The headers are sent by the client, so they are truncated to the maximum lengths.
+func FromRequest(r *http.Request) ClientInfo {
+	ci := ClientInfo{
+		IPAddress: Truncate(ipAddress(r), MaxIPAddressLength),
+		UserAgent: Truncate(r.UserAgent(), MaxUserAgentLength),
+		Device:    Truncate(strings.TrimSpace(r.Header.Get("X-Device-Name")), MaxDeviceLength),
+		DeviceID:  strings.TrimSpace(r.Header.Get("X-Device-ID")),
+	}
+
+	if trustedProxyHops > 0 {
+		ci.Country = countryCode(r.Header.Get("X-Country-Code"))
+	}
+
+	if ci.Device == "" {
+		ci.Device = deviceFromUserAgent(ci.UserAgent)
+	}
+
+	return ci
+}
+
+// Fingerprint identifies the device of the client. The device's id that is sent by the app is preferred, otherwise the user agent is used.
+func (ci ClientInfo) Fingerprint() string {
+	source := "ua:" + ci.UserAgent
+	if ci.DeviceID != "" {
+		source = "id:" + ci.DeviceID
+	}
+
+	sum := sha256.Sum256([]byte(source))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// NewContext returns a copy of the parent context that carries the client's properties.
+func NewContext(ctx context.Context, ci ClientInfo) context.Context {
+	return context.WithValue(ctx, contextKey{}, ci)
+}
+
+// FromContext returns the client's properties from the context. It returns an empty value if the context does not carry it.
+func FromContext(ctx context.Context) ClientInfo {
+	if ctx == nil {
+		return ClientInfo{}
+	}
+
+	ci, _ := ctx.Value(contextKey{}).(ClientInfo)
+
+	return ci
+}
+
+// Truncate cuts the value to the maximum number of characters, a multi-byte character is not split.
+func Truncate(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+
+	runes := []rune(value)
+
+	return string(runes[:max])
+}
+
+func countryCode(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) != 2 || value[0] < 'A' || value[0] > 'Z' || value[1] < 'A' || value[1] > 'Z' {
+		return ""
+	}
+
+	return value
+}
+
+func ipAddress(r *http.Request) string {
+	if trustedProxyHops > 0 {
+		var entries []string
+		for _, xff := range r.Header.Values("X-Forwarded-For") {
+			for _, entry := range strings.Split(xff, ",") {
+				if entry = strings.TrimSpace(entry); entry != "" {
+					entries = append(entries, entry)
+				}
+			}
+		}
+
+		// the entries on the left of the trusted proxies are sent by the client.
+		if len(entries) > 0 {
+			idx := len(entries) - trustedProxyHops
+			if idx < 0 {
+				idx = 0
+			}
+			return entries[idx]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func deviceFromUserAgent(ua string) string {
+	platforms := []struct {
+		keyword string
+		name    string
+	}{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "Mac"},
+		{"CrOS", "Chrome OS"},
+		{"Linux", "Linux"},
+	}
+
+	for _, p := range platforms {
+		if strings.Contains(ua, p.keyword) {
+			return p.name
+		}
+	}
+
+	return "Unknown"
+}
@@ -0,0 +1,58 @@
+package clientinfo_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+)
+
+func TestIPAddress(t *testing.T) {
+	defer clientinfo.SetTrustedProxyHops(0)
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.RemoteAddr = "10.0.0.2:44321"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.7")
+	r.Header.Set("X-Real-IP", "1.1.1.1")
+
+	// the forwarded headers are ignored without a trusted proxy.
+	clientinfo.SetTrustedProxyHops(0)
+	assert.Equal(t, "10.0.0.2", clientinfo.FromRequest(r).IPAddress)
+
+	// the load balancer appends the address it is connected from, the entries on its left are forged by the client.
+	clientinfo.SetTrustedProxyHops(1)
+	assert.Equal(t, "203.0.113.7", clientinfo.FromRequest(r).IPAddress)
+
+	clientinfo.SetTrustedProxyHops(2)
+	assert.Equal(t, "1.1.1.1", clientinfo.FromRequest(r).IPAddress)
+
+	r.Header.Del("X-Forwarded-For")
+	assert.Equal(t, "10.0.0.2", clientinfo.FromRequest(r).IPAddress)
+}
+
+func TestFromRequest(t *testing.T) {
+	defer clientinfo.SetTrustedProxyHops(0)
+	clientinfo.SetTrustedProxyHops(1)
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("User-Agent", strings.Repeat("é", 600))
+	r.Header.Set("X-Device-Name", strings.Repeat("a", 300))
+	r.Header.Set("X-Country-Code", " id ")
+
+	ci := clientinfo.FromRequest(r)
+	assert.Equal(t, strings.Repeat("é", clientinfo.MaxUserAgentLength), ci.UserAgent)
+	assert.Equal(t, strings.Repeat("a", clientinfo.MaxDeviceLength), ci.Device)
+	assert.Equal(t, "ID", ci.Country)
+
+	for _, country := range []string{"IDN", "1D", "Ä", "x"} {
+		r.Header.Set("X-Country-Code", country)
+		assert.Empty(t, clientinfo.FromRequest(r).Country, country)
+	}
+
+	// the country is ignored without a trusted proxy.
+	clientinfo.SetTrustedProxyHops(0)
+	r.Header.Set("X-Country-Code", "ID")
+	assert.Empty(t, clientinfo.FromRequest(r).Country)
+}
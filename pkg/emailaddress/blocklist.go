@@ -0,0 +1,157 @@
+package emailaddress
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Blocklist is the list of blocked domains, e.g. the disposable email providers. A domain blocks its subdomains as well.
+// The domains are read from a file with one domain per line, the lines starting with # are comments.
+type Blocklist struct {
+	logger  *logrus.Logger
+	path    string
+	static  []string
+	mu      sync.RWMutex
+	domains map[string]struct{}
+	modTime time.Time
+}
+
+// NewBlocklist creates the blocklist of the given domains and the domains of the file. The file is optional, it is not read when the path is empty.
+func NewBlocklist(logger *logrus.Logger, path string, domains []string) (*Blocklist, error) {
+	b := &Blocklist{
+		logger: logger,
+		path:   path,
+		static: domains,
+	}
+
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Reload reads the file again and replaces the blocked domains. The existing domains are kept when the file is unreadable.
+func (b *Blocklist) Reload() error {
+	domains := make(map[string]struct{})
+	for _, d := range b.static {
+		if d = normalizeDomain(d); d != "" {
+			domains[d] = struct{}{}
+		}
+	}
+
+	var modTime time.Time
+	if b.path != "" {
+		f, err := os.Open(b.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		modTime = info.ModTime()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if d := normalizeDomain(line); d != "" {
+				domains[d] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	b.domains = domains
+	b.modTime = modTime
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the file whenever it is modified, it is checked every interval until the context is done.
+func (b *Blocklist) Watch(ctx context.Context, interval time.Duration) {
+	if b.path == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(b.path)
+			if err != nil {
+				b.logger.WithContext(ctx).WithError(err).Error()
+				continue
+			}
+
+			b.mu.RLock()
+			modified := !info.ModTime().Equal(b.modTime)
+			b.mu.RUnlock()
+
+			if !modified {
+				continue
+			}
+
+			if err := b.Reload(); err != nil {
+				b.logger.WithContext(ctx).WithError(err).Error()
+				continue
+			}
+
+			b.logger.WithContext(ctx).WithField("path", b.path).WithField("domains", b.Len()).Info("blocklist is reloaded")
+		}
+	}
+}
+
+// Contains tells whether the domain of the email, or one of its parent domains, is blocked.
+func (b *Blocklist) Contains(email string) bool {
+	domain := Domain(email)
+	if domain == "" {
+		return false
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for {
+		if _, ok := b.domains[domain]; ok {
+			return true
+		}
+
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// Len returns the number of the blocked domains.
+func (b *Blocklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.domains)
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
+}
@@ -0,0 +1,63 @@
+package emailaddress
+
+import (
+	"strings"
+)
+
+// gmailDomains are the domains of the same gmail mailboxes, the first one is canonical.
+var gmailDomains = []string{"gmail.com", "googlemail.com"}
+
+// Options are the optional rules of the canonicalisation.
+type Options struct {
+	// GmailRules removes the dots and the plus suffix of the local part of a gmail address, gmail delivers them to the same mailbox.
+	GmailRules bool
+}
+
+// Canonicalize returns the form of the email that is stored and looked up, so the addresses of the same mailbox are considered equal.
+// The email is trimmed and lower-cased. The email without a domain is returned trimmed and lower-cased only.
+func Canonicalize(email string, opts Options) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		return email
+	}
+
+	local, domain := email[:at], email[at+1:]
+
+	if opts.GmailRules && isGmail(domain) {
+		if plus := strings.Index(local, "+"); plus >= 0 {
+			local = local[:plus]
+		}
+		local = strings.ReplaceAll(local, ".", "")
+		domain = gmailDomains[0]
+
+		if local == "" {
+			return email
+		}
+	}
+
+	return local + "@" + domain
+}
+
+// Domain returns the lower-cased domain of the email, or empty string when the email does not have one.
+func Domain(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+
+	return email[at+1:]
+}
+
+func isGmail(domain string) bool {
+	for _, d := range gmailDomains {
+		if domain == d {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package emailaddress_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
+)
+
+func TestCanonicalize(t *testing.T) {
+	cases := []struct {
+		email    string
+		opts     emailaddress.Options
+		expected string
+	}{
+		{email: "  Foo@X.com ", expected: "foo@x.com"},
+		{email: "J.Doe+tickets@Gmail.com", expected: "j.doe+tickets@gmail.com"},
+		{email: "J.Doe+tickets@Gmail.com", opts: emailaddress.Options{GmailRules: true}, expected: "jdoe@gmail.com"},
+		{email: "j.doe@googlemail.com", opts: emailaddress.Options{GmailRules: true}, expected: "jdoe@gmail.com"},
+		{email: "j.doe+x@example.com", opts: emailaddress.Options{GmailRules: true}, expected: "j.doe+x@example.com"},
+		{email: "not-an-email", expected: "not-an-email"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, emailaddress.Canonicalize(c.email, c.opts), c.email)
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disposable.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# disposable\nmailinator.com\n\n"), 0o644))
+
+	b, err := emailaddress.NewBlocklist(logrus.New(), path, []string{"@Trash.Example"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, b.Len())
+
+	assert.True(t, b.Contains("bot@mailinator.com"))
+	assert.True(t, b.Contains("bot@eu.MAILINATOR.com"))
+	assert.True(t, b.Contains("bot@trash.example"))
+	assert.False(t, b.Contains("fan@example.com"))
+	assert.False(t, b.Contains("not-an-email"))
+
+	assert.NoError(t, os.WriteFile(path, []byte("yopmail.com\n"), 0o644))
+	assert.NoError(t, b.Reload())
+	assert.False(t, b.Contains("bot@mailinator.com"))
+	assert.True(t, b.Contains("bot@yopmail.com"))
+
+	_, err = emailaddress.NewBlocklist(logrus.New(), filepath.Join(t.TempDir(), "missing.txt"), nil)
+	assert.Error(t, err)
+}
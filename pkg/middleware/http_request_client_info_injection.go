@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+)
+
+func HTTPRequestClientInfoInjection(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := clientinfo.NewContext(r.Context(), clientinfo.FromRequest(r))
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/captcha"
+	"github.com/tsel-ticketmaster/tm-user/pkg/response"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+const (
+	// CaptchaTokenHeader carries the token of the primary, usually invisible, captcha.
+	CaptchaTokenHeader = "X-Captcha-Token"
+	// CaptchaChallengeTokenHeader carries the token of the stricter challenge that is solved after the primary token is escalated.
+	CaptchaChallengeTokenHeader = "X-Captcha-Challenge-Token"
+)
+
+type HumanVerification struct {
+	logger    *logrus.Logger
+	verifier  captcha.HumanVerifier
+	challenge captcha.HumanVerifier
+	policies  map[string]captcha.Policy
+}
+
+// NewHumanVerification creates the middleware that verifies the captcha token of the routes. The verification is turned off when the verifier is nil.
+// The challenge is the stricter verifier that the request with a low score is escalated to, it is optional.
+// The policies are keyed by the action of the route, the route without a policy is verified without a score threshold.
+func NewHumanVerification(logger *logrus.Logger, verifier, challenge captcha.HumanVerifier, policies map[string]captcha.Policy) *HumanVerification {
+	if policies == nil {
+		policies = make(map[string]captcha.Policy)
+	}
+
+	return &HumanVerification{
+		logger:    logger,
+		verifier:  verifier,
+		challenge: challenge,
+		policies:  policies,
+	}
+}
+
+// Verify returns a route middleware that requires a captcha token for the action, e.g. signup. The request is rejected when the provider is unavailable, unless the policy fails open.
+func (hv *HumanVerification) Verify(action string) func(http.HandlerFunc) http.HandlerFunc {
+	policy := hv.policies[action]
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if hv.verifier == nil || policy.Disabled {
+			return next
+		}
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			remoteIP := KeyByIP(r)
+
+			if token := r.Header.Get(CaptchaChallengeTokenHeader); token != "" && hv.challenge != nil {
+				result, err := hv.challenge.Verify(ctx, token, remoteIP)
+				if err != nil {
+					hv.unavailable(w, r, next, policy, err)
+					return
+				}
+
+				if !result.Success || !policy.AllowsHostname(result.Hostname) {
+					hv.reject(w, r, action, status.HUMAN_VERIFICATION_FAILED, "captcha challenge is invalid or expired", result)
+					return
+				}
+
+				next(w, r)
+				return
+			}
+
+			token := r.Header.Get(CaptchaTokenHeader)
+			if token == "" {
+				response.JSON(w, http.StatusForbidden, response.RESTEnvelope{
+					Status:  status.HUMAN_VERIFICATION_REQUIRED,
+					Message: "captcha token is required",
+				})
+				return
+			}
+
+			result, err := hv.verifier.Verify(ctx, token, remoteIP)
+			if err != nil {
+				hv.unavailable(w, r, next, policy, err)
+				return
+			}
+
+			// the token that is solved for another action or site must not be reused, e.g. the one of sign in for sign up.
+			// the scored token always carries its action, only the unscored one, e.g. an interactive widget, may leave it out.
+			if !result.Success || !policy.AllowsHostname(result.Hostname) || (result.Action != action && (result.Action != "" || result.Score != nil)) {
+				hv.reject(w, r, action, status.HUMAN_VERIFICATION_FAILED, "captcha token is invalid or expired", result)
+				return
+			}
+
+			if policy.MinScore > 0 && result.Score != nil && *result.Score < policy.MinScore {
+				if hv.challenge == nil {
+					hv.reject(w, r, action, status.HUMAN_VERIFICATION_FAILED, "captcha verification is failed", result)
+					return
+				}
+
+				hv.reject(w, r, action, status.HUMAN_CHALLENGE_REQUIRED, "captcha challenge is required", result)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// unavailable handles the error of the provider. The request is rejected unless the policy fails open.
+func (hv *HumanVerification) unavailable(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, policy captcha.Policy, err error) {
+	hv.logger.WithContext(r.Context()).WithError(err).Error()
+
+	if policy.FailOpen {
+		next(w, r)
+		return
+	}
+
+	response.JSON(w, http.StatusBadGateway, response.RESTEnvelope{
+		Status:  status.BAD_GATEWAY,
+		Message: "captcha verification is unavailable",
+	})
+}
+
+func (hv *HumanVerification) reject(w http.ResponseWriter, r *http.Request, action, st, message string, result captcha.Result) {
+	entry := hv.logger.WithContext(r.Context()).WithField("action", action).WithField("error_codes", result.ErrorCodes)
+	if result.Score != nil {
+		entry = entry.WithField("score", *result.Score)
+	}
+	entry.Warn(message)
+
+	response.JSON(w, http.StatusForbidden, response.RESTEnvelope{
+		Status:  st,
+		Message: message,
+	})
+}
@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/captcha"
+	"github.com/tsel-ticketmaster/tm-user/pkg/middleware"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+type unavailableVerifier struct{}
+
+func (v unavailableVerifier) Verify(ctx context.Context, token, remoteIP string) (captcha.Result, error) {
+	return captcha.Result{}, fmt.Errorf("verify endpoint responds with status 503")
+}
+
+func TestHumanVerification(t *testing.T) {
+	logger := logrus.New()
+	score := 0.9
+
+	cases := []struct {
+		name     string
+		verifier captcha.HumanVerifier
+		policy   captcha.Policy
+		expected int
+	}{
+		{name: "verified", verifier: captcha.StaticVerifier{Result: captcha.Result{Success: true, Score: &score, Action: "signup", Hostname: "ticketmaster.example"}}, policy: captcha.Policy{Hostnames: []string{"ticketmaster.example"}}, expected: http.StatusOK},
+		{name: "unscored without action", verifier: captcha.AlwaysPass(), expected: http.StatusOK},
+		{name: "other action", verifier: captcha.StaticVerifier{Result: captcha.Result{Success: true, Score: &score, Action: "signin"}}, expected: http.StatusForbidden},
+		{name: "scored without action", verifier: captcha.StaticVerifier{Result: captcha.Result{Success: true, Score: &score}}, expected: http.StatusForbidden},
+		{name: "other hostname", verifier: captcha.StaticVerifier{Result: captcha.Result{Success: true, Score: &score, Action: "signup", Hostname: "evil.example"}}, policy: captcha.Policy{Hostnames: []string{"ticketmaster.example"}}, expected: http.StatusForbidden},
+		{name: "unavailable", verifier: unavailableVerifier{}, expected: http.StatusBadGateway},
+		{name: "unavailable fails open", verifier: unavailableVerifier{}, policy: captcha.Policy{FailOpen: true}, expected: http.StatusOK},
+	}
+
+	for _, c := range cases {
+		hv := middleware.NewHumanVerification(logger, c.verifier, nil, map[string]captcha.Policy{"signup": c.policy})
+		handler := hv.Verify("signup")(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		r := httptest.NewRequest(http.MethodPost, "/signup", nil)
+		r.Header.Set(middleware.CaptchaTokenHeader, "token")
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		assert.Equal(t, c.expected, w.Code, c.name)
+	}
+}
+
+func TestHumanChallengeRetryWithIdempotencyKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	logger := logrus.New()
+
+	score := 0.1
+	verifier := captcha.StaticVerifier{Result: captcha.Result{Success: true, Score: &score, Action: "signup"}}
+	hv := middleware.NewHumanVerification(logger, verifier, captcha.AlwaysPass(), map[string]captcha.Policy{"signup": {MinScore: 0.5}})
+	idempotency := middleware.NewIdempotency(logger, rc, "secret", time.Hour)
+
+	calls := 0
+	handler := middleware.SetRouteChain(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}, hv.Verify("signup"), idempotency.Handle(nil))
+
+	send := func(header string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"email":"john@example.com"}`))
+		r.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		r.Header.Set(header, "token")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	w := send(middleware.CaptchaTokenHeader)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), status.HUMAN_CHALLENGE_REQUIRED)
+	assert.Equal(t, 0, calls)
+
+	// the rejection is not stored, so the retry with the solved challenge reaches the handler.
+	w = send(middleware.CaptchaChallengeTokenHeader)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, calls)
+
+	w = send(middleware.CaptchaChallengeTokenHeader)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/response"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+var idempotencyKeyPrefix string = "idempotency:%s"
+
+const (
+	// IdempotencyKeyHeader is the header that is sent by the client to make the request safe to retry.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on the stored response that is sent back to a retry.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyMaxLength = 255
+	// idempotencyLockTTL releases the key of a request that never completes, e.g. the instance is shut down in the middle of it.
+	idempotencyLockTTL = time.Minute
+)
+
+const (
+	idempotencyStateInFlight  = "IN_FLIGHT"
+	idempotencyStateCompleted = "COMPLETED"
+)
+
+// IdempotencyCallerFunc identifies the caller, so the same key of different callers does not collide. An empty caller skips the idempotency.
+type IdempotencyCallerFunc func(r *http.Request) string
+
+type idempotentResponse struct {
+	State       string `json:"state"`
+	RequestHash string `json:"request_hash"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+type Idempotency struct {
+	logger *logrus.Logger
+	r      redis.UniversalClient
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewIdempotency creates the idempotency that keeps the first response of the key for the given ttl.
+// The request is stored as a hash keyed with the secret, since the body may carry a password that must not be guessed from redis.
+func NewIdempotency(logger *logrus.Logger, r redis.UniversalClient, secret string, ttl time.Duration) *Idempotency {
+	if ttl <= 0 {
+		ttl = time.Hour * 24
+	}
+
+	return &Idempotency{
+		logger: logger,
+		r:      r,
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// Handle returns a route middleware that honours the Idempotency-Key header of POST and PATCH requests.
+// The first response of the key, route and caller is stored, so the retries receive the same response instead of executing the request again.
+// A retry that arrives while the first request is still in flight is rejected with 409. The request is passed through when redis is unavailable.
+// It must be chained after the middlewares that reject the request on their own, e.g. the human verification, otherwise the rejection is replayed to the retries.
+func (i *Idempotency) Handle(caller IdempotencyCallerFunc) func(http.HandlerFunc) http.HandlerFunc {
+	if caller == nil {
+		caller = KeyByDevice
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next(w, r)
+				return
+			}
+
+			if len(key) > idempotencyKeyMaxLength {
+				response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+					Status:  status.BAD_REQUEST,
+					Message: fmt.Sprintf("idempotency key must not exceed %d characters", idempotencyKeyMaxLength),
+				})
+				return
+			}
+
+			who := caller(r)
+			if who == "" {
+				next(w, r)
+				return
+			}
+
+			buf, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxKeyBodySize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					response.JSON(w, http.StatusRequestEntityTooLarge, response.RESTEnvelope{
+						Status:  status.PAYLOAD_TOO_LARGE,
+						Message: fmt.Sprintf("request body must not be larger than %d bytes", maxKeyBodySize),
+					})
+					return
+				}
+
+				response.JSON(w, http.StatusBadRequest, response.RESTEnvelope{
+					Status:  status.BAD_REQUEST,
+					Message: "request body is unreadable",
+				})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewBuffer(buf))
+
+			redisKey := fmt.Sprintf(idempotencyKeyPrefix, i.hashOf(who, r.Method, r.URL.Path, key))
+			requestHash := i.hashOf(string(buf))
+
+			inFlight, _ := json.Marshal(idempotentResponse{State: idempotencyStateInFlight, RequestHash: requestHash})
+			acquired, err := i.r.SetNX(ctx, redisKey, inFlight, idempotencyLockTTL).Result()
+			if err != nil {
+				i.logger.WithContext(ctx).WithError(err).Error()
+				next(w, r)
+				return
+			}
+
+			if !acquired {
+				i.replay(w, r, redisKey, requestHash)
+				return
+			}
+
+			recorder := httptest.NewRecorder()
+			next(wrappedResponseWriter{w, recorder}, r)
+
+			result := recorder.Result()
+			defer result.Body.Close()
+
+			// the failures of the server and the rate limit are not final, so the client is able to retry them with the same key.
+			if result.StatusCode >= http.StatusInternalServerError || result.StatusCode == http.StatusTooManyRequests {
+				if err := i.r.Del(ctx, redisKey).Err(); err != nil {
+					i.logger.WithContext(ctx).WithError(err).Error()
+				}
+				return
+			}
+
+			body, _ := io.ReadAll(result.Body)
+			completed, _ := json.Marshal(idempotentResponse{
+				State:       idempotencyStateCompleted,
+				RequestHash: requestHash,
+				StatusCode:  result.StatusCode,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        body,
+			})
+			if err := i.r.Set(ctx, redisKey, completed, i.ttl).Err(); err != nil {
+				i.logger.WithContext(ctx).WithError(err).Error()
+			}
+		}
+	}
+}
+
+// replay sends back the stored response of the key.
+func (i *Idempotency) replay(w http.ResponseWriter, r *http.Request, redisKey, requestHash string) {
+	ctx := r.Context()
+
+	buff, err := i.r.Get(ctx, redisKey).Bytes()
+	if err != nil && err != redis.Nil {
+		i.logger.WithContext(ctx).WithError(err).Error()
+		response.JSON(w, http.StatusInternalServerError, response.RESTEnvelope{
+			Status:  status.INTERNAL_SERVER_ERROR,
+			Message: "an error occured while processing the request",
+		})
+		return
+	}
+
+	var stored idempotentResponse
+	if err == nil {
+		json.Unmarshal(buff, &stored)
+	}
+
+	// the key has just been released by the first request, e.g. it failed, so the retry is treated as still in flight.
+	if stored.State != idempotencyStateCompleted {
+		response.JSON(w, http.StatusConflict, response.RESTEnvelope{
+			Status:  status.REQUEST_IN_PROGRESS,
+			Message: "a request with the same idempotency key is still in progress",
+		})
+		return
+	}
+
+	if stored.RequestHash != requestHash {
+		response.JSON(w, http.StatusUnprocessableEntity, response.RESTEnvelope{
+			Status:  status.UNPROCESSABLE_ENTITY,
+			Message: "idempotency key has been used by a different request",
+		})
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	w.Write(stored.Body)
+}
+
+// KeyByDevice identifies the requester by the device id of the client, or by the ip address when the client does not send it.
+func KeyByDevice(r *http.Request) string {
+	info := clientinfo.FromContext(r.Context())
+	if info.IPAddress == "" {
+		info = clientinfo.FromRequest(r)
+	}
+
+	if info.DeviceID != "" {
+		return "device:" + info.DeviceID
+	}
+
+	return "ip:" + info.IPAddress
+}
+
+func (i *Idempotency) hashOf(values ...string) string {
+	h := hmac.New(sha256.New, i.secret)
+	for _, v := range values {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/middleware"
+)
+
+func TestIdempotencyKeysTheRequestHash(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	idempotency := middleware.NewIdempotency(logrus.New(), rc, "secret", time.Hour)
+	handler := idempotency.Handle(nil)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+		r.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusCreated, send(`{"password":"Secret123!"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, send(`{"password":"Secret124!"}`).Code)
+
+	// the unkeyed hash of the body is enough to guess the password offline.
+	sum := sha256.Sum256([]byte("{\"password\":\"Secret123!\"}\x00"))
+	for _, key := range mr.Keys() {
+		value, _ := mr.Get(key)
+		assert.NotContains(t, value, "Secret123!")
+		assert.NotContains(t, value, hex.EncodeToString(sum[:]))
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(strings.Repeat("a", 2<<20)))
+	r.Header.Set(middleware.IdempotencyKeyHeader, "key-2")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
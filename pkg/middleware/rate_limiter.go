@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tsel-ticketmaster/tm-user/pkg/clientinfo"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
+	"github.com/tsel-ticketmaster/tm-user/pkg/response"
+	"github.com/tsel-ticketmaster/tm-user/pkg/status"
+)
+
+var rateLimitKeyPrefix string = "ratelimit:%s:%s"
+
+// emailKeyOptions canonicalise the email of KeyByEmailField, they must be the same as the use case's ones.
+var emailKeyOptions emailaddress.Options
+
+// SetEmailKeyOptions replaces the canonicalisation of the email that identifies the requester. It must be called before the requests are served.
+func SetEmailKeyOptions(opts emailaddress.Options) {
+	emailKeyOptions = opts
+}
+
+// maxKeyBodySize is the largest body that is read to find the key, the larger body is not a valid request of the routes anyway.
+const maxKeyBodySize = 1 << 20
+
+// RateLimitAlgorithm is the algorithm that is used to count the requests.
+type RateLimitAlgorithm string
+
+const (
+	// SlidingWindow allows at most Limit requests within any Period long window.
+	SlidingWindow RateLimitAlgorithm = "SLIDING_WINDOW"
+	// TokenBucket allows a burst of Limit requests and refills the bucket evenly within a Period.
+	TokenBucket RateLimitAlgorithm = "TOKEN_BUCKET"
+)
+
+// RateLimitKeyFunc extracts the identity of the requester. An empty key skips the rate limit.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitRule describes how a route is limited.
+type RateLimitRule struct {
+	// Name identifies the rule, requests of different rules are counted separately.
+	Name      string
+	Limit     int
+	Period    time.Duration
+	Algorithm RateLimitAlgorithm
+	Key       RateLimitKeyFunc
+}
+
+type rateLimitResult struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// slidingWindowScript keeps the timestamp of every allowed request in a sorted set.
+// It returns allowed flag, remaining requests and the milliseconds until the oldest request leaves the window.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - period)
+
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, period)
+
+local reset = period
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + period - now
+end
+
+return {allowed, limit - count, reset}
+`)
+
+// tokenBucketScript stores the remaining tokens and the last refill time in a hash.
+// It returns allowed flag, remaining tokens and the milliseconds until the next token is available.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local rate = limit / period
+
+local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = limit
+	ts = now
+end
+
+tokens = math.min(limit, tokens + (now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, period)
+
+local wait = 0
+if tokens < 1 then
+	wait = math.ceil((1 - tokens) / rate)
+end
+
+return {allowed, math.floor(tokens), wait}
+`)
+
+type RateLimiter struct {
+	logger *logrus.Logger
+	r      redis.UniversalClient
+}
+
+func NewRateLimiter(logger *logrus.Logger, r redis.UniversalClient) *RateLimiter {
+	return &RateLimiter{
+		logger: logger,
+		r:      r,
+	}
+}
+
+func (rl *RateLimiter) take(ctx context.Context, rule RateLimitRule, key string) (rateLimitResult, error) {
+	now := time.Now()
+	redisKey := fmt.Sprintf(rateLimitKeyPrefix, rule.Name, key)
+	period := rule.Period.Milliseconds()
+
+	var (
+		values []int64
+		err    error
+	)
+
+	switch rule.Algorithm {
+	case TokenBucket:
+		values, err = tokenBucketScript.Run(ctx, rl.r, []string{redisKey}, now.UnixMilli(), period, rule.Limit).Int64Slice()
+	default:
+		member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63())
+		values, err = slidingWindowScript.Run(ctx, rl.r, []string{redisKey}, now.UnixMilli(), period, rule.Limit, member).Int64Slice()
+	}
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+
+	result := rateLimitResult{
+		allowed:   values[0] == 1,
+		remaining: int(math.Max(0, float64(values[1]))),
+		reset:     time.Duration(values[2]) * time.Millisecond,
+	}
+	if !result.allowed {
+		result.retryAfter = result.reset
+	}
+	if rule.Algorithm == TokenBucket {
+		// the bucket is completely refilled after a period without any request.
+		result.reset = time.Duration(float64(rule.Period) * float64(rule.Limit-result.remaining) / float64(rule.Limit))
+	}
+
+	return result, nil
+}
+
+// Limit returns a route middleware that limits the requests with the given rule. The request is passed through when redis is unavailable.
+func (rl *RateLimiter) Limit(rule RateLimitRule) func(http.HandlerFunc) http.HandlerFunc {
+	if rule.Key == nil {
+		rule.Key = KeyByIP
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			key := rule.Key(r)
+			if key == "" {
+				next(w, r)
+				return
+			}
+
+			result, err := rl.take(ctx, rule, key)
+			if err != nil {
+				rl.logger.WithContext(ctx).WithError(err).Error()
+				next(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(rule.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+
+			if !result.allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+				response.JSON(w, http.StatusTooManyRequests, response.RESTEnvelope{
+					Status:  status.TOO_MANY_REQUESTS,
+					Message: "too many requests, please try again later",
+				})
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// KeyByIP identifies the requester by the client's ip address.
+func KeyByIP(r *http.Request) string {
+	if info := clientinfo.FromContext(r.Context()); info.IPAddress != "" {
+		return info.IPAddress
+	}
+
+	return clientinfo.FromRequest(r).IPAddress
+}
+
+// KeyByHeader identifies the requester by the value of the given header.
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// KeyByJSONField identifies the requester by a top level string field of the json body, e.g. the email of a sign in request.
+// The body is restored so the next handler is able to read it, the body that exceeds the limit is cut.
+func KeyByJSONField(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if r.Body == nil {
+			return ""
+		}
+
+		buf, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxKeyBodySize))
+		r.Body = io.NopCloser(bytes.NewBuffer(buf))
+		if err != nil {
+			return ""
+		}
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(buf, &body); err != nil {
+			return ""
+		}
+
+		value, _ := body[name].(string)
+
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+}
+
+// KeyByEmailField identifies the requester by the email of the json body. The email is canonicalised, so the addresses of the same mailbox share the limit.
+func KeyByEmailField(name string) RateLimitKeyFunc {
+	key := KeyByJSONField(name)
+
+	return func(r *http.Request) string {
+		email := key(r)
+		if email == "" {
+			return ""
+		}
+
+		return emailaddress.Canonicalize(email, emailKeyOptions)
+	}
+}
@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/emailaddress"
+	"github.com/tsel-ticketmaster/tm-user/pkg/middleware"
+)
+
+func newRateLimitedHandler(t *testing.T, rule middleware.RateLimitRule) (http.HandlerFunc, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+
+	rl := middleware.NewRateLimiter(logrus.New(), rc)
+	handler := rl.Limit(rule)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return handler, mr
+}
+
+func sendRateLimited(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(body))
+	r.RemoteAddr = "10.0.0.1:44321"
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	return w
+}
+
+func TestRateLimiter(t *testing.T) {
+	for _, algorithm := range []middleware.RateLimitAlgorithm{middleware.SlidingWindow, middleware.TokenBucket} {
+		handler, _ := newRateLimitedHandler(t, middleware.RateLimitRule{
+			Name:      "test",
+			Limit:     2,
+			Period:    time.Minute,
+			Algorithm: algorithm,
+			Key:       middleware.KeyByIP,
+		})
+
+		w := sendRateLimited(handler, "")
+		assert.Equal(t, http.StatusOK, w.Code, algorithm)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"), algorithm)
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"), algorithm)
+
+		assert.Equal(t, http.StatusOK, sendRateLimited(handler, "").Code, algorithm)
+
+		w = sendRateLimited(handler, "")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, algorithm)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"), algorithm)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"), algorithm)
+		assert.NotEqual(t, "0", w.Header().Get("Retry-After"), algorithm)
+	}
+}
+
+func TestRateLimiterFailsOpen(t *testing.T) {
+	handler, mr := newRateLimitedHandler(t, middleware.RateLimitRule{
+		Name:   "test",
+		Limit:  1,
+		Period: time.Minute,
+		Key:    middleware.KeyByIP,
+	})
+
+	mr.Close()
+
+	assert.Equal(t, http.StatusOK, sendRateLimited(handler, "").Code)
+	assert.Equal(t, http.StatusOK, sendRateLimited(handler, "").Code)
+}
+
+func TestKeyByEmailField(t *testing.T) {
+	middleware.SetEmailKeyOptions(emailaddress.Options{GmailRules: true})
+	defer middleware.SetEmailKeyOptions(emailaddress.Options{})
+
+	handler, _ := newRateLimitedHandler(t, middleware.RateLimitRule{
+		Name:   "test",
+		Limit:  1,
+		Period: time.Minute,
+		Key:    middleware.KeyByEmailField("email"),
+	})
+
+	assert.Equal(t, http.StatusOK, sendRateLimited(handler, `{"email":"a.b+1@gmail.com"}`).Code)
+	assert.Equal(t, http.StatusTooManyRequests, sendRateLimited(handler, `{"email":"AB@googlemail.com"}`).Code)
+	assert.Equal(t, http.StatusOK, sendRateLimited(handler, `{"email":"cd@gmail.com"}`).Code)
+}
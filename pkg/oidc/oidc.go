@@ -0,0 +1,304 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Errors.
+var (
+	ErrInvalidIDToken error = fmt.Errorf("invalid id token")
+	ErrInvalidNonce   error = fmt.Errorf("invalid nonce of id token")
+)
+
+// Config is the registration of the relying party on the provider. The endpoints are discovered from the issuer when they are empty.
+type Config struct {
+	Name                  string   `json:"name"`
+	Issuer                string   `json:"issuer"`
+	ClientID              string   `json:"client_id"`
+	ClientSecret          string   `json:"client_secret"`
+	RedirectURL           string   `json:"redirect_url"`
+	Scopes                []string `json:"scopes"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+}
+
+// Token is the response of the token endpoint.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Claims is the identity of the end user that is asserted by the provider.
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is the relying party of an openid connect provider using authorization code flow with PKCE.
+type Provider struct {
+	cfg        Config
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	discovered bool
+	keys       map[string]interface{}
+}
+
+// NewProvider is a constructor. The discovery document and the keys are fetched lazily on the first use.
+func NewProvider(cfg Config, httpClient *http.Client) *Provider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Second * 10}
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &Provider{
+		cfg:        cfg,
+		httpClient: httpClient,
+		keys:       make(map[string]interface{}),
+	}
+}
+
+// Name returns the name of the provider.
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovered || (p.cfg.AuthorizationEndpoint != "" && p.cfg.TokenEndpoint != "" && p.cfg.JWKSURI != "") {
+		p.discovered = true
+		return nil
+	}
+
+	var d discovery
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &d); err != nil {
+		return err
+	}
+
+	if d.Issuer != p.cfg.Issuer {
+		return fmt.Errorf("issuer of discovery document %q does not match %q", d.Issuer, p.cfg.Issuer)
+	}
+
+	if p.cfg.AuthorizationEndpoint == "" {
+		p.cfg.AuthorizationEndpoint = d.AuthorizationEndpoint
+	}
+	if p.cfg.TokenEndpoint == "" {
+		p.cfg.TokenEndpoint = d.TokenEndpoint
+	}
+	if p.cfg.JWKSURI == "" {
+		p.cfg.JWKSURI = d.JWKSURI
+	}
+	p.discovered = true
+
+	return nil
+}
+
+// AuthCodeURL returns the url of the provider's consent page.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", p.cfg.ClientID)
+	values.Set("redirect_uri", p.cfg.RedirectURL)
+	values.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	values.Set("state", state)
+	values.Set("nonce", nonce)
+	values.Set("code_challenge", codeChallenge)
+	values.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.cfg.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return p.cfg.AuthorizationEndpoint + separator + values.Encode(), nil
+}
+
+// Exchange trades the authorization code for the tokens.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (Token, error) {
+	if err := p.discover(ctx); err != nil {
+		return Token{}, err
+	}
+
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", p.cfg.RedirectURL)
+	values.Set("client_id", p.cfg.ClientID)
+	values.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret != "" {
+		values.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.TokenEndpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return Token{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("token endpoint responds with status %d: %s", res.StatusCode, body)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return Token{}, err
+	}
+
+	if token.IDToken == "" {
+		return Token{}, fmt.Errorf("token endpoint does not return id token")
+	}
+
+	return token, nil
+}
+
+// VerifyIDToken validates the signature, issuer, audience, expiry and nonce of the id token.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	if err := p.discover(ctx); err != nil {
+		return Claims{}, err
+	}
+
+	var claims Claims
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+	token, err := parser.ParseWithClaims(rawIDToken, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil || !token.Valid {
+		return Claims{}, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyIssuer(p.cfg.Issuer, true) {
+		return Claims{}, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return Claims{}, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyExpiresAt(time.Now(), true) {
+		return Claims{}, ErrInvalidIDToken
+	}
+
+	if claims.Subject == "" {
+		return Claims{}, ErrInvalidIDToken
+	}
+
+	if claims.Nonce != nonce {
+		return Claims{}, ErrInvalidNonce
+	}
+
+	return claims, nil
+}
+
+// key returns the public key of the given key id. The keys are fetched again when the key id is unknown, so the rotation of the provider's keys is followed.
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set jwks
+	if err := p.getJSON(ctx, p.cfg.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys, err := set.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responds with status %d", rawURL, res.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
+
+// GenerateCodeVerifier returns a random PKCE code verifier.
+func GenerateCodeVerifier() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CodeChallengeS256 returns the PKCE code challenge of the code verifier.
+func CodeChallengeS256(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,166 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/oidc"
+)
+
+type identityProvider struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	codeChallenge string
+	nonce         string
+	audience      string
+}
+
+func newIdentityProvider(t *testing.T) *identityProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	idp := &identityProvider{key: key, audience: "client-id"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kid": "key-1",
+					"kty": "RSA",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "valid-code" || oidc.CodeChallengeS256(r.Form.Get("code_verifier")) != idp.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idp.sign(t, time.Now().Add(time.Hour)),
+		})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *identityProvider) sign(t *testing.T, expiresAt time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, oidc.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    idp.server.URL,
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{idp.audience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Nonce:         idp.nonce,
+		Email:         "patrick@example.com",
+		EmailVerified: true,
+		Name:          "Patrick Star",
+	})
+	token.Header["kid"] = "key-1"
+
+	signed, err := token.SignedString(idp.key)
+	assert.NoError(t, err)
+
+	return signed
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp := newIdentityProvider(t)
+	ctx := context.Background()
+
+	provider := oidc.NewProvider(oidc.Config{
+		Name:        "local",
+		Issuer:      idp.server.URL,
+		ClientID:    "client-id",
+		RedirectURL: "https://tm.example.com/callback",
+	}, idp.server.Client())
+
+	codeVerifier := oidc.GenerateCodeVerifier()
+	idp.codeChallenge = oidc.CodeChallengeS256(codeVerifier)
+	idp.nonce = "nonce-1"
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", idp.nonce, idp.codeChallenge)
+	assert.NoError(t, err)
+
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, idp.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "state-1", u.Query().Get("state"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+
+	_, err = provider.Exchange(ctx, "valid-code", oidc.GenerateCodeVerifier())
+	assert.Error(t, err)
+
+	token, err := provider.Exchange(ctx, "valid-code", codeVerifier)
+	assert.NoError(t, err)
+
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "patrick@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+
+	_, err = provider.VerifyIDToken(ctx, token.IDToken, "other-nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidNonce)
+}
+
+func TestVerifyIDTokenRejectsInvalidToken(t *testing.T) {
+	idp := newIdentityProvider(t)
+	ctx := context.Background()
+	idp.nonce = "nonce-1"
+
+	provider := oidc.NewProvider(oidc.Config{
+		Name:     "local",
+		Issuer:   idp.server.URL,
+		ClientID: "client-id",
+	}, idp.server.Client())
+
+	_, err := provider.VerifyIDToken(ctx, idp.sign(t, time.Now().Add(-time.Minute)), "nonce-1")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+	idp.audience = "other-client"
+	_, err = provider.VerifyIDToken(ctx, idp.sign(t, time.Now().Add(time.Hour)), "nonce-1")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	idp.audience = "client-id"
+	idp.key, otherKey = otherKey, idp.key
+	forged := idp.sign(t, time.Now().Add(time.Hour))
+	idp.key = otherKey
+	_, err = provider.VerifyIDToken(ctx, forged, "nonce-1")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
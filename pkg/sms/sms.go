@@ -0,0 +1,104 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+)
+
+// Message is a short message to a phone number in E.164 format.
+type Message struct {
+	MSISDN string    `json:"msisdn"`
+	Body   string    `json:"body"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// SMSSender delivers the short message to the phone number.
+type SMSSender interface {
+	Send(ctx context.Context, msisdn, body string) error
+}
+
+// InMemorySender keeps every sent message instead of delivering it. It is meant for tests and local development.
+type InMemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewInMemorySender is a constructor.
+func NewInMemorySender() *InMemorySender {
+	return &InMemorySender{}
+}
+
+// Send implements SMSSender.
+func (s *InMemorySender) Send(ctx context.Context, msisdn, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, Message{
+		MSISDN: msisdn,
+		Body:   body,
+		SentAt: time.Now(),
+	})
+
+	return nil
+}
+
+// Messages returns the sent messages in the order they are sent.
+func (s *InMemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]Message, len(s.messages))
+	copy(messages, s.messages)
+
+	return messages
+}
+
+// Last returns the last message that is sent to the phone number.
+func (s *InMemorySender) Last(msisdn string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].MSISDN == msisdn {
+			return s.messages[i], true
+		}
+	}
+
+	return Message{}, false
+}
+
+type pubsubSender struct {
+	publisher pubsub.Publisher
+	topic     string
+	origin    string
+}
+
+// NewPubSubSender returns the sender that publishes the message to the topic, the delivery is done by the consumer of the topic.
+func NewPubSubSender(publisher pubsub.Publisher, topic, origin string) SMSSender {
+	return &pubsubSender{
+		publisher: publisher,
+		topic:     topic,
+		origin:    origin,
+	}
+}
+
+// Send implements SMSSender.
+func (s *pubsubSender) Send(ctx context.Context, msisdn, body string) error {
+	message := Message{
+		MSISDN: msisdn,
+		Body:   body,
+		SentAt: time.Now(),
+	}
+
+	messageBuff, _ := json.Marshal(message)
+
+	messageHeader := pubsub.MessageHeaders{
+		"origin": s.origin,
+	}
+
+	return s.publisher.Publish(ctx, s.topic, msisdn, messageHeader, messageBuff)
+}
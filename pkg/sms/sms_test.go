@@ -0,0 +1,58 @@
+package sms_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/pubsub"
+	"github.com/tsel-ticketmaster/tm-user/pkg/sms"
+)
+
+type publisher struct {
+	topic   string
+	key     string
+	message []byte
+}
+
+func (p *publisher) Publish(ctx context.Context, topic string, key string, headers pubsub.MessageHeaders, message []byte) error {
+	p.topic, p.key, p.message = topic, key, message
+	return nil
+}
+
+func (p *publisher) Close() error {
+	return nil
+}
+
+func TestInMemorySender(t *testing.T) {
+	sender := sms.NewInMemorySender()
+	ctx := context.Background()
+
+	_, ok := sender.Last("+6281234567890")
+	assert.False(t, ok)
+
+	assert.NoError(t, sender.Send(ctx, "+6281234567890", "first"))
+	assert.NoError(t, sender.Send(ctx, "+6289876543210", "other"))
+	assert.NoError(t, sender.Send(ctx, "+6281234567890", "second"))
+
+	assert.Len(t, sender.Messages(), 3)
+
+	last, ok := sender.Last("+6281234567890")
+	assert.True(t, ok)
+	assert.Equal(t, "second", last.Body)
+}
+
+func TestPubSubSender(t *testing.T) {
+	p := &publisher{}
+	sender := sms.NewPubSubSender(p, "sms-outbound", "tm-user")
+
+	assert.NoError(t, sender.Send(context.Background(), "+6281234567890", "hello"))
+	assert.Equal(t, "sms-outbound", p.topic)
+	assert.Equal(t, "+6281234567890", p.key)
+
+	var message sms.Message
+	assert.NoError(t, json.Unmarshal(p.message, &message))
+	assert.Equal(t, "+6281234567890", message.MSISDN)
+	assert.Equal(t, "hello", message.Body)
+}
@@ -1,17 +1,30 @@
 package status
 
 const (
-	OK                    = "OK"
-	CREATED               = "CREATED"
-	BAD_REQUEST           = "BAD_REQUEST"
-	UNAUTHORIZED          = "UNAUTHORIZED"
-	FORBIDDEN             = "FORBIDDEN"
-	NOT_FOUND             = "NOT_FOUND"
-	UNPROCESSABLE_ENTITY  = "UNPROCESSABLE_ENTITY"
-	EXPECTATION_FAILED    = "EXPECTATION_FAILED"
-	INTERNAL_SERVER_ERROR = "INTERNAL_SERVER_ERROR"
+	OK                     = "OK"
+	CREATED                = "CREATED"
+	ACCEPTED               = "ACCEPTED"
+	BAD_REQUEST            = "BAD_REQUEST"
+	UNAUTHORIZED           = "UNAUTHORIZED"
+	FORBIDDEN              = "FORBIDDEN"
+	NOT_FOUND              = "NOT_FOUND"
+	PAYLOAD_TOO_LARGE      = "PAYLOAD_TOO_LARGE"
+	UNSUPPORTED_MEDIA_TYPE = "UNSUPPORTED_MEDIA_TYPE"
+	UNPROCESSABLE_ENTITY   = "UNPROCESSABLE_ENTITY"
+	TOO_MANY_REQUESTS      = "TOO_MANY_REQUESTS"
+	EXPECTATION_FAILED     = "EXPECTATION_FAILED"
+	INTERNAL_SERVER_ERROR  = "INTERNAL_SERVER_ERROR"
+	BAD_GATEWAY            = "BAD_GATEWAY"
 
 	// custom status
-	ALREADY_EXIST     = "ALREADY_EXIST"
-	ALREADY_SIGNED_IN = "ALREADY_SIGNED_IN"
+	ALREADY_EXIST               = "ALREADY_EXIST"
+	ALREADY_SIGNED_IN           = "ALREADY_SIGNED_IN"
+	ACCOUNT_LOCKED              = "ACCOUNT_LOCKED"
+	ACCOUNT_INACTIVE            = "ACCOUNT_INACTIVE"
+	PASSWORD_RESET_REQUIRED     = "PASSWORD_RESET_REQUIRED"
+	CONSENT_REQUIRED            = "CONSENT_REQUIRED"
+	REQUEST_IN_PROGRESS         = "REQUEST_IN_PROGRESS"
+	HUMAN_VERIFICATION_REQUIRED = "HUMAN_VERIFICATION_REQUIRED"
+	HUMAN_VERIFICATION_FAILED   = "HUMAN_VERIFICATION_FAILED"
+	HUMAN_CHALLENGE_REQUIRED    = "HUMAN_CHALLENGE_REQUIRED"
 )
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+type gcsStorage struct {
+	bucket  *storage.BucketHandle
+	baseURL string
+}
+
+// NewGCSStorage returns the storage that keeps the objects in the google cloud storage's bucket. The default credentials are used when the service account is empty.
+// The objects are served from https://storage.googleapis.com/<bucket> when the base url is empty.
+func NewGCSStorage(ctx context.Context, bucket, baseURL string, serviceAccount []byte) (ObjectStorage, error) {
+	opts := []option.ClientOption{}
+	if len(serviceAccount) > 0 {
+		opts = append(opts, option.WithCredentialsJSON(serviceAccount))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://storage.googleapis.com/%s", bucket)
+	}
+
+	return &gcsStorage{
+		bucket:  client.Bucket(bucket),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put implements ObjectStorage.
+func (s *gcsStorage) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	w.CacheControl = "public, max-age=86400"
+
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// Delete implements ObjectStorage.
+func (s *gcsStorage) Delete(ctx context.Context, key string) error {
+	err := s.bucket.Object(key).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// URL implements ObjectStorage.
+func (s *gcsStorage) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s.baseURL, strings.TrimPrefix(key, "/"))
+}
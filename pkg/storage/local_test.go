@@ -0,0 +1,47 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/storage"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	s := storage.NewLocalStorage(dir, "http://localhost:9000/files/")
+
+	assert.NoError(t, s.Put(ctx, "avatars/customer/1/64.jpg", strings.NewReader("first"), "image/jpeg"))
+	assert.NoError(t, s.Put(ctx, "avatars/customer/1/64.jpg", strings.NewReader("second"), "image/jpeg"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "avatars", "customer", "1", "64.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+
+	assert.Equal(t, "http://localhost:9000/files/avatars/customer/1/64.jpg", s.URL("avatars/customer/1/64.jpg"))
+
+	assert.NoError(t, s.Delete(ctx, "avatars/customer/1/64.jpg"))
+	assert.NoError(t, s.Delete(ctx, "avatars/customer/1/64.jpg"))
+
+	_, err = os.Stat(filepath.Join(dir, "avatars", "customer", "1", "64.jpg"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalStorageKeepsObjectsInsideDirectory(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	s := storage.NewLocalStorage(filepath.Join(dir, "files"), "http://localhost:9000/files")
+
+	assert.NoError(t, s.Put(ctx, "../../escaped.txt", strings.NewReader("content"), "text/plain"))
+
+	_, err := os.Stat(filepath.Join(dir, "escaped.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = os.Stat(filepath.Join(dir, "files", "escaped.txt"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// ObjectStorage stores the objects by their key. The key is a slash separated path, e.g. avatars/customer/1/512.jpg.
+type ObjectStorage interface {
+	// Put creates or replaces the object of the key.
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Delete removes the object of the key. It does not fail when the object does not exist.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public url of the object.
+	URL(key string) string
+}
@@ -0,0 +1,108 @@
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	stdDraw "image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"sort"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Errors.
+var (
+	ErrUnsupportedType error = fmt.Errorf("unsupported type of image")
+	ErrInvalidImage    error = fmt.Errorf("invalid image")
+	ErrTooManyPixels   error = fmt.Errorf("image has too many pixels")
+)
+
+// MaxPixels is the largest image that is decoded, it prevents a small file from being expanded into a huge image.
+const MaxPixels = 50_000_000
+
+// Quality is the quality of the encoded thumbnails.
+const Quality = 85
+
+// ContentTypes are the supported types of image.
+var ContentTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// ContentType returns the type of the image that is sniffed from its content, the declared type is not trusted.
+func ContentType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	for _, ct := range ContentTypes {
+		if ct == contentType {
+			return contentType, nil
+		}
+	}
+
+	return "", ErrUnsupportedType
+}
+
+// Generate returns the square thumbnails of the image in jpeg format keyed by their size. The image is cropped at its center.
+// The thumbnails are re-encoded from the pixels only, so the metadata of the image, e.g. exif, is not kept.
+func Generate(data []byte, sizes []int) (map[int][]byte, error) {
+	contentType, err := ContentType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	sorted := append([]int{}, sizes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	// the largest thumbnail is made from the image, the smaller ones are made from the larger one.
+	largest := square(src, sorted[0])
+	if contentType == "image/jpeg" {
+		largest = orient(largest, orientation(data))
+	}
+
+	thumbnails := make(map[int][]byte, len(sorted))
+	for _, size := range sorted {
+		img := largest
+		if size != sorted[0] {
+			img = square(largest, size)
+		}
+
+		buff := new(bytes.Buffer)
+		if err := jpeg.Encode(buff, img, &jpeg.Options{Quality: Quality}); err != nil {
+			return nil, err
+		}
+		thumbnails[size] = buff.Bytes()
+	}
+
+	return thumbnails, nil
+}
+
+// square crops the center of the image and scales it to the size. The transparent pixels are drawn on white, because jpeg has no alpha channel.
+func square(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2))
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	stdDraw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, stdDraw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+
+	return dst
+}
@@ -0,0 +1,107 @@
+package thumbnail_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/thumbnail"
+)
+
+var (
+	red  = color.RGBA{R: 255, A: 255}
+	blue = color.RGBA{B: 255, A: 255}
+)
+
+// halves returns a landscape image whose left half is red and right half is blue.
+func halves() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 80, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 80; x++ {
+			if x < 40 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+
+	return img
+}
+
+// withOrientation inserts exif segment with the orientation after SOI of the jpeg.
+func withOrientation(data []byte, o uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, o)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000 && g < 0x4000
+}
+
+func TestGenerate(t *testing.T) {
+	buff := new(bytes.Buffer)
+	assert.NoError(t, png.Encode(buff, halves()))
+
+	thumbnails, err := thumbnail.Generate(buff.Bytes(), []int{8, 16})
+	assert.NoError(t, err)
+	assert.Len(t, thumbnails, 2)
+
+	for size, data := range thumbnails {
+		img, format, err := image.Decode(bytes.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+	}
+
+	img, _ := jpeg.Decode(bytes.NewReader(thumbnails[16]))
+	assert.True(t, isRed(img.At(2, 8)))
+	assert.True(t, isBlue(img.At(13, 8)))
+}
+
+func TestGenerateAppliesOrientation(t *testing.T) {
+	buff := new(bytes.Buffer)
+	assert.NoError(t, jpeg.Encode(buff, halves(), &jpeg.Options{Quality: 100}))
+
+	// rotated 90 degree clockwise, the left half is on top.
+	thumbnails, err := thumbnail.Generate(withOrientation(buff.Bytes(), 6), []int{16})
+	assert.NoError(t, err)
+
+	img, _ := jpeg.Decode(bytes.NewReader(thumbnails[16]))
+	assert.True(t, isRed(img.At(8, 2)))
+	assert.True(t, isBlue(img.At(8, 13)))
+
+	// the exif is not kept.
+	assert.False(t, bytes.Contains(thumbnails[16], []byte("Exif")))
+}
+
+func TestGenerateRejectsInvalidImage(t *testing.T) {
+	_, err := thumbnail.Generate([]byte("<html><body>not an image</body></html>"), []int{16})
+	assert.ErrorIs(t, err, thumbnail.ErrUnsupportedType)
+
+	_, err = thumbnail.Generate([]byte("\x89PNG\r\n\x1a\ntruncated"), []int{16})
+	assert.ErrorIs(t, err, thumbnail.ErrInvalidImage)
+}
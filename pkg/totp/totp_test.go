@@ -0,0 +1,62 @@
+package totp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/totp"
+)
+
+// secret is the base32 form of the RFC 6238 sha1 seed "12345678901234567890".
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	// the expected values are the last 6 digits of the RFC 6238 appendix B test vectors.
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range cases {
+		code, err := totp.GenerateCode(secret, time.Unix(unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, "code at %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := totp.GenerateCode(secret, now)
+
+	t.Run("accepts the code of the current step", func(t *testing.T) {
+		step, ok := totp.Validate(secret, code, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now), step)
+	})
+	t.Run("accepts the code of the previous step within the skew", func(t *testing.T) {
+		_, ok := totp.Validate(secret, code, now.Add(totp.Period*time.Second), 1)
+		assert.True(t, ok)
+	})
+	t.Run("rejects the code out of the skew", func(t *testing.T) {
+		_, ok := totp.Validate(secret, code, now.Add(3*totp.Period*time.Second), 1)
+		assert.False(t, ok)
+	})
+	t.Run("rejects a malformed code", func(t *testing.T) {
+		_, ok := totp.Validate(secret, "12345", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	s1, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	s2, _ := totp.GenerateSecret()
+
+	assert.Len(t, s1, 32)
+	assert.NotEqual(t, s1, s2)
+}
@@ -0,0 +1,27 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// BirthDateLayout is the format of the birth date.
+const BirthDateLayout = "2006-01-02"
+
+// maxAge is the oldest age that is accepted as a birth date.
+const maxAge = 120
+
+// validateBirthDate checks that the field is a date in the past that is not older than the max age.
+//
+// Usage: `validate:"birth_date"`
+func validateBirthDate(fl validator.FieldLevel) bool {
+	birthDate, err := time.Parse(BirthDateLayout, fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	now := time.Now()
+
+	return !birthDate.After(now) && birthDate.After(now.AddDate(-maxAge, 0, 0))
+}
@@ -0,0 +1,41 @@
+package validator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/validator"
+)
+
+type profileRequest struct {
+	BirthDate *string `validate:"omitnil,eq=|birth_date"`
+}
+
+func TestBirthDateTag(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(validator.BirthDateLayout)
+	testCases := []struct {
+		name      string
+		birthDate *string
+		valid     bool
+	}{
+		{name: "omitted", birthDate: nil, valid: true},
+		{name: "cleared", birthDate: new(string), valid: true},
+		{name: "valid", birthDate: stringPtr("1990-01-31"), valid: true},
+		{name: "invalid format", birthDate: stringPtr("31-01-1990"), valid: false},
+		{name: "invalid date", birthDate: stringPtr("1990-02-30"), valid: false},
+		{name: "in the future", birthDate: &tomorrow, valid: false},
+		{name: "too old", birthDate: stringPtr("1890-01-31"), valid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validator.Get().Struct(profileRequest{BirthDate: tc.birthDate})
+			assert.Equal(t, tc.valid, err == nil)
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,170 @@
+package validator
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Reasons of the password that violates the policy.
+const (
+	PasswordTooShort         = "TOO_SHORT"
+	PasswordTooLong          = "TOO_LONG"
+	PasswordTooFewCharacters = "TOO_FEW_CHARACTER_CLASSES"
+	PasswordContainsIdentity = "CONTAINS_IDENTITY"
+	PasswordCommon           = "COMMON_PASSWORD"
+)
+
+// identityMinLength is the minimum length of an identity part to be checked, short parts give too many false positives.
+const identityMinLength = 3
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var (
+	commonPasswords         map[string]bool
+	commonPasswordsSyncOnce sync.Once
+)
+
+// PasswordPolicy describes the strength of the password.
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength bounds the cost of hashing the password.
+	MaxLength int
+	// MinCharacterClasses is the minimum number of character classes (uppercase, lowercase, digit and symbol) in the password.
+	MinCharacterClasses int
+}
+
+var passwordPolicy = PasswordPolicy{
+	MinLength:           8,
+	MaxLength:           128,
+	MinCharacterClasses: 3,
+}
+
+// SetPasswordPolicy replaces the default password policy. It must be called before the validator is used.
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicy = policy
+}
+
+type passwordIdentitiesContextKey struct{}
+
+// WithPasswordIdentities returns a copy of the context with identities, e.g. email and name, that must not be part of the password.
+// It is useful when the identities are not part of the validated struct.
+func WithPasswordIdentities(ctx context.Context, identities ...string) context.Context {
+	return context.WithValue(ctx, passwordIdentitiesContextKey{}, identities)
+}
+
+func loadCommonPasswords() map[string]bool {
+	commonPasswordsSyncOnce.Do(func() {
+		commonPasswords = make(map[string]bool)
+		scanner := bufio.NewScanner(strings.NewReader(commonPasswordsFile))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			commonPasswords[strings.ToLower(line)] = true
+		}
+	})
+
+	return commonPasswords
+}
+
+// CheckPassword returns the reasons of the password that violates the policy. The password is valid when there is no reason.
+func CheckPassword(plain string, identities ...string) []string {
+	reasons := []string{}
+
+	length := utf8.RuneCountInString(plain)
+	if length < passwordPolicy.MinLength {
+		reasons = append(reasons, PasswordTooShort)
+	}
+	if passwordPolicy.MaxLength > 0 && length > passwordPolicy.MaxLength {
+		reasons = append(reasons, PasswordTooLong)
+	}
+
+	var upper, lower, digit, symbol int
+	for _, r := range plain {
+		switch {
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	if upper+lower+digit+symbol < passwordPolicy.MinCharacterClasses {
+		reasons = append(reasons, PasswordTooFewCharacters)
+	}
+
+	lowerPlain := strings.ToLower(plain)
+	if containsIdentity(lowerPlain, identities) {
+		reasons = append(reasons, PasswordContainsIdentity)
+	}
+
+	if loadCommonPasswords()[lowerPlain] {
+		reasons = append(reasons, PasswordCommon)
+	}
+
+	return reasons
+}
+
+func containsIdentity(lowerPlain string, identities []string) bool {
+	for _, identity := range identities {
+		identity = strings.ToLower(identity)
+		// only the local part of the email is meaningful.
+		if at := strings.LastIndex(identity, "@"); at >= 0 {
+			identity = identity[:at]
+		}
+
+		parts := strings.FieldsFunc(identity, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		parts = append(parts, identity)
+
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) < identityMinLength {
+				continue
+			}
+			if strings.Contains(lowerPlain, part) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// passwordIdentities collects the identities from the fields of the param, e.g. `validate:"password=Email Name"`, and from the context.
+func passwordIdentities(ctx context.Context, parent reflect.Value, param string) []string {
+	identities, _ := ctx.Value(passwordIdentitiesContextKey{}).([]string)
+
+	for parent.Kind() == reflect.Ptr {
+		parent = parent.Elem()
+	}
+	if parent.Kind() != reflect.Struct {
+		return identities
+	}
+
+	for _, name := range strings.Fields(param) {
+		field := parent.FieldByName(name)
+		if field.IsValid() && field.Kind() == reflect.String {
+			identities = append(identities, field.String())
+		}
+	}
+
+	return identities
+}
+
+func validatePassword(ctx context.Context, fl validator.FieldLevel) bool {
+	return len(CheckPassword(fl.Field().String(), passwordIdentities(ctx, fl.Parent(), fl.Param())...)) == 0
+}
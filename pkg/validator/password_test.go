@@ -0,0 +1,59 @@
+package validator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/validator"
+)
+
+type signUpRequest struct {
+	Name     string
+	Email    string
+	Password string `validate:"required,password=Email Name"`
+}
+
+func TestCheckPassword(t *testing.T) {
+	testCases := []struct {
+		name       string
+		plain      string
+		identities []string
+		reasons    []string
+	}{
+		{name: "strong", plain: "Correct-Horse-42", reasons: []string{}},
+		{name: "too short", plain: "Ab1!", reasons: []string{validator.PasswordTooShort}},
+		{name: "too few character classes", plain: "abcdefghij", reasons: []string{validator.PasswordTooFewCharacters}},
+		{name: "common", plain: "P@ssw0rd", reasons: []string{validator.PasswordCommon}},
+		{name: "contains email", plain: "Patrick-2024!", identities: []string{"patrick.star@example.com"}, reasons: []string{validator.PasswordContainsIdentity}},
+		{name: "contains name", plain: "my-Bikini-Bottom-1", identities: []string{"Bikini Bottom"}, reasons: []string{validator.PasswordContainsIdentity}},
+		{name: "short identity part is ignored", plain: "Correct-Horse-42", identities: []string{"Al Or"}, reasons: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.reasons, validator.CheckPassword(tc.plain, tc.identities...))
+		})
+	}
+}
+
+func TestPasswordTag(t *testing.T) {
+	vld := validator.Get()
+	ctx := context.Background()
+
+	req := signUpRequest{Name: "Squidward", Email: "squidward@example.com", Password: "Squidward-1"}
+	err := vld.StructCtx(ctx, req)
+	assert.Error(t, err)
+
+	vErr := validator.NewValidationError(ctx, req, err)
+	assert.Equal(t, []validator.Violation{
+		{Field: "Password", Tag: "password", Reasons: []string{validator.PasswordContainsIdentity}},
+	}, vErr.Violations)
+	assert.NotContains(t, vErr.Error(), req.Password)
+
+	req.Password = "Clarinet-Solo-7"
+	assert.NoError(t, vld.StructCtx(ctx, req))
+
+	ctx = validator.WithPasswordIdentities(ctx, "clarinet")
+	assert.Error(t, vld.StructCtx(ctx, req))
+}
@@ -1,6 +1,10 @@
 package validator
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/go-playground/validator/v10"
@@ -13,6 +17,8 @@ var (
 
 func new() *validator.Validate {
 	vld := validator.New()
+	vld.RegisterValidationCtx("password", validatePassword)
+	vld.RegisterValidation("birth_date", validateBirthDate)
 
 	return vld
 }
@@ -24,3 +30,52 @@ func Get() *validator.Validate {
 
 	return vld
 }
+
+// Violation is the machine readable detail of a field that fails the validation.
+type Violation struct {
+	Field   string   `json:"field"`
+	Tag     string   `json:"tag"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// ValidationError is the error of a struct validation along with its violations.
+type ValidationError struct {
+	Message    string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// NewValidationError describes the error that is returned by validating the payload. The value of a password field is never written to the message.
+func NewValidationError(ctx context.Context, payload interface{}, err error) *ValidationError {
+	errorFields, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return &ValidationError{Message: err.Error()}
+	}
+
+	errMessages := make([]string, len(errorFields))
+	violations := make([]Violation, len(errorFields))
+
+	for k, errorField := range errorFields {
+		violations[k] = Violation{
+			Field: errorField.Field(),
+			Tag:   errorField.Tag(),
+		}
+
+		if errorField.Tag() == "password" {
+			plain, _ := errorField.Value().(string)
+			violations[k].Reasons = CheckPassword(plain, passwordIdentities(ctx, reflect.ValueOf(payload), errorField.Param())...)
+			errMessages[k] = fmt.Sprintf("invalid '%s' (%s)", errorField.Field(), strings.Join(violations[k].Reasons, ", "))
+			continue
+		}
+
+		errMessages[k] = fmt.Sprintf("invalid '%s' with value '%v'", errorField.Field(), errorField.Value())
+	}
+
+	return &ValidationError{
+		Message:    strings.Join(errMessages, ", "),
+		Violations: violations,
+	}
+}
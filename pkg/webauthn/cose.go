@@ -0,0 +1,109 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// The algorithms of COSE that are supported.
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+const (
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// coseKey is the public key in COSE format. The meaning of the negative labels depends on the type of the key.
+type coseKey struct {
+	KeyType   int             `cbor:"1,keyasint"`
+	Algorithm int             `cbor:"3,keyasint"`
+	Param1    cbor.RawMessage `cbor:"-1,keyasint"`
+	Param2    []byte          `cbor:"-2,keyasint"`
+	Param3    []byte          `cbor:"-3,keyasint"`
+}
+
+type publicKey struct {
+	algorithm int
+	key       crypto.PublicKey
+}
+
+func parsePublicKey(data []byte) (publicKey, error) {
+	var k coseKey
+	if err := cbor.Unmarshal(data, &k); err != nil {
+		return publicKey{}, ErrInvalidResponse
+	}
+
+	switch {
+	case k.KeyType == coseKeyTypeEC2 && k.Algorithm == AlgES256:
+		var curve int
+		if err := cbor.Unmarshal(k.Param1, &curve); err != nil || curve != coseCurveP256 {
+			return publicKey{}, ErrUnsupportedAlgorithm
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(k.Param2),
+			Y:     new(big.Int).SetBytes(k.Param3),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return publicKey{}, ErrInvalidResponse
+		}
+
+		return publicKey{algorithm: AlgES256, key: key}, nil
+	case k.KeyType == coseKeyTypeOKP && k.Algorithm == AlgEdDSA:
+		var curve int
+		if err := cbor.Unmarshal(k.Param1, &curve); err != nil || curve != coseCurveEd25519 {
+			return publicKey{}, ErrUnsupportedAlgorithm
+		}
+
+		if len(k.Param2) != ed25519.PublicKeySize {
+			return publicKey{}, ErrInvalidResponse
+		}
+
+		return publicKey{algorithm: AlgEdDSA, key: ed25519.PublicKey(k.Param2)}, nil
+	case k.KeyType == coseKeyTypeRSA && k.Algorithm == AlgRS256:
+		var modulus []byte
+		if err := cbor.Unmarshal(k.Param1, &modulus); err != nil || len(modulus) == 0 {
+			return publicKey{}, ErrInvalidResponse
+		}
+
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(k.Param2).Int64()),
+		}
+
+		return publicKey{algorithm: AlgRS256, key: key}, nil
+	}
+
+	return publicKey{}, ErrUnsupportedAlgorithm
+}
+
+func (pk publicKey) verify(data, signature []byte) bool {
+	switch pk.algorithm {
+	case AlgES256:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(pk.key.(*ecdsa.PublicKey), digest[:], signature)
+	case AlgEdDSA:
+		return ed25519.Verify(pk.key.(ed25519.PublicKey), data, signature)
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(pk.key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	}
+
+	return false
+}
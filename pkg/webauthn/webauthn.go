@@ -0,0 +1,455 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Errors.
+var (
+	ErrInvalidResponse       error = fmt.Errorf("invalid webauthn response")
+	ErrInvalidChallenge      error = fmt.Errorf("invalid webauthn challenge")
+	ErrInvalidOrigin         error = fmt.Errorf("invalid webauthn origin")
+	ErrInvalidRelyingParty   error = fmt.Errorf("invalid webauthn relying party")
+	ErrUserNotPresent        error = fmt.Errorf("webauthn user is not present")
+	ErrUserNotVerified       error = fmt.Errorf("webauthn user is not verified")
+	ErrInvalidSignature      error = fmt.Errorf("invalid webauthn signature")
+	ErrUnsupportedAlgorithm  error = fmt.Errorf("unsupported webauthn public key algorithm")
+	ErrSignCountNotIncreased error = fmt.Errorf("webauthn sign count is not increased, the authenticator may have been cloned")
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+
+	// ChallengeLength is the length of the random challenge in bytes.
+	ChallengeLength = 32
+)
+
+// Config is the relying party. The origins are the web or app origins that are allowed to run the ceremonies, e.g. https://ticketmaster.example.
+type Config struct {
+	RPID    string
+	RPName  string
+	Origins []string
+	Timeout time.Duration
+}
+
+// RelyingParty runs the registration and the assertion ceremonies. The attestation is not requested, so the authenticator is trusted by its public key only.
+type RelyingParty struct {
+	cfg Config
+}
+
+func NewRelyingParty(cfg Config) *RelyingParty {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Minute * 5
+	}
+
+	return &RelyingParty{
+		cfg: cfg,
+	}
+}
+
+// Timeout is how long the ceremony may take, the challenge should be kept as long.
+func (rp *RelyingParty) Timeout() time.Duration {
+	return rp.cfg.Timeout
+}
+
+// User is the account that owns the credential. The id must not contain any personal information.
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// Credential is the public key credential that has been registered.
+type Credential struct {
+	ID         []byte
+	PublicKey  []byte
+	SignCount  uint32
+	AAGUID     []byte
+	Transports []string
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type credentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is passed to navigator.credentials.create() as the publicKey option. The binary fields are base64url encoded.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     rpEntity               `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []credentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is passed to navigator.credentials.get() as the publicKey option. The credentials are discovered by the authenticator when none is allowed.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []credentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationResponse is the PublicKeyCredential that is returned by navigator.credentials.create().
+type AttestationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the PublicKeyCredential that is returned by navigator.credentials.get().
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// CredentialID returns the raw id of the asserted credential.
+func (resp AssertionResponse) CredentialID() ([]byte, error) {
+	id, err := decode(resp.RawID)
+	if err != nil || len(id) == 0 {
+		return nil, ErrInvalidResponse
+	}
+
+	return id, nil
+}
+
+// UserHandle returns the user's id of the asserted credential, it is empty when the authenticator does not return it.
+func (resp AssertionResponse) UserHandle() ([]byte, error) {
+	if resp.Response.UserHandle == "" {
+		return nil, nil
+	}
+
+	return decode(resp.Response.UserHandle)
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type attestationObject struct {
+	Format   string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// GenerateChallenge returns a random challenge in base64url encoding.
+func GenerateChallenge() (string, error) {
+	b := make([]byte, ChallengeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreationOptions returns the options of the registration ceremony. The existing credentials are excluded, so the same authenticator is not registered twice.
+func (rp *RelyingParty) CreationOptions(user User, challenge string, existing []Credential) CreationOptions {
+	return CreationOptions{
+		Challenge: challenge,
+		RP: rpEntity{
+			ID:   rp.cfg.RPID,
+			Name: rp.cfg.RPName,
+		},
+		User: userEntity{
+			ID:          base64.RawURLEncoding.EncodeToString(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams: []credentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            rp.cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(existing),
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions returns the options of the assertion ceremony.
+func (rp *RelyingParty) RequestOptions(challenge string, allowed []Credential) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.cfg.RPID,
+		Timeout:          rp.cfg.Timeout.Milliseconds(),
+		AllowCredentials: descriptors(allowed),
+		UserVerification: "required",
+	}
+}
+
+// VerifyRegistration verifies the response of the registration ceremony and returns the new credential.
+func (rp *RelyingParty) VerifyRegistration(resp AttestationResponse, challenge string) (Credential, error) {
+	clientDataJSON, err := decode(resp.Response.ClientDataJSON)
+	if err != nil {
+		return Credential{}, ErrInvalidResponse
+	}
+
+	if err := rp.verifyClientData(clientDataJSON, ceremonyCreate, challenge); err != nil {
+		return Credential{}, err
+	}
+
+	rawAttestationObject, err := decode(resp.Response.AttestationObject)
+	if err != nil {
+		return Credential{}, ErrInvalidResponse
+	}
+
+	var attObj attestationObject
+	if err := cbor.Unmarshal(rawAttestationObject, &attObj); err != nil {
+		return Credential{}, ErrInvalidResponse
+	}
+
+	authData, err := parseAuthenticatorData(attObj.AuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return Credential{}, err
+	}
+
+	if authData.Flags&flagAttestedData == 0 {
+		return Credential{}, ErrInvalidResponse
+	}
+
+	if _, err := parsePublicKey(authData.PublicKey); err != nil {
+		return Credential{}, err
+	}
+
+	cred := Credential{
+		ID:         authData.CredentialID,
+		PublicKey:  authData.PublicKey,
+		SignCount:  authData.SignCount,
+		AAGUID:     authData.AAGUID,
+		Transports: resp.Response.Transports,
+	}
+
+	return cred, nil
+}
+
+// VerifyAssertion verifies the response of the assertion ceremony by the registered credential and returns the new sign count.
+func (rp *RelyingParty) VerifyAssertion(resp AssertionResponse, challenge string, cred Credential) (uint32, error) {
+	clientDataJSON, err := decode(resp.Response.ClientDataJSON)
+	if err != nil {
+		return 0, ErrInvalidResponse
+	}
+
+	if err := rp.verifyClientData(clientDataJSON, ceremonyGet, challenge); err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := decode(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, ErrInvalidResponse
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return 0, err
+	}
+
+	signature, err := decode(resp.Response.Signature)
+	if err != nil {
+		return 0, ErrInvalidResponse
+	}
+
+	publicKey, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+
+	if !publicKey.verify(signed, signature) {
+		return 0, ErrInvalidSignature
+	}
+
+	// the authenticators that do not count always return zero.
+	if (authData.SignCount != 0 || cred.SignCount != 0) && authData.SignCount <= cred.SignCount {
+		return 0, ErrSignCountNotIncreased
+	}
+
+	return authData.SignCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony, challenge string) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return ErrInvalidResponse
+	}
+
+	if cd.Type != ceremony {
+		return ErrInvalidResponse
+	}
+
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return ErrInvalidChallenge
+	}
+
+	for _, origin := range rp.cfg.Origins {
+		if cd.Origin == origin {
+			return nil
+		}
+	}
+
+	return ErrInvalidOrigin
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.cfg.RPID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return ErrInvalidRelyingParty
+	}
+
+	if authData.Flags&flagUserPresent == 0 {
+		return ErrUserNotPresent
+	}
+
+	if authData.Flags&flagUserVerified == 0 {
+		return ErrUserNotVerified
+	}
+
+	return nil
+}
+
+func parseAuthenticatorData(data []byte) (authenticatorData, error) {
+	// rp id hash (32), flags (1) and sign count (4).
+	if len(data) < 37 {
+		return authenticatorData{}, ErrInvalidResponse
+	}
+
+	authData := authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if authData.Flags&flagAttestedData == 0 {
+		return authData, nil
+	}
+
+	// aaguid (16) and the length of credential id (2).
+	rest := data[37:]
+	if len(rest) < 18 {
+		return authenticatorData{}, ErrInvalidResponse
+	}
+
+	authData.AAGUID = rest[:16]
+	credentialIDLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < credentialIDLength {
+		return authenticatorData{}, ErrInvalidResponse
+	}
+
+	authData.CredentialID = rest[:credentialIDLength]
+	rest = rest[credentialIDLength:]
+
+	// the public key is followed by the extensions, so only the first item is read.
+	var publicKey cbor.RawMessage
+	dec := cbor.NewDecoder(bytes.NewReader(rest))
+	if err := dec.Decode(&publicKey); err != nil {
+		return authenticatorData{}, ErrInvalidResponse
+	}
+
+	authData.PublicKey = rest[:dec.NumBytesRead()]
+
+	return authData, nil
+}
+
+func descriptors(creds []Credential) []credentialDescriptor {
+	result := make([]credentialDescriptor, len(creds))
+	for k, cred := range creds {
+		result[k] = credentialDescriptor{
+			Type:       "public-key",
+			ID:         base64.RawURLEncoding.EncodeToString(cred.ID),
+			Transports: cred.Transports,
+		}
+	}
+
+	return result
+}
+
+// decode accepts both padded and unpadded base64url, since the clients are not consistent.
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(trimPadding(s))
+}
+
+func trimPadding(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+
+	return s
+}
@@ -0,0 +1,158 @@
+package webauthn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsel-ticketmaster/tm-user/pkg/webauthn"
+)
+
+const (
+	rpID   = "ticketmaster.example"
+	origin = "https://ticketmaster.example"
+)
+
+type authenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	return &authenticator{key: key, credentialID: []byte("credential-id")}
+}
+
+func (a *authenticator) clientData(ceremony, challenge, o string) []byte {
+	buff, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": o})
+
+	return buff
+}
+
+func (a *authenticator) authData(flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+
+	if attested {
+		publicKey, _ := cbor.Marshal(map[int]interface{}{
+			1:  2,
+			3:  webauthn.AlgES256,
+			-1: 1,
+			-2: a.key.X.FillBytes(make([]byte, 32)),
+			-3: a.key.Y.FillBytes(make([]byte, 32)),
+		})
+
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, publicKey...)
+	}
+
+	return data
+}
+
+func (a *authenticator) create(challenge string) webauthn.AttestationResponse {
+	attestationObject, _ := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x45, true),
+	})
+
+	var resp webauthn.AttestationResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(a.credentialID)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", challenge, origin))
+	resp.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(attestationObject)
+
+	return resp
+}
+
+func (a *authenticator) get(t *testing.T, challenge string, o string) webauthn.AssertionResponse {
+	a.signCount++
+	authData := a.authData(0x05, false)
+	clientData := a.clientData("webauthn.get", challenge, o)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	assert.NoError(t, err)
+
+	var resp webauthn.AssertionResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(a.credentialID)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientData)
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+
+	return resp
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := webauthn.NewRelyingParty(webauthn.Config{RPID: rpID, RPName: "Ticket Master", Origins: []string{origin}})
+	a := newAuthenticator(t)
+
+	challenge, err := webauthn.GenerateChallenge()
+	assert.NoError(t, err)
+
+	opts := rp.CreationOptions(webauthn.User{ID: []byte("user"), Name: "john@example.com", DisplayName: "John"}, challenge, nil)
+	assert.Equal(t, rpID, opts.RP.ID)
+	assert.Equal(t, "none", opts.Attestation)
+
+	cred, err := rp.VerifyRegistration(a.create(challenge), challenge)
+	assert.NoError(t, err)
+	assert.Equal(t, a.credentialID, cred.ID)
+
+	challenge, _ = webauthn.GenerateChallenge()
+	resp := a.get(t, challenge, origin)
+
+	credentialID, err := resp.CredentialID()
+	assert.NoError(t, err)
+	assert.Equal(t, a.credentialID, credentialID)
+
+	signCount, err := rp.VerifyAssertion(resp, challenge, cred)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), signCount)
+
+	// the replayed assertion does not increase the sign count.
+	cred.SignCount = signCount
+	_, err = rp.VerifyAssertion(resp, challenge, cred)
+	assert.ErrorIs(t, err, webauthn.ErrSignCountNotIncreased)
+}
+
+func TestVerifyRejectsInvalidResponse(t *testing.T) {
+	rp := webauthn.NewRelyingParty(webauthn.Config{RPID: rpID, Origins: []string{origin}})
+	a := newAuthenticator(t)
+
+	challenge, _ := webauthn.GenerateChallenge()
+	_, err := rp.VerifyRegistration(a.create(challenge), "another-challenge")
+	assert.ErrorIs(t, err, webauthn.ErrInvalidChallenge)
+
+	cred, err := rp.VerifyRegistration(a.create(challenge), challenge)
+	assert.NoError(t, err)
+
+	_, err = rp.VerifyAssertion(a.get(t, challenge, "https://phishing.example"), challenge, cred)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidOrigin)
+
+	resp := a.get(t, challenge, origin)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString([]byte("invalid"))
+	_, err = rp.VerifyAssertion(resp, challenge, cred)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidSignature)
+
+	other := webauthn.NewRelyingParty(webauthn.Config{RPID: "other.example", Origins: []string{origin}})
+	_, err = other.VerifyAssertion(a.get(t, challenge, origin), challenge, cred)
+	assert.ErrorIs(t, err, webauthn.ErrInvalidRelyingParty)
+}